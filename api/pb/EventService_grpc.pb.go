@@ -19,14 +19,30 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EventService_CreateEvent_FullMethodName       = "/api.EventService/CreateEvent"
-	EventService_UpdateEvent_FullMethodName       = "/api.EventService/UpdateEvent"
-	EventService_DeleteEvent_FullMethodName       = "/api.EventService/DeleteEvent"
-	EventService_GetEvent_FullMethodName          = "/api.EventService/GetEvent"
-	EventService_ListEvents_FullMethodName        = "/api.EventService/ListEvents"
-	EventService_ListEventsByDay_FullMethodName   = "/api.EventService/ListEventsByDay"
-	EventService_ListEventsByWeek_FullMethodName  = "/api.EventService/ListEventsByWeek"
-	EventService_ListEventsByMonth_FullMethodName = "/api.EventService/ListEventsByMonth"
+	EventService_CreateEvent_FullMethodName         = "/api.EventService/CreateEvent"
+	EventService_UpdateEvent_FullMethodName         = "/api.EventService/UpdateEvent"
+	EventService_DeleteEvent_FullMethodName         = "/api.EventService/DeleteEvent"
+	EventService_GetEvent_FullMethodName            = "/api.EventService/GetEvent"
+	EventService_ListEvents_FullMethodName          = "/api.EventService/ListEvents"
+	EventService_SearchEvents_FullMethodName        = "/api.EventService/SearchEvents"
+	EventService_ListEventsInRange_FullMethodName   = "/api.EventService/ListEventsInRange"
+	EventService_ListEventsByDay_FullMethodName     = "/api.EventService/ListEventsByDay"
+	EventService_ListEventsByWeek_FullMethodName    = "/api.EventService/ListEventsByWeek"
+	EventService_ListEventsByMonth_FullMethodName   = "/api.EventService/ListEventsByMonth"
+	EventService_ListAllEvents_FullMethodName       = "/api.EventService/ListAllEvents"
+	EventService_CreateAPIKey_FullMethodName        = "/api.EventService/CreateAPIKey"
+	EventService_ListAPIKeys_FullMethodName         = "/api.EventService/ListAPIKeys"
+	EventService_DeleteAPIKey_FullMethodName        = "/api.EventService/DeleteAPIKey"
+	EventService_CreateCalendar_FullMethodName      = "/api.EventService/CreateCalendar"
+	EventService_GetCalendar_FullMethodName         = "/api.EventService/GetCalendar"
+	EventService_ListCalendars_FullMethodName       = "/api.EventService/ListCalendars"
+	EventService_UpdateCalendar_FullMethodName      = "/api.EventService/UpdateCalendar"
+	EventService_DeleteCalendar_FullMethodName      = "/api.EventService/DeleteCalendar"
+	EventService_ShareCalendar_FullMethodName       = "/api.EventService/ShareCalendar"
+	EventService_UnshareCalendar_FullMethodName     = "/api.EventService/UnshareCalendar"
+	EventService_ListCalendarACL_FullMethodName     = "/api.EventService/ListCalendarACL"
+	EventService_GetDefaultReminders_FullMethodName = "/api.EventService/GetDefaultReminders"
+	EventService_SetDefaultReminders_FullMethodName = "/api.EventService/SetDefaultReminders"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -38,9 +54,28 @@ type EventServiceClient interface {
 	DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*DeleteEventResponse, error)
 	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*GetEventResponse, error)
 	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*SearchEventsResponse, error)
+	ListEventsInRange(ctx context.Context, in *ListEventsInRangeRequest, opts ...grpc.CallOption) (*ListEventsInRangeResponse, error)
 	ListEventsByDay(ctx context.Context, in *ListEventsByDayRequest, opts ...grpc.CallOption) (*ListEventsByDayResponse, error)
 	ListEventsByWeek(ctx context.Context, in *ListEventsByWeekRequest, opts ...grpc.CallOption) (*ListEventsByWeekResponse, error)
 	ListEventsByMonth(ctx context.Context, in *ListEventsByMonthRequest, opts ...grpc.CallOption) (*ListEventsByMonthResponse, error)
+	ListAllEvents(ctx context.Context, in *ListAllEventsRequest, opts ...grpc.CallOption) (*ListAllEventsResponse, error)
+	// API key management; admins only.
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
+	DeleteAPIKey(ctx context.Context, in *DeleteAPIKeyRequest, opts ...grpc.CallOption) (*DeleteAPIKeyResponse, error)
+	// Calendars and sharing. Deleting, sharing and listing the ACL require the owner role.
+	CreateCalendar(ctx context.Context, in *CreateCalendarRequest, opts ...grpc.CallOption) (*CreateCalendarResponse, error)
+	GetCalendar(ctx context.Context, in *GetCalendarRequest, opts ...grpc.CallOption) (*GetCalendarResponse, error)
+	ListCalendars(ctx context.Context, in *ListCalendarsRequest, opts ...grpc.CallOption) (*ListCalendarsResponse, error)
+	UpdateCalendar(ctx context.Context, in *UpdateCalendarRequest, opts ...grpc.CallOption) (*UpdateCalendarResponse, error)
+	DeleteCalendar(ctx context.Context, in *DeleteCalendarRequest, opts ...grpc.CallOption) (*DeleteCalendarResponse, error)
+	ShareCalendar(ctx context.Context, in *ShareCalendarRequest, opts ...grpc.CallOption) (*ShareCalendarResponse, error)
+	UnshareCalendar(ctx context.Context, in *UnshareCalendarRequest, opts ...grpc.CallOption) (*UnshareCalendarResponse, error)
+	ListCalendarACL(ctx context.Context, in *ListCalendarACLRequest, opts ...grpc.CallOption) (*ListCalendarACLResponse, error)
+	// The caller's reminders for events without reminders of their own.
+	GetDefaultReminders(ctx context.Context, in *GetDefaultRemindersRequest, opts ...grpc.CallOption) (*GetDefaultRemindersResponse, error)
+	SetDefaultReminders(ctx context.Context, in *SetDefaultRemindersRequest, opts ...grpc.CallOption) (*SetDefaultRemindersResponse, error)
 }
 
 type eventServiceClient struct {
@@ -101,6 +136,26 @@ func (c *eventServiceClient) ListEvents(ctx context.Context, in *ListEventsReque
 	return out, nil
 }
 
+func (c *eventServiceClient) SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*SearchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_SearchEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListEventsInRange(ctx context.Context, in *ListEventsInRangeRequest, opts ...grpc.CallOption) (*ListEventsInRangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsInRangeResponse)
+	err := c.cc.Invoke(ctx, EventService_ListEventsInRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) ListEventsByDay(ctx context.Context, in *ListEventsByDayRequest, opts ...grpc.CallOption) (*ListEventsByDayResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListEventsByDayResponse)
@@ -131,6 +186,146 @@ func (c *eventServiceClient) ListEventsByMonth(ctx context.Context, in *ListEven
 	return out, nil
 }
 
+func (c *eventServiceClient) ListAllEvents(ctx context.Context, in *ListAllEventsRequest, opts ...grpc.CallOption) (*ListAllEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAllEventsResponse)
+	err := c.cc.Invoke(ctx, EventService_ListAllEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, EventService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPIKeysResponse)
+	err := c.cc.Invoke(ctx, EventService_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) DeleteAPIKey(ctx context.Context, in *DeleteAPIKeyRequest, opts ...grpc.CallOption) (*DeleteAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAPIKeyResponse)
+	err := c.cc.Invoke(ctx, EventService_DeleteAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) CreateCalendar(ctx context.Context, in *CreateCalendarRequest, opts ...grpc.CallOption) (*CreateCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_CreateCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetCalendar(ctx context.Context, in *GetCalendarRequest, opts ...grpc.CallOption) (*GetCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_GetCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListCalendars(ctx context.Context, in *ListCalendarsRequest, opts ...grpc.CallOption) (*ListCalendarsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCalendarsResponse)
+	err := c.cc.Invoke(ctx, EventService_ListCalendars_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) UpdateCalendar(ctx context.Context, in *UpdateCalendarRequest, opts ...grpc.CallOption) (*UpdateCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_UpdateCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) DeleteCalendar(ctx context.Context, in *DeleteCalendarRequest, opts ...grpc.CallOption) (*DeleteCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_DeleteCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ShareCalendar(ctx context.Context, in *ShareCalendarRequest, opts ...grpc.CallOption) (*ShareCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_ShareCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) UnshareCalendar(ctx context.Context, in *UnshareCalendarRequest, opts ...grpc.CallOption) (*UnshareCalendarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnshareCalendarResponse)
+	err := c.cc.Invoke(ctx, EventService_UnshareCalendar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) ListCalendarACL(ctx context.Context, in *ListCalendarACLRequest, opts ...grpc.CallOption) (*ListCalendarACLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCalendarACLResponse)
+	err := c.cc.Invoke(ctx, EventService_ListCalendarACL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) GetDefaultReminders(ctx context.Context, in *GetDefaultRemindersRequest, opts ...grpc.CallOption) (*GetDefaultRemindersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDefaultRemindersResponse)
+	err := c.cc.Invoke(ctx, EventService_GetDefaultReminders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventServiceClient) SetDefaultReminders(ctx context.Context, in *SetDefaultRemindersRequest, opts ...grpc.CallOption) (*SetDefaultRemindersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetDefaultRemindersResponse)
+	err := c.cc.Invoke(ctx, EventService_SetDefaultReminders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventServiceServer is the server API for EventService service.
 // All implementations must embed UnimplementedEventServiceServer
 // for forward compatibility.
@@ -140,9 +335,28 @@ type EventServiceServer interface {
 	DeleteEvent(context.Context, *DeleteEventRequest) (*DeleteEventResponse, error)
 	GetEvent(context.Context, *GetEventRequest) (*GetEventResponse, error)
 	ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error)
+	SearchEvents(context.Context, *SearchEventsRequest) (*SearchEventsResponse, error)
+	ListEventsInRange(context.Context, *ListEventsInRangeRequest) (*ListEventsInRangeResponse, error)
 	ListEventsByDay(context.Context, *ListEventsByDayRequest) (*ListEventsByDayResponse, error)
 	ListEventsByWeek(context.Context, *ListEventsByWeekRequest) (*ListEventsByWeekResponse, error)
 	ListEventsByMonth(context.Context, *ListEventsByMonthRequest) (*ListEventsByMonthResponse, error)
+	ListAllEvents(context.Context, *ListAllEventsRequest) (*ListAllEventsResponse, error)
+	// API key management; admins only.
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error)
+	DeleteAPIKey(context.Context, *DeleteAPIKeyRequest) (*DeleteAPIKeyResponse, error)
+	// Calendars and sharing. Deleting, sharing and listing the ACL require the owner role.
+	CreateCalendar(context.Context, *CreateCalendarRequest) (*CreateCalendarResponse, error)
+	GetCalendar(context.Context, *GetCalendarRequest) (*GetCalendarResponse, error)
+	ListCalendars(context.Context, *ListCalendarsRequest) (*ListCalendarsResponse, error)
+	UpdateCalendar(context.Context, *UpdateCalendarRequest) (*UpdateCalendarResponse, error)
+	DeleteCalendar(context.Context, *DeleteCalendarRequest) (*DeleteCalendarResponse, error)
+	ShareCalendar(context.Context, *ShareCalendarRequest) (*ShareCalendarResponse, error)
+	UnshareCalendar(context.Context, *UnshareCalendarRequest) (*UnshareCalendarResponse, error)
+	ListCalendarACL(context.Context, *ListCalendarACLRequest) (*ListCalendarACLResponse, error)
+	// The caller's reminders for events without reminders of their own.
+	GetDefaultReminders(context.Context, *GetDefaultRemindersRequest) (*GetDefaultRemindersResponse, error)
+	SetDefaultReminders(context.Context, *SetDefaultRemindersRequest) (*SetDefaultRemindersResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
 
@@ -168,6 +382,12 @@ func (UnimplementedEventServiceServer) GetEvent(context.Context, *GetEventReques
 func (UnimplementedEventServiceServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
 }
+func (UnimplementedEventServiceServer) SearchEvents(context.Context, *SearchEventsRequest) (*SearchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchEvents not implemented")
+}
+func (UnimplementedEventServiceServer) ListEventsInRange(context.Context, *ListEventsInRangeRequest) (*ListEventsInRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventsInRange not implemented")
+}
 func (UnimplementedEventServiceServer) ListEventsByDay(context.Context, *ListEventsByDayRequest) (*ListEventsByDayResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEventsByDay not implemented")
 }
@@ -177,6 +397,48 @@ func (UnimplementedEventServiceServer) ListEventsByWeek(context.Context, *ListEv
 func (UnimplementedEventServiceServer) ListEventsByMonth(context.Context, *ListEventsByMonthRequest) (*ListEventsByMonthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEventsByMonth not implemented")
 }
+func (UnimplementedEventServiceServer) ListAllEvents(context.Context, *ListAllEventsRequest) (*ListAllEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAllEvents not implemented")
+}
+func (UnimplementedEventServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedEventServiceServer) ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedEventServiceServer) DeleteAPIKey(context.Context, *DeleteAPIKeyRequest) (*DeleteAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAPIKey not implemented")
+}
+func (UnimplementedEventServiceServer) CreateCalendar(context.Context, *CreateCalendarRequest) (*CreateCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) GetCalendar(context.Context, *GetCalendarRequest) (*GetCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) ListCalendars(context.Context, *ListCalendarsRequest) (*ListCalendarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCalendars not implemented")
+}
+func (UnimplementedEventServiceServer) UpdateCalendar(context.Context, *UpdateCalendarRequest) (*UpdateCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) DeleteCalendar(context.Context, *DeleteCalendarRequest) (*DeleteCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) ShareCalendar(context.Context, *ShareCalendarRequest) (*ShareCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) UnshareCalendar(context.Context, *UnshareCalendarRequest) (*UnshareCalendarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnshareCalendar not implemented")
+}
+func (UnimplementedEventServiceServer) ListCalendarACL(context.Context, *ListCalendarACLRequest) (*ListCalendarACLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCalendarACL not implemented")
+}
+func (UnimplementedEventServiceServer) GetDefaultReminders(context.Context, *GetDefaultRemindersRequest) (*GetDefaultRemindersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDefaultReminders not implemented")
+}
+func (UnimplementedEventServiceServer) SetDefaultReminders(context.Context, *SetDefaultRemindersRequest) (*SetDefaultRemindersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDefaultReminders not implemented")
+}
 func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
 func (UnimplementedEventServiceServer) testEmbeddedByValue()                      {}
 
@@ -288,6 +550,42 @@ func _EventService_ListEvents_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_SearchEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).SearchEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_SearchEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).SearchEvents(ctx, req.(*SearchEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListEventsInRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsInRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListEventsInRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListEventsInRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListEventsInRange(ctx, req.(*ListEventsInRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_ListEventsByDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListEventsByDayRequest)
 	if err := dec(in); err != nil {
@@ -342,6 +640,258 @@ func _EventService_ListEventsByMonth_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_ListAllEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAllEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListAllEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListAllEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListAllEvents(ctx, req.(*ListAllEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListAPIKeys(ctx, req.(*ListAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_DeleteAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).DeleteAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_DeleteAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).DeleteAPIKey(ctx, req.(*DeleteAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_CreateCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).CreateCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_CreateCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).CreateCalendar(ctx, req.(*CreateCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetCalendar(ctx, req.(*GetCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListCalendars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCalendarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListCalendars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListCalendars_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListCalendars(ctx, req.(*ListCalendarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_UpdateCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).UpdateCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_UpdateCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).UpdateCalendar(ctx, req.(*UpdateCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_DeleteCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).DeleteCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_DeleteCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).DeleteCalendar(ctx, req.(*DeleteCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ShareCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ShareCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ShareCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ShareCalendar(ctx, req.(*ShareCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_UnshareCalendar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnshareCalendarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).UnshareCalendar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_UnshareCalendar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).UnshareCalendar(ctx, req.(*UnshareCalendarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_ListCalendarACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCalendarACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).ListCalendarACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_ListCalendarACL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).ListCalendarACL(ctx, req.(*ListCalendarACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_GetDefaultReminders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDefaultRemindersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetDefaultReminders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_GetDefaultReminders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetDefaultReminders(ctx, req.(*GetDefaultRemindersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventService_SetDefaultReminders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDefaultRemindersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).SetDefaultReminders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_SetDefaultReminders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).SetDefaultReminders(ctx, req.(*SetDefaultRemindersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EventService_ServiceDesc is the grpc.ServiceDesc for EventService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -369,6 +919,14 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListEvents",
 			Handler:    _EventService_ListEvents_Handler,
 		},
+		{
+			MethodName: "SearchEvents",
+			Handler:    _EventService_SearchEvents_Handler,
+		},
+		{
+			MethodName: "ListEventsInRange",
+			Handler:    _EventService_ListEventsInRange_Handler,
+		},
 		{
 			MethodName: "ListEventsByDay",
 			Handler:    _EventService_ListEventsByDay_Handler,
@@ -381,6 +939,62 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListEventsByMonth",
 			Handler:    _EventService_ListEventsByMonth_Handler,
 		},
+		{
+			MethodName: "ListAllEvents",
+			Handler:    _EventService_ListAllEvents_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _EventService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _EventService_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "DeleteAPIKey",
+			Handler:    _EventService_DeleteAPIKey_Handler,
+		},
+		{
+			MethodName: "CreateCalendar",
+			Handler:    _EventService_CreateCalendar_Handler,
+		},
+		{
+			MethodName: "GetCalendar",
+			Handler:    _EventService_GetCalendar_Handler,
+		},
+		{
+			MethodName: "ListCalendars",
+			Handler:    _EventService_ListCalendars_Handler,
+		},
+		{
+			MethodName: "UpdateCalendar",
+			Handler:    _EventService_UpdateCalendar_Handler,
+		},
+		{
+			MethodName: "DeleteCalendar",
+			Handler:    _EventService_DeleteCalendar_Handler,
+		},
+		{
+			MethodName: "ShareCalendar",
+			Handler:    _EventService_ShareCalendar_Handler,
+		},
+		{
+			MethodName: "UnshareCalendar",
+			Handler:    _EventService_UnshareCalendar_Handler,
+		},
+		{
+			MethodName: "ListCalendarACL",
+			Handler:    _EventService_ListCalendarACL_Handler,
+		},
+		{
+			MethodName: "GetDefaultReminders",
+			Handler:    _EventService_GetDefaultReminders_Handler,
+		},
+		{
+			MethodName: "SetDefaultReminders",
+			Handler:    _EventService_SetDefaultReminders_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "EventService.proto",
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Dendyator/calendar/internal/app"  //nolint
+	"github.com/Dendyator/calendar/internal/auth" //nolint
+	"github.com/google/uuid"                      //nolint
+)
+
+// runImport implements the "import" subcommand:
+//
+//	calendar --config=<file> import --user=<uuid> [--calendar=<uuid>] [--tz=<zone>] <file.ics|->
+//
+// It imports the file as the given user and prints the result as JSON.
+func runImport(application *app.App, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	userFlag := flags.String("user", "", "UUID of the user importing the events")
+	calendarFlag := flags.String("calendar", "", "UUID of the target calendar, the user's default calendar if empty")
+	timeZone := flags.String("tz", "", "Time zone of floating times, the calendar's time zone if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import --user=<uuid> [--calendar=<uuid>] [--tz=<zone>] <file.ics|->")
+	}
+
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil {
+		return fmt.Errorf("user: %w", err)
+	}
+	var calendarID uuid.UUID
+	if *calendarFlag != "" {
+		if calendarID, err = uuid.Parse(*calendarFlag); err != nil {
+			return fmt.Errorf("calendar: %w", err)
+		}
+	}
+
+	var input io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
+	result, err := application.ImportEvents(ctx, calendarID, input, *timeZone)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
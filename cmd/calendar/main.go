@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 
 	api "github.com/Dendyator/calendar/api/pb"                            //nolint
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
 	"github.com/Dendyator/calendar/internal/config"                       //nolint
 	"github.com/Dendyator/calendar/internal/logger"                       //nolint
 	internalgrpc "github.com/Dendyator/calendar/internal/server/grpc"     //nolint
@@ -43,27 +47,38 @@ func main() {
 
 	logg.Info("Using DSN: " + cfg.Database.DSN)
 
-	var store storage.Interface
-	if cfg.Database.Driver == "in-memory" {
-		store = memorystorage.New()
-		logg.Info("Using in-memory storage")
-	} else {
-		var err error
-		store, err = sqlstorage.New(cfg.Database.DSN)
-		if err != nil {
-			logg.Error("Failed to initialize SQL storage: " + err.Error())
-			return
+	store, err := newStorage(cfg, logg)
+	if err != nil {
+		logg.Error(err.Error())
+		return
+	}
+
+	if flag.Arg(0) == "import" {
+		if err := runImport(app.New(logg, store), flag.Args()[1:]); err != nil {
+			logg.Error("Import failed: " + err.Error())
+			os.Exit(1)
 		}
-		logg.Info("Using SQL storage")
+		return
 	}
 
+	userAuthn, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		logg.Error("Invalid auth config: " + err.Error())
+		return
+	}
+	if _, ok := userAuthn.(*auth.HeaderAuthenticator); ok {
+		logg.Warn("auth.mode is header, trusting the " + auth.UserIDHeader + " header")
+	}
+	authn := auth.Chain(auth.NewAPIKeyAuthenticator(store), userAuthn)
+	calendar := app.New(logg, store)
+
 	httpServer := internalhttp.NewServer(internalhttp.ServerConfig{
 		Host: cfg.Server.Host,
 		Port: cfg.Server.Port,
-	}, logg, store)
+	}, logg, calendar, store, authn)
 
-	grpcServer := grpc.NewServer()
-	apiServer := internalgrpc.NewGRPCServer(store, logg)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(internalgrpc.AuthInterceptor(authn)))
+	apiServer := internalgrpc.NewGRPCServer(calendar, store, logg)
 	api.RegisterEventServiceServer(grpcServer, apiServer)
 	reflection.Register(grpcServer)
 
@@ -99,3 +114,67 @@ func main() {
 		return
 	}
 }
+
+// storageBackend is implemented by both storage backends.
+type storageBackend interface {
+	app.Storage
+	storage.APIKeyInterface
+}
+
+// newStorage opens the storage selected by the config.
+func newStorage(cfg config.Config, logg *logger.Logger) (storageBackend, error) {
+	policy, err := storage.ParseConflictPolicy(cfg.Database.ConflictPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conflict policy: %w", err)
+	}
+	onConflict := func(event, conflict storage.Event) {
+		logg.Warnf("Event %s overlaps event %s of user %s", event.ID, conflict.ID, event.UserID)
+	}
+
+	if cfg.Database.Driver == "in-memory" {
+		memStore := memorystorage.New()
+		memStore.SetConflictPolicy(policy, onConflict)
+		logg.Info("Using in-memory storage")
+		return memStore, nil
+	}
+	sqlStore, err := sqlstorage.New(cfg.Database.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize SQL storage: %w", err)
+	}
+	sqlStore.SetConflictPolicy(policy, onConflict)
+	if cfg.Database.QueryTimeout > 0 {
+		sqlStore.SetQueryTimeout(cfg.Database.QueryTimeout)
+	}
+	logg.Info("Using SQL storage")
+	return sqlStore, nil
+}
+
+// Values of auth.mode.
+const (
+	authModeJWT    = "jwt"
+	authModeHeader = "header"
+)
+
+// newAuthenticator refuses to start without a JWT key unless header
+// authentication was chosen explicitly.
+func newAuthenticator(cfg config.AuthConfig) (auth.Authenticator, error) {
+	jwtConfig := auth.JWTConfig{
+		Algorithm:     cfg.JWT.Algorithm,
+		Secret:        cfg.JWT.Secret,
+		PublicKeyFile: cfg.JWT.PublicKeyFile,
+		Issuer:        cfg.JWT.Issuer,
+		Audience:      cfg.JWT.Audience,
+	}
+	switch cfg.Mode {
+	case "", authModeJWT:
+		if !jwtConfig.Enabled() {
+			return nil, fmt.Errorf("auth.jwt needs a secret (or %s) or a publicKeyFile; set auth.mode to %q"+
+				" to trust the %s header instead", config.JWTSecretEnv, authModeHeader, auth.UserIDHeader)
+		}
+		return auth.NewJWTAuthenticator(jwtConfig, cfg.Admins)
+	case authModeHeader:
+		return auth.NewHeaderAuthenticator(cfg.Admins)
+	default:
+		return nil, fmt.Errorf("unknown auth.mode %q", cfg.Mode)
+	}
+}
@@ -1,24 +1,43 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/Dendyator/calendar/internal/app"                    //nolint
 	"github.com/Dendyator/calendar/internal/config"                 //nolint
+	"github.com/Dendyator/calendar/internal/leader"                 //nolint
 	"github.com/Dendyator/calendar/internal/logger"                 //nolint
+	"github.com/Dendyator/calendar/internal/outbox"                 //nolint
 	"github.com/Dendyator/calendar/internal/rabbitmq"               //nolint
+	"github.com/Dendyator/calendar/internal/reminder"               //nolint
+	"github.com/Dendyator/calendar/internal/storage"                //nolint
 	sqlstorage "github.com/Dendyator/calendar/internal/storage/sql" //nolint
-	"github.com/google/uuid"                                        //nolint
+	"github.com/Dendyator/calendar/internal/subscription"           //nolint
 	_ "github.com/lib/pq"                                           //nolint
 )
 
-type Notification struct {
-	EventID   uuid.UUID `json:"eventId"`
-	Title     string    `json:"title"`
-	StartTime int64     `json:"startTime"`
-}
+const (
+	// fetchTimeout bounds a fetch of a subscribed feed.
+	fetchTimeout = 30 * time.Second
+	// relayInterval is how often the outbox is drained to RabbitMQ.
+	relayInterval = time.Second
+	// syncInterval is how often due subscriptions are looked for.
+	syncInterval = time.Minute
+	// leaseName is the lease the replicas elect their leader with.
+	leaseName = "calendar_scheduler"
+	// defaultLeaseTTL applies unless scheduler.leaseTTL is set.
+	defaultLeaseTTL = 15 * time.Second
+	// defaultCleanupInterval applies unless scheduler.cleanupInterval is set.
+	defaultCleanupInterval = time.Hour
+	// outboxRetention is how long relayed outbox messages are kept.
+	outboxRetention = 7 * 24 * time.Hour
+)
 
 func main() {
 	configPath := flag.String("config", "configs/scheduler_config.yaml",
@@ -30,6 +49,9 @@ func main() {
 
 	logg.Info("Starting scheduler...")
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	rabbit, err := rabbitmq.New(cfg.RabbitMQ.DSN, logg)
 	if err != nil {
 		logg.Error("Failed to connect to RabbitMQ: " + err.Error())
@@ -39,12 +61,17 @@ func main() {
 
 	logg.Info("Connected to RabbitMQ")
 
-	err = rabbit.DeclareQueue("notifications")
-	if err != nil {
-		logg.Error("Failed to declare RabbitMQ queue: " + err.Error())
+	if err := rabbit.EnableConfirms(); err != nil {
+		logg.Error(err.Error())
 		return
 	}
-	logg.Info("Declared RabbitMQ queue: notifications")
+	for _, queue := range []string{reminder.Queue, storage.EventChangesTopic} {
+		if err := rabbit.DeclareQueue(queue); err != nil {
+			logg.Error("Failed to declare RabbitMQ queue: " + err.Error())
+			return
+		}
+		logg.Info("Declared RabbitMQ queue: " + queue)
+	}
 
 	store, err := sqlstorage.New(cfg.Database.DSN)
 	if err != nil {
@@ -52,54 +79,104 @@ func main() {
 		return
 	}
 	logg.Info("Connected to database")
+	if cfg.Database.QueryTimeout > 0 {
+		store.SetQueryTimeout(cfg.Database.QueryTimeout)
+	}
+	fetcher := subscription.NewFetcher(app.New(logg, store), store, subscription.NewClient(fetchTimeout), logg)
 
-	for {
-		events, err := store.ListEvents()
-		if err != nil {
-			logg.Error("Failed to list events: " + err.Error())
-			continue
-		}
-
-		logg.Info(fmt.Sprintf("Retrieved %d events from database", len(events)))
-
-		for _, event := range events {
-			logg.Info(fmt.Sprintf("Processing event: %s at %s", event.Title, event.StartTime))
-
-			timeUntilStart := time.Until(event.StartTime)
-			logg.Info(fmt.Sprintf("Time until event starts: %v", timeUntilStart))
+	// Reminders and event changes reach RabbitMQ only through the outbox.
+	relay := outbox.NewRelay(store, rabbit, logg)
 
-			if timeUntilStart < 24*time.Hour {
-				notification := Notification{
-					EventID:   event.ID,
-					Title:     event.Title,
-					StartTime: event.StartTime.Unix(),
+	leaseTTL := cfg.Scheduler.LeaseTTL
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+	cleanupInterval := cfg.Scheduler.CleanupInterval
+	if cleanupInterval <= 0 {
+		cleanupInterval = defaultCleanupInterval
+	}
+	// Of several replicas only the leader schedules; the others stand by to
+	// take over.
+	elector := leader.NewElector(store, leaseName, replicaID(), leaseTTL, logg)
+	elector.Run(ctx, func(ctx context.Context) {
+		relayDone := make(chan struct{})
+		go func() {
+			defer close(relayDone)
+			relay.Run(ctx, relayInterval)
+		}()
+		defer func() { <-relayDone }()
+
+		// Feeds are fetched on their own, so that slow feeds do not delay
+		// reminders.
+		syncDone := make(chan struct{})
+		go func() {
+			defer close(syncDone)
+			for {
+				if err := fetcher.SyncDue(ctx, time.Now()); err != nil {
+					logg.Error("Failed to sync subscriptions: " + err.Error())
 				}
-
-				body, err := json.Marshal(notification)
-				if err != nil {
-					logg.Error("Failed to marshal notification: " + err.Error())
-					continue
+				if !sleep(ctx, syncInterval) {
+					return
 				}
+			}
+		}()
+		defer func() { <-syncDone }()
+
+		// The ledger keeps reminders from firing twice, so the first plan
+		// looks one interval back to catch up with those due while no
+		// replica was leading.
+		scheduler := reminder.NewScheduler(store, logg, time.Now().Add(-cfg.Scheduler.Interval))
+		var cleaned time.Time
+		for {
+			now := time.Now()
+			next, err := scheduler.Tick(ctx, now, now.Add(cfg.Scheduler.Interval))
+			if err != nil {
+				logg.Error("Failed to schedule reminders: " + err.Error())
+			}
 
-				err = rabbit.Publish("notifications", body)
+			if time.Since(cleaned) >= cleanupInterval {
+				cleaned = time.Now()
+				err = store.DeleteOldEvents(ctx, cleaned.AddDate(-1, 0, 0))
 				if err != nil {
-					logg.Error("Failed to publish notification: " + err.Error())
+					logg.Error("Failed to delete old events: " + err.Error())
 				} else {
-					logg.Info("Successfully published notification for event: " + notification.Title)
+					logg.Info("Old events deleted successfully")
+				}
+				purged, err := store.PurgeOutbox(ctx, cleaned.Add(-outboxRetention))
+				if err != nil {
+					logg.Error("Failed to purge outbox: " + err.Error())
+				} else if purged > 0 {
+					logg.Info(fmt.Sprintf("Purged %d relayed outbox messages", purged))
 				}
-			} else {
-				logg.Info("Event does not require notification at this time")
 			}
-		}
 
-		err = store.DeleteOldEvents(time.Now().AddDate(-1, 0, 0))
-		if err != nil {
-			logg.Error("Failed to delete old events: " + err.Error())
-		} else {
-			logg.Info("Old events deleted successfully")
+			logg.Info(fmt.Sprintf("Sleeping until %v", next))
+			if !sleep(ctx, time.Until(next)) {
+				return
+			}
 		}
+	})
+	logg.Info("Scheduler stopped")
+}
+
+// replicaID names this replica in the lease: the host name, which is the
+// pod name in Kubernetes, and the process ID.
+func replicaID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
 
-		logg.Info(fmt.Sprintf("Sleeping for %v", cfg.Scheduler.Interval))
-		time.Sleep(cfg.Scheduler.Interval)
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
 	}
 }
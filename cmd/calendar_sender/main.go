@@ -1,21 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-
-	"github.com/Dendyator/calendar/internal/config"   //nolint
-	"github.com/Dendyator/calendar/internal/logger"   //nolint
-	"github.com/Dendyator/calendar/internal/rabbitmq" //nolint
-	"github.com/google/uuid"                          //nolint
+	"time"
+
+	"github.com/Dendyator/calendar/internal/config"                 //nolint
+	"github.com/Dendyator/calendar/internal/logger"                 //nolint
+	"github.com/Dendyator/calendar/internal/rabbitmq"               //nolint
+	"github.com/Dendyator/calendar/internal/reminder"               //nolint
+	sqlstorage "github.com/Dendyator/calendar/internal/storage/sql" //nolint
+	"github.com/google/uuid"                                        //nolint
+	_ "github.com/lib/pq"                                           //nolint
+	"github.com/streadway/amqp"                                     //nolint
 )
 
-type Notification struct {
-	EventID   uuid.UUID `json:"eventId"`
-	Title     string    `json:"title"`
-	StartTime int64     `json:"startTime"`
-}
+// retryDelay keeps a message that could not be claimed from being
+// redelivered at once while the database is unavailable.
+const retryDelay = time.Second
 
 type NotificationStatus struct {
 	EventID uuid.UUID `json:"eventId"`
@@ -43,8 +48,18 @@ func main() {
 		logg.Info("RabbitMQ connection closed")
 	}()
 
+	// Журнал уведомлений, по которому отсеиваются повторные доставки
+	store, err := sqlstorage.New(cfg.Database.DSN)
+	if err != nil {
+		logg.Error("Failed to connect to database: " + err.Error())
+		return
+	}
+	if cfg.Database.QueryTimeout > 0 {
+		store.SetQueryTimeout(cfg.Database.QueryTimeout)
+	}
+
 	// Объявление очередей
-	err = rabbit.DeclareQueue("notifications")
+	err = rabbit.DeclareQueue(reminder.Queue)
 	if err != nil {
 		logg.Error("Failed to declare RabbitMQ queue: " + err.Error())
 		return
@@ -57,7 +72,8 @@ func main() {
 	}
 
 	// Потребление сообщений из очереди "notifications"
-	deliveries, err := rabbit.Consume("notifications")
+	// Сообщение подтверждается только после записи результата в журнал
+	deliveries, err := rabbit.ConsumeWithAck(reminder.Queue)
 	if err != nil {
 		logg.Error("Failed to consume from RabbitMQ: " + err.Error())
 		return
@@ -67,40 +83,54 @@ func main() {
 
 	for msg := range deliveries {
 		logg.Info("Received notification: " + string(msg.Body))
-		err := processNotification(rabbit, msg.Body)
-		if err != nil {
+		err := processNotification(context.Background(), store, rabbit, msg.Body)
+		switch {
+		case errors.Is(err, reminder.ErrDuplicate):
+			logg.Info("Notification already handled, skipping")
+		case err != nil:
 			logg.Error("Failed to process notification: " + err.Error())
-		} else {
+		default:
 			logg.Info("Successfully processed notification")
 		}
+		if err := acknowledge(msg, err); err != nil {
+			logg.Error("Failed to acknowledge notification: " + err.Error())
+		}
 	}
 }
 
-func processNotification(rabbit *rabbitmq.Client, body []byte) error {
-	var notification Notification
-	err := json.Unmarshal(body, &notification)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal notification: %w", err)
-	}
-
-	fmt.Println("Processing notification:", notification)
-
-	// Обработка уведомления и отправка статуса
-	status := NotificationStatus{
-		EventID: notification.EventID,
-		Status:  "processed",
-		Details: "Notification processed successfully",
+// acknowledge acks the message once the ledger holds its outcome, and
+// otherwise returns it to the queue after retryDelay.
+func acknowledge(msg amqp.Delivery, processErr error) error {
+	if errors.Is(processErr, reminder.ErrNotClaimed) {
+		time.Sleep(retryDelay)
+		return msg.Nack(false, true)
 	}
+	return msg.Ack(false)
+}
 
-	statusBody, err := json.Marshal(status)
-	if err != nil {
-		return fmt.Errorf("failed to marshal notification status: %w", err)
-	}
+// processNotification sends the reminder in body once: the ledger records
+// the outcome, and a redelivered message is reported as reminder.ErrDuplicate.
+func processNotification(ctx context.Context, store *sqlstorage.Storage, rabbit *rabbitmq.Client, body []byte) error {
+	_, err := reminder.Deliver(ctx, store, body, func(notification reminder.Message) error {
+		fmt.Println("Processing notification:", notification)
+
+		// Обработка уведомления и отправка статуса
+		status := NotificationStatus{
+			EventID: notification.EventID,
+			Status:  "processed",
+			Details: "Notification processed successfully",
+		}
 
-	err = rabbit.Publish("notification_statuses", statusBody)
-	if err != nil {
-		return fmt.Errorf("failed to publish notification status: %w", err)
-	}
+		statusBody, err := json.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification status: %w", err)
+		}
 
-	return nil
+		err = rabbit.Publish("notification_statuses", statusBody)
+		if err != nil {
+			return fmt.Errorf("failed to publish notification status: %w", err)
+		}
+		return nil
+	})
+	return err
 }
@@ -2,30 +2,524 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// defaultCalendarName names the calendar created for a user's first event
+// that does not name a calendar.
+const defaultCalendarName = "Default"
+
+// defaultTimeZone is used for calendars created without a time zone.
+const defaultTimeZone = "UTC"
+
+// defaultPageSize and maxPageSize bound the pages returned by ListEvents.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// defaultSearchLimit and maxSearchLimit bound the results of SearchEvents.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
 )
 
+// maxRange bounds ListEventsInRange so a single request cannot expand
+// recurring series indefinitely.
+const maxRange = 366 * 24 * time.Hour
+
+// App is the service layer between the HTTP and gRPC servers and storage.
+// It resolves the caller from the request context and enforces the caller's
+// role in the calendar of every event it reads or writes. Admins bypass the
+// role checks.
 type App struct {
 	logger  Logger
 	storage Storage
+	feeds   *feedCache
 }
 
 type Logger interface {
-	Info(message string)
-	Error(message string)
+	Info(args ...interface{})
+	Error(args ...interface{})
 }
 
 type Storage interface {
-	CreateEvent(ctx context.Context, id, title string) error
+	storage.Interface
+	storage.CalendarInterface
+	storage.SearchInterface
+	storage.FeedInterface
+	storage.SubscriptionInterface
+	storage.ReminderInterface
 }
 
 func New(logger Logger, storage Storage) *App {
 	return &App{
 		logger:  logger,
 		storage: storage,
+		feeds:   &feedCache{documents: make(map[uuid.UUID]FeedDocument)},
+	}
+}
+
+// CreateEvent stores the event in its calendar, or in the owner's default
+// calendar if CalendarID is not set, and returns it with ID and owner filled
+// in. The caller needs the editor role in the calendar.
+func (a *App) CreateEvent(ctx context.Context, event storage.Event) (storage.Event, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return event, err
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	event.UserID = identity.Owner(event.UserID)
+	// Overrides and cancelled occurrences are only made by UpdateEvent and
+	// DeleteEvent, which check the series they belong to.
+	event.RecurringEventID, event.RecurrenceID, event.ExDates = uuid.Nil, time.Time{}, nil
+	if event.CalendarID == uuid.Nil {
+		if event.CalendarID, err = a.defaultCalendar(ctx, event.UserID); err != nil {
+			return event, err
+		}
+	}
+	if err := a.requireRole(ctx, identity, event.CalendarID, storage.RoleEditor, storage.ErrCalendarNotFound); err != nil {
+		return event, err
+	}
+	return event, a.storage.CreateEvent(ctx, event)
+}
+
+// defaultCalendar returns the oldest calendar owned by userID, creating one
+// if the user owns none.
+func (a *App) defaultCalendar(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	calendars, err := a.storage.ListCalendars(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, calendar := range calendars {
+		if calendar.OwnerID == userID {
+			return calendar.ID, nil
+		}
+	}
+	calendar := storage.Calendar{
+		ID:       uuid.New(),
+		OwnerID:  userID,
+		Name:     defaultCalendarName,
+		TimeZone: defaultTimeZone,
+	}
+	if err := a.storage.CreateCalendar(ctx, calendar); err != nil {
+		return uuid.Nil, err
+	}
+	a.logger.Info("Created default calendar ", calendar.ID, " for user ", userID)
+	return calendar.ID, nil
+}
+
+// UpdateEvent needs the editor role in the event's calendar and, when the
+// event moves, in the new calendar too. Only admins may change the owner.
+func (a *App) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	identity, current, err := a.getEvent(ctx, id, storage.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if !identity.Admin || newEvent.UserID == uuid.Nil {
+		newEvent.UserID = current.UserID
+	}
+	if newEvent.CalendarID == uuid.Nil {
+		newEvent.CalendarID = current.CalendarID
+	}
+	if newEvent.CalendarID != current.CalendarID {
+		err := a.requireRole(ctx, identity, newEvent.CalendarID, storage.RoleEditor, storage.ErrCalendarNotFound)
+		if err != nil {
+			return err
+		}
+	}
+	newEvent.ID = id
+	return a.storage.UpdateEvent(ctx, id, newEvent, scope, recurrenceID)
+}
+
+// DeleteEvent needs the editor role in the event's calendar.
+func (a *App) DeleteEvent(ctx context.Context, id uuid.UUID, scope storage.Scope, recurrenceID time.Time) error {
+	if _, _, err := a.getEvent(ctx, id, storage.RoleEditor); err != nil {
+		return err
+	}
+	return a.storage.DeleteEvent(ctx, id, scope, recurrenceID)
+}
+
+// GetEvent needs the viewer role in the event's calendar.
+func (a *App) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
+	_, event, err := a.getEvent(ctx, id, storage.RoleViewer)
+	return event, err
+}
+
+// getEvent loads the event if the caller has the required role in its
+// calendar. Events of calendars the caller has no access to are reported as
+// not found so their IDs are not disclosed.
+func (a *App) getEvent(ctx context.Context, id uuid.UUID, required storage.Role,
+) (auth.Identity, storage.Event, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return identity, storage.Event{}, err
+	}
+	event, err := a.storage.GetEvent(ctx, id)
+	if err != nil {
+		return identity, storage.Event{}, err
+	}
+	if err := a.requireRole(ctx, identity, event.CalendarID, required, storage.ErrNotFound); err != nil {
+		return identity, storage.Event{}, err
+	}
+	return identity, event, nil
+}
+
+// ListEvents returns a page of the events matching filter. Without
+// filter.CalendarIDs every calendar the caller owns or was granted access to
+// is listed; the page size defaults to defaultPageSize and is capped at
+// maxPageSize.
+func (a *App) ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	calendarIDs, err := a.readableCalendars(ctx, filter.CalendarIDs)
+	if err != nil || len(calendarIDs) == 0 {
+		return storage.EventPage{Events: []storage.Event{}}, err
+	}
+	filter.CalendarIDs = calendarIDs
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultPageSize
+	case filter.Limit > maxPageSize:
+		filter.Limit = maxPageSize
+	}
+	return a.storage.ListEvents(ctx, filter)
+}
+
+// ListEventsInRange returns the events overlapping [from, to), which may
+// span at most maxRange.
+func (a *App) ListEventsInRange(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", storage.ErrInvalidRange)
+	}
+	if to.Sub(from) > maxRange {
+		return nil, fmt.Errorf("%w: range exceeds %d days", storage.ErrInvalidRange, maxRange/(24*time.Hour))
+	}
+	calendarIDs, err := a.readableCalendars(ctx, calendarIDs)
+	if err != nil || len(calendarIDs) == 0 {
+		return []storage.Event{}, err
+	}
+	return a.storage.ListEventsInRange(ctx, calendarIDs, from, to)
+}
+
+// ListEventsByDay, ListEventsByWeek and ListEventsByMonth cut the day, ISO
+// week or month containing date at local midnight in timeZone. An empty
+// timeZone falls back to the time zone of the caller's default calendar.
+func (a *App) ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, timeZone string,
+) ([]storage.Event, error) {
+	calendarIDs, loc, err := a.listScope(ctx, calendarIDs, timeZone)
+	if err != nil || len(calendarIDs) == 0 {
+		return []storage.Event{}, err
+	}
+	return a.storage.ListEventsByDay(ctx, calendarIDs, date, loc)
+}
+
+func (a *App) ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, timeZone string,
+) ([]storage.Event, error) {
+	calendarIDs, loc, err := a.listScope(ctx, calendarIDs, timeZone)
+	if err != nil || len(calendarIDs) == 0 {
+		return []storage.Event{}, err
+	}
+	return a.storage.ListEventsByWeek(ctx, calendarIDs, date, loc)
+}
+
+func (a *App) ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, timeZone string,
+) ([]storage.Event, error) {
+	calendarIDs, loc, err := a.listScope(ctx, calendarIDs, timeZone)
+	if err != nil || len(calendarIDs) == 0 {
+		return []storage.Event{}, err
+	}
+	return a.storage.ListEventsByMonth(ctx, calendarIDs, date, loc)
+}
+
+// listScope resolves the calendars and the time zone of a bounded listing.
+func (a *App) listScope(ctx context.Context, calendarIDs []uuid.UUID, timeZone string,
+) ([]uuid.UUID, *time.Location, error) {
+	calendarIDs, err := a.readableCalendars(ctx, calendarIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := a.Location(ctx, timeZone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return calendarIDs, loc, nil
+}
+
+// Location loads timeZone or, if it is empty, the time zone of the oldest
+// calendar the caller owns. Callers without a calendar get UTC.
+func (a *App) Location(ctx context.Context, timeZone string) (*time.Location, error) {
+	if timeZone != "" {
+		return storage.LoadLocation(timeZone)
+	}
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := a.storage.ListCalendars(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, calendar := range calendars {
+		if calendar.OwnerID == identity.UserID {
+			return calendar.Location(), nil
+		}
 	}
+	return time.UTC, nil
 }
 
-func (a *App) CreateEvent(ctx context.Context, id, title string) error {
-	a.logger.Info("Creating event: " + title)
-	return a.storage.CreateEvent(ctx, id, title)
+// ExportEvents returns the stored events to export as iCalendar: every event
+// of the calendars, or, if from and to are set, the events and series with an
+// occurrence overlapping [from, to).
+func (a *App) ExportEvents(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	if from.IsZero() && to.IsZero() {
+		return a.allEvents(ctx, calendarIDs)
+	}
+	occurrences, err := a.ListEventsInRange(ctx, calendarIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]storage.Event, 0, len(occurrences))
+	seen := make(map[uuid.UUID]bool)
+	for _, occurrence := range occurrences {
+		if seen[occurrence.ID] {
+			continue
+		}
+		seen[occurrence.ID] = true
+		event, err := a.storage.GetEvent(ctx, occurrence.ID)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return storage.Less(events[i], events[j]) })
+	return events, nil
+}
+
+// allEvents reads every page of ListEvents.
+func (a *App) allEvents(ctx context.Context, calendarIDs []uuid.UUID) ([]storage.Event, error) {
+	filter := storage.EventFilter{CalendarIDs: calendarIDs, Limit: maxPageSize}
+	var events []storage.Event
+	for {
+		page, err := a.ListEvents(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+		if page.Next == nil {
+			return events, nil
+		}
+		filter.After = page.Next
+	}
+}
+
+// SearchEvents finds the events containing every word of query.Text in the
+// given calendars, or in every calendar visible to the caller.
+func (a *App) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	if len(storage.Tokenize(query.Text)) == 0 {
+		return nil, fmt.Errorf("%w: the query has no words", storage.ErrInvalidSearch)
+	}
+	calendarIDs, err := a.readableCalendars(ctx, query.CalendarIDs)
+	if err != nil || len(calendarIDs) == 0 {
+		return []storage.SearchResult{}, err
+	}
+	query.CalendarIDs = calendarIDs
+	switch {
+	case query.Limit <= 0:
+		query.Limit = defaultSearchLimit
+	case query.Limit > maxSearchLimit:
+		query.Limit = maxSearchLimit
+	}
+	return a.storage.SearchEvents(ctx, query)
+}
+
+// ListAllEvents lists the events of every user; admins only.
+func (a *App) ListAllEvents(ctx context.Context) ([]storage.Event, error) {
+	if _, err := auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return a.storage.ListAllEvents(ctx)
+}
+
+// readableCalendars checks the caller has the viewer role in every
+// requested calendar. Without requested calendars it returns every calendar
+// visible to the caller.
+func (a *App) readableCalendars(ctx context.Context, requested []uuid.UUID) ([]uuid.UUID, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range requested {
+		if err := a.requireRole(ctx, identity, id, storage.RoleViewer, storage.ErrCalendarNotFound); err != nil {
+			return nil, err
+		}
+	}
+	if len(requested) > 0 {
+		return requested, nil
+	}
+	calendars, err := a.storage.ListCalendars(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(calendars))
+	for i, calendar := range calendars {
+		ids[i] = calendar.ID
+	}
+	return ids, nil
+}
+
+// CreateCalendar creates a calendar owned by the caller, or by the requested
+// owner if the caller is an admin.
+func (a *App) CreateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return calendar, err
+	}
+	if calendar.TimeZone == "" {
+		calendar.TimeZone = defaultTimeZone
+	}
+	calendar.ID = uuid.New()
+	calendar.OwnerID = identity.Owner(calendar.OwnerID)
+	if err := a.storage.CreateCalendar(ctx, calendar); err != nil {
+		return calendar, err
+	}
+	return a.storage.GetCalendar(ctx, calendar.ID)
+}
+
+// GetCalendar needs the viewer role.
+func (a *App) GetCalendar(ctx context.Context, id uuid.UUID) (storage.Calendar, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	if err := a.requireRole(ctx, identity, id, storage.RoleViewer, storage.ErrCalendarNotFound); err != nil {
+		return storage.Calendar{}, err
+	}
+	return a.storage.GetCalendar(ctx, id)
+}
+
+// UpdateCalendar changes the name, color and time zone of a calendar;
+// owners only.
+func (a *App) UpdateCalendar(ctx context.Context, calendar storage.Calendar) (storage.Calendar, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return calendar, err
+	}
+	if err := a.requireRole(ctx, identity, calendar.ID, storage.RoleOwner, storage.ErrCalendarNotFound); err != nil {
+		return calendar, err
+	}
+	if calendar.TimeZone == "" {
+		calendar.TimeZone = defaultTimeZone
+	}
+	if err := a.storage.UpdateCalendar(ctx, calendar); err != nil {
+		return calendar, err
+	}
+	return a.storage.GetCalendar(ctx, calendar.ID)
+}
+
+// ListCalendars returns the calendars the caller owns or was granted access
+// to.
+func (a *App) ListCalendars(ctx context.Context) ([]storage.Calendar, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.storage.ListCalendars(ctx, identity.UserID)
+}
+
+// DeleteCalendar deletes the calendar with its events; owners only.
+func (a *App) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.requireRole(ctx, identity, id, storage.RoleOwner, storage.ErrCalendarNotFound); err != nil {
+		return err
+	}
+	return a.storage.DeleteCalendar(ctx, id)
+}
+
+// ShareCalendar grants entry.Role to entry.UserID, replacing an earlier
+// grant; owners only.
+func (a *App) ShareCalendar(ctx context.Context, entry storage.ACLEntry) error {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := storage.ParseRole(string(entry.Role)); err != nil {
+		return err
+	}
+	if err := a.requireRole(ctx, identity, entry.CalendarID, storage.RoleOwner, storage.ErrCalendarNotFound); err != nil {
+		return err
+	}
+	calendar, err := a.storage.GetCalendar(ctx, entry.CalendarID)
+	if err != nil {
+		return err
+	}
+	if entry.UserID == uuid.Nil || entry.UserID == calendar.OwnerID {
+		return fmt.Errorf("%w: cannot share a calendar with its owner", storage.ErrInvalidCalendar)
+	}
+	return a.storage.SetACL(ctx, entry)
+}
+
+// UnshareCalendar revokes the access of userID. Owners may revoke anyone's
+// access; other users may only leave a calendar shared with them.
+func (a *App) UnshareCalendar(ctx context.Context, calendarID, userID uuid.UUID) error {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	required := storage.RoleOwner
+	if userID == identity.UserID {
+		required = storage.RoleViewer
+	}
+	if err := a.requireRole(ctx, identity, calendarID, required, storage.ErrCalendarNotFound); err != nil {
+		return err
+	}
+	return a.storage.DeleteACL(ctx, calendarID, userID)
+}
+
+// ListACL lists who the calendar is shared with; owners only.
+func (a *App) ListACL(ctx context.Context, calendarID uuid.UUID) ([]storage.ACLEntry, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.requireRole(ctx, identity, calendarID, storage.RoleOwner, storage.ErrCalendarNotFound); err != nil {
+		return nil, err
+	}
+	return a.storage.ListACL(ctx, calendarID)
+}
+
+// requireRole returns hidden if the caller has no access to the calendar at
+// all, and ErrPermissionDenied if the caller's role is below required.
+func (a *App) requireRole(ctx context.Context, identity auth.Identity, calendarID uuid.UUID,
+	required storage.Role, hidden error,
+) error {
+	role, err := a.storage.GetRole(ctx, calendarID, identity.UserID)
+	if errors.Is(err, storage.ErrCalendarNotFound) {
+		return hidden
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case identity.Admin || role.Allows(required):
+		return nil
+	case role == storage.RoleNone:
+		return hidden
+	default:
+		return fmt.Errorf("%w: requires the %s role", auth.ErrPermissionDenied, required)
+	}
 }
@@ -2,37 +2,274 @@ package app_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/Dendyator/calendar/internal/app" //nolint
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/ical"                         //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockLogger struct{}
 
-func (m *MockLogger) Info(_ string)  {}
-func (m *MockLogger) Error(_ string) {}
+func (m *MockLogger) Info(_ ...interface{})  {}
+func (m *MockLogger) Error(_ ...interface{}) {}
 
-type MockStorage struct {
-	mock.Mock
+func asUser(userID uuid.UUID) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
 }
 
-func (m *MockStorage) CreateEvent(ctx context.Context, id, title string) error {
-	args := m.Called(ctx, id, title)
-	return args.Error(0)
+func newEvent(title string) storage.Event {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	return storage.Event{Title: title, StartTime: start, EndTime: start.Add(time.Hour)}
 }
 
 func TestCreateEvent(t *testing.T) {
-	mockLogger := &MockLogger{}
-	mockStorage := new(MockStorage)
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	owner := uuid.New()
 
-	mockStorage.On("CreateEvent", mock.Anything, "1", "Test Event").Return(nil)
+	event, err := appInstance.CreateEvent(asUser(owner), newEvent("Test Event"))
+	require.NoError(t, err)
+	assert.Equal(t, owner, event.UserID)
+	assert.NotEqual(t, uuid.Nil, event.CalendarID)
 
-	appInstance := app.New(mockLogger, mockStorage)
+	calendars, err := appInstance.ListCalendars(asUser(owner))
+	require.NoError(t, err)
+	require.Len(t, calendars, 1)
+	assert.Equal(t, event.CalendarID, calendars[0].ID)
 
-	err := appInstance.CreateEvent(context.Background(), "1", "Test Event")
+	later := newEvent("Another Event")
+	later.StartTime, later.EndTime = later.StartTime.Add(2*time.Hour), later.EndTime.Add(2*time.Hour)
+	second, err := appInstance.CreateEvent(asUser(owner), later)
+	require.NoError(t, err)
+	assert.Equal(t, event.CalendarID, second.CalendarID)
+}
+
+func TestCreateEvent_NoOverride(t *testing.T) {
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	owner, stranger := uuid.New(), uuid.New()
+
+	draft := newEvent("Standup")
+	draft.RRule = "FREQ=DAILY"
+	series, err := appInstance.CreateEvent(asUser(owner), draft)
+	require.NoError(t, err)
+
+	forged := newEvent("Hijack")
+	forged.StartTime, forged.EndTime = forged.StartTime.AddDate(0, 0, 1), forged.EndTime.AddDate(0, 0, 1)
+	forged.RecurringEventID = series.ID
+	forged.RecurrenceID = series.StartTime.AddDate(0, 0, 1)
+	forged.ExDates = storage.Times{series.StartTime}
+	created, err := appInstance.CreateEvent(asUser(stranger), forged)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, created.RecurringEventID)
+	assert.True(t, created.RecurrenceID.IsZero())
+	assert.Empty(t, created.ExDates)
+
+	stored, err := appInstance.GetEvent(asUser(owner), series.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.ExDates)
+}
+
+func TestShareCalendar(t *testing.T) {
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	owner, viewer, editor, stranger := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	calendar, err := appInstance.CreateCalendar(asUser(owner), storage.Calendar{Name: "Team"})
+	require.NoError(t, err)
+	draft := newEvent("Planning")
+	draft.CalendarID = calendar.ID
+	event, err := appInstance.CreateEvent(asUser(owner), draft)
+	require.NoError(t, err)
+
+	require.NoError(t, appInstance.ShareCalendar(asUser(owner),
+		storage.ACLEntry{CalendarID: calendar.ID, UserID: viewer, Role: storage.RoleViewer}))
+	require.NoError(t, appInstance.ShareCalendar(asUser(owner),
+		storage.ACLEntry{CalendarID: calendar.ID, UserID: editor, Role: storage.RoleEditor}))
+
+	_, err = appInstance.GetEvent(asUser(viewer), event.ID)
+	assert.NoError(t, err)
+	page, err := appInstance.ListEvents(asUser(viewer), storage.EventFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Events, 1)
+
+	changed := event
+	changed.Title = "Planning (moved)"
+	err = appInstance.UpdateEvent(asUser(viewer), event.ID, changed, storage.ScopeAll, time.Time{})
+	assert.ErrorIs(t, err, auth.ErrPermissionDenied)
+	err = appInstance.UpdateEvent(asUser(editor), event.ID, changed, storage.ScopeAll, time.Time{})
+	assert.NoError(t, err)
+	stored, err := appInstance.GetEvent(asUser(owner), event.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, owner, stored.UserID)
+
+	_, err = appInstance.GetEvent(asUser(stranger), event.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	err = appInstance.ShareCalendar(asUser(editor),
+		storage.ACLEntry{CalendarID: calendar.ID, UserID: stranger, Role: storage.RoleViewer})
+	assert.ErrorIs(t, err, auth.ErrPermissionDenied)
+
+	require.NoError(t, appInstance.UnshareCalendar(asUser(owner), calendar.ID, viewer))
+	_, err = appInstance.GetEvent(asUser(viewer), event.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	err = appInstance.DeleteCalendar(asUser(editor), calendar.ID)
+	assert.ErrorIs(t, err, auth.ErrPermissionDenied)
+	require.NoError(t, appInstance.DeleteCalendar(asUser(owner), calendar.ID))
+	_, err = appInstance.GetEvent(asUser(owner), event.ID)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestListEvents_CalendarFilter(t *testing.T) {
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	user, stranger := uuid.New(), uuid.New()
 
+	work, err := appInstance.CreateCalendar(asUser(user),
+		storage.Calendar{Name: "Work", Color: "#ff8800", TimeZone: "Europe/Moscow"})
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", work.TimeZone)
+	personal, err := appInstance.CreateCalendar(asUser(user), storage.Calendar{Name: "Personal"})
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", personal.TimeZone)
+
+	for i, calendar := range []storage.Calendar{work, personal} {
+		event := newEvent(calendar.Name)
+		event.StartTime, event.EndTime = event.StartTime.Add(time.Duration(i)*2*time.Hour),
+			event.EndTime.Add(time.Duration(i)*2*time.Hour)
+		event.CalendarID = calendar.ID
+		_, err := appInstance.CreateEvent(asUser(user), event)
+		require.NoError(t, err)
+	}
+
+	page, err := appInstance.ListEvents(asUser(user), storage.EventFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Events, 2)
+	events, err := appInstance.ListEventsByDay(asUser(user), []uuid.UUID{work.ID}, newEvent("").StartTime, "")
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Work", events[0].Title)
+	_, err = appInstance.ListEventsByDay(asUser(user), nil, newEvent("").StartTime, "Mars/Olympus")
+	assert.ErrorIs(t, err, storage.ErrInvalidTimeZone)
+
+	_, err = appInstance.ListEvents(asUser(stranger), storage.EventFilter{CalendarIDs: []uuid.UUID{work.ID}})
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+
+	work.Color = "orange"
+	_, err = appInstance.UpdateCalendar(asUser(user), work)
+	assert.ErrorIs(t, err, storage.ErrInvalidCalendar)
+	work.Color, work.TimeZone = "#0088ff", "Mars/Olympus"
+	_, err = appInstance.UpdateCalendar(asUser(user), work)
+	assert.ErrorIs(t, err, storage.ErrInvalidCalendar)
+	work.Name, work.TimeZone = "On-call", "Asia/Tokyo"
+	updated, err := appInstance.UpdateCalendar(asUser(user), work)
+	assert.NoError(t, err)
+	assert.Equal(t, "On-call", updated.Name)
+	assert.Equal(t, "#0088ff", updated.Color)
+	assert.Equal(t, user, updated.OwnerID)
+}
+
+func TestListEventsByDay_DefaultTimeZone(t *testing.T) {
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	user := uuid.New()
+
+	_, err := appInstance.CreateCalendar(asUser(user), storage.Calendar{Name: "Home", TimeZone: "Asia/Tokyo"})
+	require.NoError(t, err)
+	// 20:00 UTC on 1 January is already 2 January in Tokyo.
+	event := newEvent("Late call")
+	event.StartTime, event.EndTime = event.StartTime.Add(10*time.Hour), event.EndTime.Add(10*time.Hour)
+	_, err = appInstance.CreateEvent(asUser(user), event)
+	require.NoError(t, err)
+
+	january1 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	events, err := appInstance.ListEventsByDay(asUser(user), nil, january1, "")
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	events, err = appInstance.ListEventsByDay(asUser(user), nil, january1, "UTC")
 	assert.NoError(t, err)
-	mockStorage.AssertExpectations(t)
+	assert.Len(t, events, 1)
+}
+
+func TestImportEvents(t *testing.T) {
+	appInstance := app.New(&MockLogger{}, memorystorage.New())
+	user := uuid.New()
+	calendar, err := appInstance.CreateCalendar(asUser(user), storage.Calendar{Name: "Work", TimeZone: "Asia/Tokyo"})
+	require.NoError(t, err)
+
+	file := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"SUMMARY:Standup",
+		"DTSTART:20240101T100000",
+		"DTEND:20240101T101500",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"RECURRENCE-ID:20240103T010000Z",
+		"SUMMARY:Standup (late)",
+		"DTSTART:20240103T120000",
+		"DTEND:20240103T121500",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:orphan",
+		"RECURRENCE-ID:20240103T010000Z",
+		"DTSTART:20240103T120000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad",
+		"DTSTART:20240101T100000",
+		"DTEND:20240101T090000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	importFile := func() app.ImportResult {
+		result, err := appInstance.ImportEvents(asUser(user), calendar.ID, strings.NewReader(file), "")
+		require.NoError(t, err)
+		return result
+	}
+
+	result := importFile()
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 0, result.Updated)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 2, result.Errors[0].Index)
+	assert.Equal(t, "orphan", result.Errors[0].UID)
+	assert.Equal(t, "bad", result.Errors[1].UID)
+
+	// Floating times are read in the calendar's time zone.
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	events, err := appInstance.ListEventsInRange(asUser(user), nil, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	starts := make(map[string][]time.Time)
+	for _, event := range events {
+		starts[event.Title] = append(starts[event.Title], event.StartTime.UTC())
+	}
+	assert.Contains(t, starts["Standup"], time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))
+	assert.NotContains(t, starts["Standup"], time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC))
+	assert.Equal(t, []time.Time{time.Date(2024, 1, 3, 3, 0, 0, 0, time.UTC)}, starts["Standup (late)"])
+
+	result = importFile()
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 0, result.Updated)
+	assert.Equal(t, 2, result.Unchanged)
+	events, err = appInstance.ListEventsInRange(asUser(user), nil, from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	assert.Len(t, events, 5)
+
+	file = strings.Replace(file, "SUMMARY:Standup\r\n", "SUMMARY:Daily\r\n", 1)
+	result = importFile()
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Unchanged)
+
+	_, err = appInstance.ImportEvents(asUser(uuid.New()), calendar.ID, strings.NewReader(file), "")
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+	_, err = appInstance.ImportEvents(asUser(user), calendar.ID, strings.NewReader("BEGIN:VEVENT"), "")
+	assert.ErrorIs(t, err, ical.ErrMalformed)
 }
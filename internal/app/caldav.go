@@ -0,0 +1,350 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/ical"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// ErrPreconditionFailed is returned when the If-Match or If-None-Match
+// condition of a CalDAV write does not hold.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Resource is a CalDAV calendar object resource: an event, or a series with
+// its overrides, which share a UID.
+type Resource struct {
+	// Name is the resource's file name without the ".ics" extension: the
+	// UID, or the ID of an event stored without one.
+	Name string
+	// Events holds the event or series first, then its overrides.
+	Events []storage.Event
+}
+
+// ResourceName returns the name of the resource the event or series is
+// stored in.
+func ResourceName(event storage.Event) string {
+	if event.UID != "" {
+		return event.UID
+	}
+	return event.ID.String()
+}
+
+// ETag is a strong entity tag of the resource's content.
+func (r Resource) ETag() string {
+	return etag(r.Events)
+}
+
+func etag(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ListResources returns the resources of a calendar the caller can read,
+// ordered by name, and a tag of the calendar's content that changes whenever
+// a resource does.
+func (a *App) ListResources(ctx context.Context, calendarID uuid.UUID) ([]Resource, string, error) {
+	events, err := a.allEvents(ctx, []uuid.UUID{calendarID})
+	if err != nil {
+		return nil, "", err
+	}
+	resources := groupResources(events)
+	tags := make([]string, len(resources))
+	for i, resource := range resources {
+		tags[i] = resource.Name + resource.ETag()
+	}
+	return resources, etag(tags), nil
+}
+
+// QueryResources returns the resources of a calendar the caller can read that
+// have an occurrence overlapping [from, to). A zero bound leaves that side of
+// the window open; an open end is approximated by maxRange past from.
+func (a *App) QueryResources(ctx context.Context, calendarID uuid.UUID, from, to time.Time) ([]Resource, error) {
+	resources, _, err := a.ListResources(ctx, calendarID)
+	if err != nil || (from.IsZero() && to.IsZero()) {
+		return resources, err
+	}
+	matching := make([]Resource, 0, len(resources))
+	for _, resource := range resources {
+		windowFrom, windowTo := from, to
+		for _, event := range resource.Events {
+			// Occurrences never start before their series.
+			if from.IsZero() && (windowFrom.IsZero() || event.StartTime.Before(windowFrom)) {
+				windowFrom = event.StartTime
+			}
+			if to.IsZero() && !event.StartTime.Before(windowTo) {
+				windowTo = event.StartTime.Add(time.Nanosecond)
+			}
+		}
+		if to.IsZero() && windowTo.Before(windowFrom.Add(maxRange)) {
+			windowTo = windowFrom.Add(maxRange)
+		}
+		occurrences, err := storage.ExpandOccurrences(resource.Events, windowFrom, windowTo)
+		if err != nil {
+			return nil, err
+		}
+		if len(occurrences) > 0 {
+			matching = append(matching, resource)
+		}
+	}
+	return matching, nil
+}
+
+// GetResource returns the named resource of a calendar the caller can read.
+func (a *App) GetResource(ctx context.Context, calendarID uuid.UUID, name string) (Resource, error) {
+	if _, err := a.GetCalendar(ctx, calendarID); err != nil {
+		return Resource{}, err
+	}
+	return a.resource(ctx, calendarID, name)
+}
+
+func (a *App) resource(ctx context.Context, calendarID uuid.UUID, name string) (Resource, error) {
+	event, err := a.eventByUID(ctx, calendarID, name)
+	if err != nil {
+		return Resource{}, err
+	}
+	resource := Resource{Name: name, Events: []storage.Event{event}}
+	if !event.IsRecurring() {
+		return resource, nil
+	}
+	events, err := a.allEvents(ctx, []uuid.UUID{calendarID})
+	if err != nil {
+		return Resource{}, err
+	}
+	for _, override := range events {
+		if override.RecurringEventID == event.ID {
+			resource.Events = append(resource.Events, override)
+		}
+	}
+	sortOverrides(resource.Events[1:])
+	return resource, nil
+}
+
+// groupResources groups events into resources by series.
+func groupResources(events []storage.Event) []Resource {
+	index := make(map[uuid.UUID]int)
+	var resources []Resource
+	for _, event := range events {
+		if !event.IsOverride() {
+			index[event.ID] = len(resources)
+			resources = append(resources, Resource{Name: ResourceName(event), Events: []storage.Event{event}})
+		}
+	}
+	for _, event := range events {
+		if i, ok := index[event.RecurringEventID]; ok && event.IsOverride() {
+			resources[i].Events = append(resources[i].Events, event)
+		}
+	}
+	for _, resource := range resources {
+		sortOverrides(resource.Events[1:])
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+	return resources
+}
+
+func sortOverrides(overrides []storage.Event) {
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].RecurrenceID.Before(overrides[j].RecurrenceID) })
+}
+
+// Preconditions are the If-Match and If-None-Match headers of a write.
+type Preconditions struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// check returns ErrPreconditionFailed unless the conditions hold for the
+// current resource, whose ETag is empty if it does not exist.
+func (p Preconditions) check(current string) error {
+	if p.IfMatch != "" && (current == "" || !matchesETag(p.IfMatch, current)) {
+		return fmt.Errorf("%w: If-Match", ErrPreconditionFailed)
+	}
+	if p.IfNoneMatch != "" && current != "" && matchesETag(p.IfNoneMatch, current) {
+		return fmt.Errorf("%w: If-None-Match", ErrPreconditionFailed)
+	}
+	return nil
+}
+
+func matchesETag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// PutResource replaces the named resource of the calendar with the
+// VCALENDAR read from r, or creates it. Every VEVENT must have the resource
+// name as UID. Overrides missing from r are deleted. It reports whether the
+// resource was created. The caller needs the editor role in the calendar.
+func (a *App) PutResource(ctx context.Context, calendarID uuid.UUID, name string, r io.Reader,
+	preconditions Preconditions,
+) (Resource, bool, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return Resource{}, false, err
+	}
+	if err := a.requireRole(ctx, identity, calendarID, storage.RoleEditor, storage.ErrCalendarNotFound); err != nil {
+		return Resource{}, false, err
+	}
+	calendar, err := a.storage.GetCalendar(ctx, calendarID)
+	if err != nil {
+		return Resource{}, false, err
+	}
+	items, err := decodeItems(r, calendar.Location())
+	if err != nil {
+		return Resource{}, false, err
+	}
+	masters := 0
+	for _, item := range items {
+		if item.Err != nil {
+			return Resource{}, false, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, item.Err)
+		}
+		if item.UID != name {
+			return Resource{}, false, fmt.Errorf("%w: UID %q does not match the resource name", storage.ErrInvalidEvent,
+				item.UID)
+		}
+		if item.RecurrenceID.IsZero() {
+			masters++
+		}
+	}
+	if masters != 1 {
+		return Resource{}, false, fmt.Errorf("%w: the resource must contain exactly one VEVENT without RECURRENCE-ID",
+			storage.ErrInvalidEvent)
+	}
+
+	// The check and the writes happen under the lock of the resource, so a
+	// concurrent write either lands first and fails the check, or waits.
+	owner := identity.Owner(uuid.Nil)
+	created := false
+	err = a.storage.ReplaceResource(ctx, calendarID, name,
+		func(current []storage.Event) (storage.SeriesChange, error) {
+			if err := preconditions.check(currentETag(name, current)); err != nil {
+				return storage.SeriesChange{}, err
+			}
+			created = len(current) == 0
+			return planResource(owner, calendarID, items, current)
+		})
+	if err != nil {
+		return Resource{}, false, err
+	}
+	resource, err := a.resource(ctx, calendarID, name)
+	return resource, created, err
+}
+
+// currentETag returns the ETag of the stored events of a resource, the
+// series first, or "" if there are none.
+func currentETag(name string, current []storage.Event) string {
+	if len(current) == 0 {
+		return ""
+	}
+	sortOverrides(current[1:])
+	return Resource{Name: name, Events: current}.ETag()
+}
+
+// planResource works out how to store the VEVENTs of a resource over its
+// stored events, current, the way importing them one by one would: stored
+// IDs, owners and reminders are kept, unchanged events are not written and
+// overrides missing from items are deleted. New events are owned by owner.
+func planResource(owner, calendarID uuid.UUID, items []ical.Item, current []storage.Event,
+) (storage.SeriesChange, error) {
+	var change storage.SeriesChange
+	order := importOrder(items)
+	master := items[order[0]]
+	overridden := overriddenOccurrences(items)[master.UID]
+
+	// Overridden occurrences are cancelled in the series.
+	series := master.Event
+	series.CalendarID = calendarID
+	series.ExDates = storage.Times{}
+	for _, exDate := range master.Event.ExDates {
+		if !overridden.Contains(exDate) {
+			series.ExDates = append(series.ExDates, exDate)
+		}
+	}
+	for _, recurrenceID := range overridden {
+		if !series.IsRecurring() || !series.HasOccurrence(recurrenceID) {
+			return change, fmt.Errorf("%w: RECURRENCE-ID %s is not an occurrence of the series",
+				storage.ErrInvalidEvent, recurrenceID.Format(time.RFC3339))
+		}
+	}
+	series.ExDates = append(series.ExDates, overridden...)
+
+	var stored []storage.Event
+	if len(current) == 0 {
+		series.ID, series.UserID = uuid.New(), owner
+		change.Save = append(change.Save, series)
+	} else {
+		stored = current[1:]
+		series.ID, series.UserID = current[0].ID, current[0].UserID
+		// iCalendar alarms are not imported, so reminders chosen here are kept.
+		series.Reminders = current[0].Reminders
+		if !sameEvent(current[0], series) {
+			change.Save = append(change.Save, series)
+		}
+	}
+	for _, override := range stored {
+		if !overridden.Contains(override.RecurrenceID) {
+			change.Delete = append(change.Delete, override.ID)
+		}
+	}
+
+	for _, i := range order[1:] {
+		override := items[i].Event
+		override.ID, override.UserID, override.CalendarID = uuid.New(), series.UserID, calendarID
+		override.RRule, override.ExDates, override.UID = "", nil, ""
+		override.RecurringEventID, override.RecurrenceID = series.ID, items[i].RecurrenceID
+		unchanged := false
+		for _, existing := range stored {
+			if existing.RecurrenceID.Equal(override.RecurrenceID) {
+				override.ID, override.UserID, override.Reminders = existing.ID, existing.UserID, existing.Reminders
+				unchanged = sameEvent(existing, override)
+			}
+		}
+		if !unchanged {
+			change.Save = append(change.Save, override)
+		}
+	}
+	return change, nil
+}
+
+// DeleteResource deletes the named resource: the event, or the series with
+// its overrides. The caller needs the editor role in the calendar.
+func (a *App) DeleteResource(ctx context.Context, calendarID uuid.UUID, name string,
+	preconditions Preconditions,
+) error {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := a.requireRole(ctx, identity, calendarID, storage.RoleEditor, storage.ErrCalendarNotFound); err != nil {
+		return err
+	}
+	return a.storage.ReplaceResource(ctx, calendarID, name,
+		func(current []storage.Event) (storage.SeriesChange, error) {
+			if len(current) == 0 {
+				return storage.SeriesChange{}, storage.ErrNotFound
+			}
+			if err := preconditions.check(currentETag(name, current)); err != nil {
+				return storage.SeriesChange{}, err
+			}
+			var change storage.SeriesChange
+			for _, event := range current {
+				change.Delete = append(change.Delete, event.ID)
+			}
+			return change, nil
+		})
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/ical"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// A feed serves the events overlapping a window rolling daily from
+// feedPast before today to feedFuture after it; together they stay within
+// maxRange.
+const (
+	feedPast   = 30 * 24 * time.Hour
+	feedFuture = 330 * 24 * time.Hour
+)
+
+// FeedMaxAge is how long a rendered feed is reused, both by clients (as
+// Cache-Control max-age) and by the App, so that polling clients cost one
+// feed lookup instead of a listing.
+const FeedMaxAge = 5 * time.Minute
+
+// FeedDocument is a rendered feed.
+type FeedDocument struct {
+	Body []byte
+	// ETag is a strong entity tag of Body.
+	ETag     string
+	Rendered time.Time
+}
+
+// feedCache holds the documents rendered in the last FeedMaxAge by feed ID.
+type feedCache struct {
+	mu        sync.Mutex
+	documents map[uuid.UUID]FeedDocument
+}
+
+func (c *feedCache) get(id uuid.UUID, now time.Time) (FeedDocument, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	document, ok := c.documents[id]
+	if !ok || now.Sub(document.Rendered) >= FeedMaxAge {
+		return FeedDocument{}, false
+	}
+	return document, true
+}
+
+func (c *feedCache) put(id uuid.UUID, document FeedDocument) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for cached, old := range c.documents {
+		if document.Rendered.Sub(old.Rendered) >= FeedMaxAge {
+			delete(c.documents, cached)
+		}
+	}
+	c.documents[id] = document
+}
+
+func (c *feedCache) forget(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.documents, id)
+}
+
+// CreateFeed creates a feed of the calendar, or of every calendar the caller
+// can read if calendarID is not set, and returns it with its token. The
+// caller needs the viewer role in the calendar.
+func (a *App) CreateFeed(ctx context.Context, calendarID uuid.UUID, name string) (storage.Feed, string, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return storage.Feed{}, "", err
+	}
+	if calendarID != uuid.Nil {
+		err := a.requireRole(ctx, identity, calendarID, storage.RoleViewer, storage.ErrCalendarNotFound)
+		if err != nil {
+			return storage.Feed{}, "", err
+		}
+	}
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		return storage.Feed{}, "", err
+	}
+	feed := storage.Feed{
+		ID:         uuid.New(),
+		UserID:     identity.UserID,
+		CalendarID: calendarID,
+		Name:       name,
+		SecretHash: hash,
+		CreatedAt:  time.Now(),
+	}
+	if err := a.storage.CreateFeed(ctx, feed); err != nil {
+		return storage.Feed{}, "", err
+	}
+	return feed, feedToken(feed.ID, secret), nil
+}
+
+func feedToken(id uuid.UUID, secret string) string {
+	return id.String() + "." + secret
+}
+
+// ListFeeds lists the caller's feeds.
+func (a *App) ListFeeds(ctx context.Context) ([]storage.Feed, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.storage.ListFeeds(ctx, identity.UserID)
+}
+
+// RotateFeed gives the caller's feed a new token; the old one stops working.
+func (a *App) RotateFeed(ctx context.Context, id uuid.UUID) (storage.Feed, string, error) {
+	feed, err := a.ownFeed(ctx, id)
+	if err != nil {
+		return feed, "", err
+	}
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		return feed, "", err
+	}
+	if err := a.storage.UpdateFeedSecret(ctx, id, hash); err != nil {
+		return feed, "", err
+	}
+	a.feeds.forget(id)
+	return feed, feedToken(feed.ID, secret), nil
+}
+
+// DeleteFeed revokes the caller's feed.
+func (a *App) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	if _, err := a.ownFeed(ctx, id); err != nil {
+		return err
+	}
+	a.feeds.forget(id)
+	return a.storage.DeleteFeed(ctx, id)
+}
+
+// ownFeed loads a feed of the caller; admins may manage every feed.
+func (a *App) ownFeed(ctx context.Context, id uuid.UUID) (storage.Feed, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return storage.Feed{}, err
+	}
+	feed, err := a.storage.GetFeed(ctx, id)
+	if err != nil {
+		return storage.Feed{}, err
+	}
+	if feed.UserID != identity.UserID && !identity.Admin {
+		return storage.Feed{}, storage.ErrFeedNotFound
+	}
+	return feed, nil
+}
+
+// RenderFeed returns the iCalendar document of the feed with the given
+// token. It needs no caller identity: the token is the credential, and the
+// feed reads events with the role of its owner at the time of the request.
+func (a *App) RenderFeed(ctx context.Context, token string, now time.Time) (FeedDocument, error) {
+	id, secret, ok := auth.ParseToken(token)
+	if !ok {
+		return FeedDocument{}, storage.ErrFeedNotFound
+	}
+	feed, err := a.storage.GetFeed(ctx, id)
+	if err != nil {
+		return FeedDocument{}, err
+	}
+	if !auth.SecretMatches(secret, feed.SecretHash) {
+		return FeedDocument{}, storage.ErrFeedNotFound
+	}
+	if document, ok := a.feeds.get(feed.ID, now); ok {
+		return document, nil
+	}
+
+	ctx = auth.WithIdentity(ctx, auth.Identity{UserID: feed.UserID, Scopes: []string{auth.ScopeEventsRead}})
+	var calendarIDs []uuid.UUID
+	loc := time.UTC
+	if feed.CalendarID != uuid.Nil {
+		calendar, err := a.GetCalendar(ctx, feed.CalendarID)
+		if err != nil {
+			return FeedDocument{}, err
+		}
+		calendarIDs, loc = []uuid.UUID{calendar.ID}, calendar.Location()
+	} else if loc, err = a.Location(ctx, ""); err != nil {
+		return FeedDocument{}, err
+	}
+	// The window and DTSTAMP only change daily, so the body, and with it
+	// the ETag, only changes with the events.
+	today := now.UTC().Truncate(24 * time.Hour)
+	events, err := a.ExportEvents(ctx, calendarIDs, today.Add(-feedPast), today.Add(feedFuture))
+	if err != nil {
+		return FeedDocument{}, err
+	}
+	var body bytes.Buffer
+	encoder := ical.NewEncoder(&body)
+	encoder.Location, encoder.Stamp = loc, today
+	if err := encoder.Encode(events); err != nil {
+		return FeedDocument{}, fmt.Errorf("encode feed %s: %w", feed.ID, err)
+	}
+	sum := sha256.Sum256(body.Bytes())
+	document := FeedDocument{Body: body.Bytes(), ETag: `"` + hex.EncodeToString(sum[:16]) + `"`, Rendered: now}
+	a.feeds.put(feed.ID, document)
+	return document, nil
+}
@@ -0,0 +1,286 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/ical"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// ImportResult summarizes an iCalendar import.
+type ImportResult struct {
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Errors    []ImportError `json:"errors"`
+}
+
+// ImportError reports a VEVENT that was not imported.
+type ImportError struct {
+	// Index is the position of the VEVENT in the file, starting at 0.
+	Index   int    `json:"index"`
+	UID     string `json:"uid,omitempty"`
+	Message string `json:"message"`
+}
+
+// errNoSeries is reported for an override whose series is not in the calendar.
+var errNoSeries = errors.New("no series with this UID to apply the RECURRENCE-ID to")
+
+// importErrors are the errors reported per VEVENT; any other storage error
+// aborts the import.
+var importErrors = []error{errNoSeries, storage.ErrInvalidEvent, storage.ErrDateBusy, storage.ErrAlreadyExists}
+
+// ImportEvents reads the VEVENTs of an iCalendar file into the calendar, or
+// into the caller's default calendar if calendarID is not set. Events are
+// matched by UID: a VEVENT whose UID is already in the calendar updates that
+// event instead of creating a copy, so importing the same file twice is a
+// no-op. Overrides (VEVENTs with a RECURRENCE-ID) are applied to the series
+// with the same UID. Floating times and all-day dates are read in timeZone or,
+// if it is empty, in the calendar's time zone. The caller needs the editor
+// role in the calendar.
+func (a *App) ImportEvents(ctx context.Context, calendarID uuid.UUID, r io.Reader, timeZone string,
+) (ImportResult, error) {
+	result := ImportResult{Errors: []ImportError{}}
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return result, err
+	}
+	if calendarID == uuid.Nil {
+		if calendarID, err = a.defaultCalendar(ctx, identity.UserID); err != nil {
+			return result, err
+		}
+	}
+	if err := a.requireRole(ctx, identity, calendarID, storage.RoleEditor, storage.ErrCalendarNotFound); err != nil {
+		return result, err
+	}
+	calendar, err := a.storage.GetCalendar(ctx, calendarID)
+	if err != nil {
+		return result, err
+	}
+	loc := calendar.Location()
+	if timeZone != "" {
+		if loc, err = storage.LoadLocation(timeZone); err != nil {
+			return result, err
+		}
+	}
+
+	items, err := decodeItems(r, loc)
+	if err != nil {
+		return result, err
+	}
+	result, err = a.importItems(ctx, identity.Owner(uuid.Nil), calendarID, items)
+	if err != nil {
+		return result, err
+	}
+	a.logger.Info("Imported events into calendar ", calendarID, ": ", result.Created, " created, ",
+		result.Updated, " updated, ", result.Unchanged, " unchanged, ", len(result.Errors), " failed")
+	return result, nil
+}
+
+// importItems stores the decoded VEVENTs owned by userID in the calendar.
+func (a *App) importItems(ctx context.Context, userID, calendarID uuid.UUID, items []ical.Item,
+) (ImportResult, error) {
+	result := ImportResult{Errors: []ImportError{}}
+	overridden := overriddenOccurrences(items)
+	for _, i := range importOrder(items) {
+		item := items[i]
+		if item.Err != nil {
+			result.Errors = append(result.Errors, ImportError{Index: i, UID: item.UID, Message: item.Err.Error()})
+			continue
+		}
+		outcome, err := a.importItem(ctx, userID, calendarID, item, overridden[item.UID])
+		if err != nil && !isImportError(err) {
+			return result, err
+		}
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, ImportError{Index: i, UID: item.UID, Message: err.Error()})
+		case outcome == created:
+			result.Created++
+		case outcome == updated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Index < result.Errors[j].Index })
+	return result, nil
+}
+
+// importOutcome is what importing a VEVENT did.
+type importOutcome int
+
+const (
+	created importOutcome = iota
+	updated
+	unchanged
+)
+
+func decodeItems(r io.Reader, loc *time.Location) ([]ical.Item, error) {
+	decoder := ical.NewDecoder(r)
+	decoder.Location = loc
+	return decoder.Decode()
+}
+
+// importOrder returns the indexes of items with the series first, so that
+// their overrides find them.
+func importOrder(items []ical.Item) []int {
+	order := make([]int, 0, len(items))
+	for _, overrides := range []bool{false, true} {
+		for i, item := range items {
+			if (item.Err == nil && !item.RecurrenceID.IsZero()) == overrides {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+// overriddenOccurrences returns the RECURRENCE-IDs of the overrides of each
+// UID. Overridden occurrences are cancelled in their series when the override
+// is stored, so they are left out of the imported series and kept in the
+// stored one.
+func overriddenOccurrences(items []ical.Item) map[string]storage.Times {
+	overridden := make(map[string]storage.Times)
+	for _, item := range items {
+		if item.Err == nil && !item.RecurrenceID.IsZero() {
+			overridden[item.UID] = append(overridden[item.UID], item.RecurrenceID)
+		}
+	}
+	return overridden
+}
+
+// importItem stores a VEVENT owned by userID in the calendar. overridden are
+// the occurrences of the item's series that the file overrides.
+func (a *App) importItem(ctx context.Context, userID, calendarID uuid.UUID, item ical.Item,
+	overridden storage.Times,
+) (importOutcome, error) {
+	event := item.Event
+	event.UserID = userID
+	event.CalendarID = calendarID
+	if item.RecurrenceID.IsZero() {
+		return a.importEvent(ctx, calendarID, event, overridden)
+	}
+	return a.importOverride(ctx, calendarID, item.RecurrenceID, event)
+}
+
+// importEvent creates the event or updates the event with the same UID.
+func (a *App) importEvent(ctx context.Context, calendarID uuid.UUID, event storage.Event,
+	overridden storage.Times,
+) (importOutcome, error) {
+	// A nil ExDates would keep the stored ones on update.
+	exDates := storage.Times{}
+	for _, exDate := range event.ExDates {
+		if !overridden.Contains(exDate) {
+			exDates = append(exDates, exDate)
+		}
+	}
+	event.ExDates = exDates
+	current, err := a.eventByUID(ctx, calendarID, event.UID)
+	if errors.Is(err, storage.ErrNotFound) {
+		event.ID = uuid.New()
+		return created, a.storage.CreateEvent(ctx, event)
+	}
+	if err != nil {
+		return created, err
+	}
+	for _, exDate := range current.ExDates {
+		if overridden.Contains(exDate) {
+			event.ExDates = append(event.ExDates, exDate)
+		}
+	}
+	if sameEvent(current, event) {
+		return unchanged, nil
+	}
+	event.ID = current.ID
+	event.UserID = current.UserID
+	// iCalendar alarms are not imported, so reminders chosen here are kept.
+	event.Reminders = current.Reminders
+	return updated, a.storage.UpdateEvent(ctx, current.ID, event, storage.ScopeAll, time.Time{})
+}
+
+// importOverride stores the event as an override of the occurrence at
+// recurrenceID of the series with the same UID.
+func (a *App) importOverride(ctx context.Context, calendarID uuid.UUID, recurrenceID time.Time,
+	event storage.Event,
+) (importOutcome, error) {
+	series, err := a.eventByUID(ctx, calendarID, event.UID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return created, errNoSeries
+	}
+	if err != nil {
+		return created, err
+	}
+	occurrences, err := a.storage.ListEventsInRange(ctx, []uuid.UUID{calendarID},
+		event.StartTime, event.EndTime.Add(time.Nanosecond))
+	if err != nil {
+		return created, err
+	}
+	outcome := created
+	event.UserID = series.UserID
+	for _, occurrence := range occurrences {
+		if occurrence.RecurringEventID == series.ID && occurrence.RecurrenceID.Equal(recurrenceID) {
+			if sameEvent(occurrence, event) {
+				return unchanged, nil
+			}
+			outcome = updated
+			event.UserID = occurrence.UserID
+			event.Reminders = occurrence.Reminders
+		}
+	}
+	event.UID = ""
+	return outcome, a.storage.UpdateEvent(ctx, series.ID, event, storage.ScopeThis, recurrenceID)
+}
+
+// eventByUID finds the event with the given UID in the calendar. Events
+// created without a UID are exported with their ID as UID, so a UID that is
+// the ID of an event of the calendar matches that event too.
+func (a *App) eventByUID(ctx context.Context, calendarID uuid.UUID, uid string) (storage.Event, error) {
+	event, err := a.storage.GetEventByUID(ctx, calendarID, uid)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return event, err
+	}
+	id, parseErr := uuid.Parse(uid)
+	if parseErr != nil {
+		return event, err
+	}
+	event, err = a.storage.GetEvent(ctx, id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return storage.Event{}, err
+	}
+	if err != nil || event.CalendarID != calendarID || event.UID != "" || event.IsOverride() {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	return event, nil
+}
+
+// sameEvent reports whether importing imported over current changes nothing.
+func sameEvent(current, imported storage.Event) bool {
+	if current.Title != imported.Title || current.Description != imported.Description ||
+		!current.StartTime.Equal(imported.StartTime) || !current.EndTime.Equal(imported.EndTime) ||
+		current.RRule != imported.RRule || current.TimeZone != imported.TimeZone ||
+		len(current.ExDates) != len(imported.ExDates) {
+		return false
+	}
+	for _, exDate := range imported.ExDates {
+		if !current.ExDates.Contains(exDate) {
+			return false
+		}
+	}
+	return true
+}
+
+func isImportError(err error) bool {
+	for _, target := range importErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
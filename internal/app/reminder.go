@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// DefaultReminders returns the reminders of the caller's events that have
+// none of their own: the caller's default, or storage.FallbackReminders.
+func (a *App) DefaultReminders(ctx context.Context) (storage.Durations, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := a.storage.GetDefaultReminders(ctx, identity.UserID)
+	if err != nil || reminders != nil {
+		return reminders, err
+	}
+	return storage.FallbackReminders, nil
+}
+
+// SetDefaultReminders sets the caller's default reminders; nil restores
+// storage.FallbackReminders and an empty list turns them off.
+func (a *App) SetDefaultReminders(ctx context.Context, reminders storage.Durations) (storage.Durations, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.ValidateReminders(reminders); err != nil {
+		return nil, fmt.Errorf("%w: %w", storage.ErrInvalidReminders, err)
+	}
+	if err := a.storage.SetDefaultReminders(ctx, identity.UserID, reminders); err != nil {
+		return nil, err
+	}
+	if reminders == nil {
+		return storage.FallbackReminders, nil
+	}
+	return reminders, nil
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/ical"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// Feeds are fetched every defaultRefresh unless the subscription says
+// otherwise, and never more often than every minRefresh.
+const (
+	defaultRefresh = 6 * time.Hour
+	minRefresh     = 15 * time.Minute
+)
+
+// SyncResult summarizes a sync of a subscription: the import of its feed
+// and the deletion of the events no longer in it.
+type SyncResult struct {
+	ImportResult
+	Deleted int `json:"deleted"`
+}
+
+// CreateSubscription subscribes the calendar to the iCalendar feed at
+// subscription.URL; the feed is fetched by the scheduler. The caller needs
+// the editor role in the calendar.
+func (a *App) CreateSubscription(ctx context.Context, subscription storage.Subscription,
+) (storage.Subscription, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return storage.Subscription{}, err
+	}
+	if subscription.CalendarID == uuid.Nil {
+		return storage.Subscription{}, fmt.Errorf("%w: calendarId is required", storage.ErrInvalidSubscription)
+	}
+	err = a.requireRole(ctx, identity, subscription.CalendarID, storage.RoleEditor, storage.ErrCalendarNotFound)
+	if err != nil {
+		return storage.Subscription{}, err
+	}
+	feedURL, err := url.Parse(subscription.URL)
+	if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || feedURL.Host == "" {
+		return storage.Subscription{}, fmt.Errorf("%w: url must be an absolute http or https URL",
+			storage.ErrInvalidSubscription)
+	}
+	switch interval := subscription.RefreshInterval(); {
+	case interval == 0:
+		subscription.RefreshSeconds = int64(defaultRefresh / time.Second)
+	case interval < minRefresh:
+		return storage.Subscription{}, fmt.Errorf("%w: refreshSeconds must be at least %d",
+			storage.ErrInvalidSubscription, int64(minRefresh/time.Second))
+	}
+	now := time.Now()
+	subscription.ID = uuid.New()
+	subscription.UserID = identity.UserID
+	subscription.SubscriptionState = storage.SubscriptionState{NextFetchAt: now}
+	subscription.CreatedAt = now
+	if err := a.storage.CreateSubscription(ctx, subscription); err != nil {
+		return storage.Subscription{}, err
+	}
+	a.logger.Info("Calendar ", subscription.CalendarID, " subscribed to ", subscription.URL)
+	return subscription, nil
+}
+
+// ListSubscriptions lists the caller's subscriptions.
+func (a *App) ListSubscriptions(ctx context.Context) ([]storage.Subscription, error) {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.storage.ListSubscriptions(ctx, identity.UserID)
+}
+
+// DeleteSubscription stops syncing the caller's subscription; the events
+// already synced stay in the calendar.
+func (a *App) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	identity, err := auth.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	subscription, err := a.storage.GetSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+	if subscription.UserID != identity.UserID && !identity.Admin {
+		return storage.ErrSubscriptionNotFound
+	}
+	return a.storage.DeleteSubscription(ctx, id)
+}
+
+// SyncSubscription makes the calendar of the subscription match the feed
+// read from r: VEVENTs are imported by UID like ImportEvents does, and the
+// events of the calendar with a UID that is no longer in the feed are
+// deleted. Events without a UID, such as those created through the API, are
+// left alone. The calendar is written with the role of the subscription's
+// owner at the time of the sync.
+func (a *App) SyncSubscription(ctx context.Context, subscription storage.Subscription, r io.Reader,
+) (SyncResult, error) {
+	result := SyncResult{ImportResult: ImportResult{Errors: []ImportError{}}}
+	identity := auth.Identity{UserID: subscription.UserID,
+		Scopes: []string{auth.ScopeEventsRead, auth.ScopeEventsWrite}}
+	ctx = auth.WithIdentity(ctx, identity)
+	err := a.requireRole(ctx, identity, subscription.CalendarID, storage.RoleEditor, storage.ErrCalendarNotFound)
+	if err != nil {
+		return result, err
+	}
+	calendar, err := a.storage.GetCalendar(ctx, subscription.CalendarID)
+	if err != nil {
+		return result, err
+	}
+	items, err := decodeItems(r, calendar.Location())
+	if err != nil {
+		return result, err
+	}
+	if result.ImportResult, err = a.importItems(ctx, identity.UserID, calendar.ID, items); err != nil {
+		return result, err
+	}
+	if result.Deleted, err = a.deleteMissing(ctx, calendar.ID, items); err != nil {
+		return result, err
+	}
+	a.logger.Info("Synced subscription ", subscription.ID, " into calendar ", calendar.ID, ": ",
+		result.Created, " created, ", result.Updated, " updated, ", result.Unchanged, " unchanged, ",
+		result.Deleted, " deleted, ", len(result.Errors), " failed")
+	return result, nil
+}
+
+// deleteMissing deletes the events of the calendar whose UID is not in
+// items, with their overrides. A VEVENT that failed to decode still keeps
+// the event with its UID.
+func (a *App) deleteMissing(ctx context.Context, calendarID uuid.UUID, items []ical.Item) (int, error) {
+	uids := make(map[string]bool, len(items))
+	for _, item := range items {
+		uids[item.UID] = true
+	}
+	events, err := a.allEvents(ctx, []uuid.UUID{calendarID})
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, event := range events {
+		if event.UID == "" || event.IsOverride() || uids[event.UID] {
+			continue
+		}
+		if err := a.storage.DeleteEvent(ctx, event.ID, storage.ScopeAll, time.Time{}); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
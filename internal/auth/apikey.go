@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// APIKeyHeader carries "<key id>.<secret>" for APIKeyAuthenticator. gRPC
+// clients send it as "x-api-key" metadata.
+const APIKeyHeader = "X-API-Key"
+
+// Scopes an API key can be limited to. Users authenticated otherwise are not
+// limited.
+const (
+	ScopeEventsRead  = "events:read"
+	ScopeEventsWrite = "events:write"
+	// ScopeAdmin makes the key an admin credential; it implies every other scope.
+	ScopeAdmin = "admin"
+)
+
+var knownScopes = map[string]bool{ScopeEventsRead: true, ScopeEventsWrite: true, ScopeAdmin: true}
+
+// ErrInvalidScope is returned when creating a key with an unknown scope.
+var ErrInvalidScope = errors.New("invalid api key scope")
+
+// NewAPIKey generates a key for userID. The returned token is the only copy
+// of the secret and must be handed to the client.
+func NewAPIKey(userID uuid.UUID, name string, scopes []string, expiresAt time.Time) (storage.APIKey, string, error) {
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return storage.APIKey{}, "", fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	secret, hash, err := NewSecret()
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+	key := storage.APIKey{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Name:       name,
+		SecretHash: hash,
+		Scopes:     append(storage.Strings{}, scopes...),
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now(),
+	}
+	return key, key.ID.String() + "." + secret, nil
+}
+
+// APIKeyAuthenticator verifies API keys against the stored hashes and
+// records when each key was last used.
+type APIKeyAuthenticator struct {
+	keys storage.APIKeyInterface
+}
+
+func NewAPIKeyAuthenticator(keys storage.APIKeyInterface) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	value := creds(APIKeyHeader)
+	if value == "" {
+		return Identity{}, fmt.Errorf("%w: %s", ErrMissingCredentials, APIKeyHeader)
+	}
+	id, secret, ok := ParseToken(value)
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: malformed api key", ErrUnauthenticated)
+	}
+
+	key, err := a.keys.GetAPIKey(ctx, id)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		return Identity{}, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	if !SecretMatches(secret, key.SecretHash) {
+		return Identity{}, fmt.Errorf("%w: unknown api key", ErrUnauthenticated)
+	}
+	now := time.Now()
+	if key.Expired(now) {
+		return Identity{}, fmt.Errorf("%w: api key expired", ErrUnauthenticated)
+	}
+	if err := a.keys.TouchAPIKey(ctx, key.ID, now); err != nil {
+		return Identity{}, err
+	}
+	return Identity{
+		UserID: key.UserID,
+		Admin:  key.Scopes.Contains(ScopeAdmin),
+		Scopes: append([]string{}, key.Scopes...),
+	}, nil
+}
+
+// Chain tries each authenticator in turn and uses the first one whose
+// credentials are present in the request.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+func (c chain) Authenticate(ctx context.Context, creds Credentials) (Identity, error) {
+	err := fmt.Errorf("%w: no authenticator configured", ErrMissingCredentials)
+	for _, authn := range c {
+		var identity Identity
+		identity, err = authn.Authenticate(ctx, creds)
+		if !errors.Is(err, ErrMissingCredentials) {
+			return identity, err
+		}
+	}
+	return Identity{}, err
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	store := memorystorage.New()
+	userID := uuid.New()
+
+	key, token, err := NewAPIKey(userID, "bot", []string{ScopeEventsRead}, time.Time{})
+	assert.NoError(t, err)
+	assert.NotContains(t, key.SecretHash, token)
+	assert.NoError(t, store.CreateAPIKey(ctx, key))
+
+	authn := NewAPIKeyAuthenticator(store)
+	header := http.Header{}
+	header.Set(APIKeyHeader, token)
+	identity, err := authn.Authenticate(ctx, header.Get)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, identity.UserID)
+	assert.False(t, identity.Admin)
+	assert.True(t, identity.HasScope(ScopeEventsRead))
+	assert.False(t, identity.HasScope(ScopeEventsWrite))
+
+	stored, err := store.GetAPIKey(ctx, key.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.LastUsedAt.IsZero())
+
+	header.Set(APIKeyHeader, key.ID.String()+".wrong")
+	_, err = authn.Authenticate(ctx, header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	expired, expiredToken, err := NewAPIKey(userID, "old", nil, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, store.CreateAPIKey(ctx, expired))
+	header.Set(APIKeyHeader, expiredToken)
+	_, err = authn.Authenticate(ctx, header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, _, err = NewAPIKey(userID, "bad", []string{"everything"}, time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	store := memorystorage.New()
+	headerAuthn, err := NewHeaderAuthenticator(nil)
+	assert.NoError(t, err)
+	authn := Chain(NewAPIKeyAuthenticator(store), headerAuthn)
+
+	header := http.Header{}
+	_, err = authn.Authenticate(ctx, header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	userID := uuid.New()
+	header.Set(UserIDHeader, userID.String())
+	identity, err := authn.Authenticate(ctx, header.Get)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, identity.UserID)
+
+	// Invalid credentials of one scheme are not retried with the next one.
+	header.Set(APIKeyHeader, "garbage")
+	_, err = authn.Authenticate(ctx, header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
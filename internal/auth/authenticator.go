@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid" //nolint
+)
+
+// UserIDHeader carries the caller's user ID for HeaderAuthenticator. gRPC
+// clients send it as metadata under the same name.
+const UserIDHeader = "X-User-ID"
+
+// Credentials looks up a request header (HTTP) or metadata key (gRPC) by
+// name. Names are case-insensitive.
+type Credentials func(name string) string
+
+// Authenticator resolves the caller from the credentials of a request.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (Identity, error)
+}
+
+// HeaderAuthenticator trusts the user ID header as is. It is meant to run
+// behind a proxy that authenticates users and sets the header.
+type HeaderAuthenticator struct {
+	admins map[uuid.UUID]bool
+}
+
+// NewHeaderAuthenticator returns a HeaderAuthenticator treating the given
+// user IDs as admins.
+func NewHeaderAuthenticator(admins []string) (*HeaderAuthenticator, error) {
+	ids, err := parseAdmins(admins)
+	if err != nil {
+		return nil, err
+	}
+	return &HeaderAuthenticator{admins: ids}, nil
+}
+
+func parseAdmins(admins []string) (map[uuid.UUID]bool, error) {
+	ids := make(map[uuid.UUID]bool, len(admins))
+	for _, admin := range admins {
+		id, err := uuid.Parse(admin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid admin user ID %q: %w", admin, err)
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+func (a *HeaderAuthenticator) Authenticate(_ context.Context, creds Credentials) (Identity, error) {
+	value := creds(UserIDHeader)
+	if value == "" {
+		return Identity{}, fmt.Errorf("%w: %s", ErrMissingCredentials, UserIDHeader)
+	}
+	id, err := uuid.Parse(value)
+	if err != nil || id == uuid.Nil {
+		return Identity{}, fmt.Errorf("%w: invalid %s", ErrUnauthenticated, UserIDHeader)
+	}
+	return Identity{UserID: id, Admin: a.admins[id]}, nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"             //nolint
+	"github.com/stretchr/testify/assert" //nolint
+)
+
+func TestHeaderAuthenticator(t *testing.T) {
+	admin, user := uuid.New(), uuid.New()
+	authn, err := NewHeaderAuthenticator([]string{admin.String()})
+	assert.NoError(t, err)
+
+	header := http.Header{}
+	_, err = authn.Authenticate(context.Background(), header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	header.Set(UserIDHeader, "not-a-uuid")
+	_, err = authn.Authenticate(context.Background(), header.Get)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	header.Set(UserIDHeader, user.String())
+	identity, err := authn.Authenticate(context.Background(), header.Get)
+	assert.NoError(t, err)
+	assert.Equal(t, Identity{UserID: user}, identity)
+
+	header.Set(UserIDHeader, admin.String())
+	identity, err = authn.Authenticate(context.Background(), header.Get)
+	assert.NoError(t, err)
+	assert.True(t, identity.Admin)
+
+	_, err = NewHeaderAuthenticator([]string{"root"})
+	assert.Error(t, err)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	_, err := RequireAdmin(context.Background())
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	ctx := WithIdentity(context.Background(), Identity{UserID: uuid.New()})
+	_, err = RequireAdmin(ctx)
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	ctx = WithIdentity(context.Background(), Identity{UserID: uuid.New(), Admin: true})
+	_, err = RequireAdmin(ctx)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid" //nolint
+)
+
+var (
+	// ErrUnauthenticated means the request carries no usable identity.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied means the caller is known but may not do this.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrMissingCredentials means the request carries no credentials of the
+	// authenticator's kind, so the next authenticator of a Chain is tried.
+	ErrMissingCredentials = fmt.Errorf("%w: missing credentials", ErrUnauthenticated)
+)
+
+// Identity is the caller a request is served for.
+type Identity struct {
+	UserID uuid.UUID
+	// Admin callers may read and change the events of every user.
+	Admin bool
+	// Scopes limit what an API key may do; nil means unrestricted.
+	Scopes []string
+}
+
+// HasScope reports whether the caller may use the given scope.
+func (i Identity) HasScope(scope string) bool {
+	if i.Scopes == nil {
+		return true
+	}
+	for _, s := range i.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope returns ErrPermissionDenied unless the caller may use scope.
+func RequireScope(ctx context.Context, scope string) error {
+	identity, err := FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !identity.HasScope(scope) {
+		return fmt.Errorf("%w: requires scope %s", ErrPermissionDenied, scope)
+	}
+	return nil
+}
+
+// CanAccess reports whether the caller may see or change data owned by
+// userID.
+func (i Identity) CanAccess(userID uuid.UUID) bool {
+	return i.Admin || i.UserID == userID
+}
+
+// Owner returns the user an event written by the caller belongs to. Admins
+// may write on behalf of the requested user; everyone else always owns what
+// they write.
+func (i Identity) Owner(requested uuid.UUID) uuid.UUID {
+	if i.Admin && requested != uuid.Nil {
+		return requested
+	}
+	return i.UserID
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the caller of the request, or ErrUnauthenticated if
+// the request was not authenticated.
+func FromContext(ctx context.Context) (Identity, error) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	if !ok || identity.UserID == uuid.Nil {
+		return Identity{}, ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+// RequireAdmin returns the caller if it is an admin.
+func RequireAdmin(ctx context.Context) (Identity, error) {
+	identity, err := FromContext(ctx)
+	if err != nil {
+		return identity, err
+	}
+	if !identity.Admin {
+		return identity, ErrPermissionDenied
+	}
+	return identity, nil
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5" //nolint
+	"github.com/google/uuid"       //nolint
+)
+
+// AuthorizationHeader carries "Bearer <token>" for JWTAuthenticator. gRPC
+// clients send it as "authorization" metadata.
+const AuthorizationHeader = "Authorization"
+
+// JWTConfig selects how bearer tokens are verified. HS* algorithms use
+// Secret, RS* algorithms the PEM public key in PublicKeyFile.
+type JWTConfig struct {
+	Algorithm     string
+	Secret        string
+	PublicKeyFile string
+	// Issuer and Audience, if set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+}
+
+// Enabled reports whether a verification key is configured.
+func (c JWTConfig) Enabled() bool {
+	return c.Secret != "" || c.PublicKeyFile != ""
+}
+
+// JWTAuthenticator verifies signed bearer tokens. The sub claim is the
+// caller's user ID.
+type JWTAuthenticator struct {
+	key    interface{}
+	parser *jwt.Parser
+	admins map[uuid.UUID]bool
+}
+
+func NewJWTAuthenticator(cfg JWTConfig, admins []string) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{}
+	var err error
+	if a.admins, err = parseAdmins(admins); err != nil {
+		return nil, err
+	}
+
+	algorithm := strings.ToUpper(cfg.Algorithm)
+	switch algorithm {
+	case "HS256", "HS384", "HS512":
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("jwt algorithm %s requires a secret", algorithm)
+		}
+		a.key = []byte(cfg.Secret)
+	case "RS256", "RS384", "RS512":
+		pem, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt public key: %w", err)
+		}
+		if a.key, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("parse jwt public key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q", cfg.Algorithm)
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{algorithm}), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(options...)
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(_ context.Context, creds Credentials) (Identity, error) {
+	value := creds(AuthorizationHeader)
+	if value == "" {
+		return Identity{}, fmt.Errorf("%w: bearer token", ErrMissingCredentials)
+	}
+	scheme, token, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Identity{}, fmt.Errorf("%w: malformed authorization header", ErrUnauthenticated)
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err := a.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.key, nil
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil || id == uuid.Nil {
+		return Identity{}, fmt.Errorf("%w: token subject is not a user ID", ErrUnauthenticated)
+	}
+	return Identity{UserID: id, Admin: a.admins[id]}, nil
+}
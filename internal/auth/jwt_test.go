@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"       //nolint
+	"github.com/google/uuid"             //nolint
+	"github.com/stretchr/testify/assert" //nolint
+)
+
+func bearer(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.Claims) Credentials {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	assert.NoError(t, err)
+	header := http.Header{}
+	header.Set(AuthorizationHeader, "Bearer "+token)
+	return header.Get
+}
+
+func claimsFor(userID uuid.UUID, expiresIn time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		Issuer:    "calendar",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	}
+}
+
+func TestJWTAuthenticator_HMAC(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	authn, err := NewJWTAuthenticator(JWTConfig{Algorithm: "HS256", Secret: "secret", Issuer: "calendar"}, nil)
+	assert.NoError(t, err)
+
+	creds := bearer(t, jwt.SigningMethodHS256, []byte("secret"), claimsFor(userID, time.Hour))
+	identity, err := authn.Authenticate(ctx, creds)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, identity.UserID)
+
+	testCases := map[string]Credentials{
+		"missing":      http.Header{}.Get,
+		"wrong secret": bearer(t, jwt.SigningMethodHS256, []byte("other"), claimsFor(userID, time.Hour)),
+		"expired":      bearer(t, jwt.SigningMethodHS256, []byte("secret"), claimsFor(userID, -time.Minute)),
+		"wrong method": bearer(t, jwt.SigningMethodHS512, []byte("secret"), claimsFor(userID, time.Hour)),
+		"no expiry": bearer(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+			Subject: userID.String(), Issuer: "calendar",
+		}),
+		"wrong issuer": bearer(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+			Subject: userID.String(), Issuer: "other", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}),
+		"bad subject": bearer(t, jwt.SigningMethodHS256, []byte("secret"), jwt.RegisteredClaims{
+			Subject: "bob", Issuer: "calendar", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}),
+	}
+	for name, creds := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := authn.Authenticate(ctx, creds)
+			assert.ErrorIs(t, err, ErrUnauthenticated)
+		})
+	}
+}
+
+func TestJWTAuthenticator_RSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "jwt.pub")
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	admin := uuid.New()
+	authn, err := NewJWTAuthenticator(JWTConfig{Algorithm: "RS256", PublicKeyFile: keyFile}, []string{admin.String()})
+	assert.NoError(t, err)
+
+	creds := bearer(t, jwt.SigningMethodRS256, key, claimsFor(admin, time.Hour))
+	identity, err := authn.Authenticate(context.Background(), creds)
+	assert.NoError(t, err)
+	assert.Equal(t, Identity{UserID: admin, Admin: true}, identity)
+
+	// An HMAC token signed with the public key must not pass as RS256.
+	pub, _ := os.ReadFile(keyFile)
+	_, err = authn.Authenticate(context.Background(), bearer(t, jwt.SigningMethodHS256, pub, claimsFor(admin, time.Hour)))
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestNewJWTAuthenticator_InvalidConfig(t *testing.T) {
+	_, err := NewJWTAuthenticator(JWTConfig{Algorithm: "none", Secret: "secret"}, nil)
+	assert.Error(t, err)
+	_, err = NewJWTAuthenticator(JWTConfig{Algorithm: "HS256"}, nil)
+	assert.Error(t, err)
+	_, err = NewJWTAuthenticator(JWTConfig{Algorithm: "RS256", PublicKeyFile: "/nonexistent"}, nil)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"github.com/spf13/viper" //nolint:depguard
@@ -15,6 +16,7 @@ type Config struct {
 	RabbitMQ  RabbitMQConfig
 	Scheduler SchedulerConfig
 	Sender    SenderConfig
+	Auth      AuthConfig
 }
 
 type ServerConfig struct {
@@ -34,6 +36,30 @@ type LoggerConfig struct {
 type DatabaseConfig struct {
 	Driver string
 	DSN    string
+	// ConflictPolicy is "reject" (default), "allow" or "warn" for overlapping events of a user.
+	ConflictPolicy string
+	// QueryTimeout bounds a single query or transaction; the storage default applies when unset.
+	QueryTimeout time.Duration
+}
+
+type AuthConfig struct {
+	// Mode is "jwt" (default), which requires a JWT key, or "header", which
+	// trusts the X-User-ID header and is only safe behind a proxy that
+	// authenticates users and sets it.
+	Mode string
+	// Admins are the user IDs allowed to list and edit the events of every user.
+	Admins []string
+	// JWT verifies bearer tokens.
+	JWT JWTConfig
+}
+
+type JWTConfig struct {
+	// Algorithm is one of HS256/384/512 (Secret) or RS256/384/512 (PublicKeyFile).
+	Algorithm     string
+	Secret        string
+	PublicKeyFile string
+	Issuer        string
+	Audience      string
 }
 
 type RabbitMQConfig struct {
@@ -42,10 +68,19 @@ type RabbitMQConfig struct {
 
 type SchedulerConfig struct {
 	Interval time.Duration
+	// LeaseTTL is how long the other replicas wait for a leader that stopped
+	// renewing its lease before one of them takes over; 15s when unset.
+	LeaseTTL time.Duration
+	// CleanupInterval is how often the events that ended over a year ago
+	// are deleted; 1h when unset.
+	CleanupInterval time.Duration
 }
 
 type SenderConfig struct{}
 
+// JWTSecretEnv overrides auth.jwt.secret.
+const JWTSecretEnv = "CALENDAR_JWT_SECRET"
+
 func LoadConfig(configPath string) Config {
 	viper.SetConfigFile(configPath)
 
@@ -60,6 +95,11 @@ func LoadConfig(configPath string) Config {
 		log.Fatalf("Unable to decode into struct, %v", err)
 	}
 
+	// The JWT secret is better kept out of the config file.
+	if secret := os.Getenv(JWTSecretEnv); secret != "" {
+		config.Auth.JWT.Secret = secret
+	}
+
 	if intervalStr := viper.GetString("scheduler.interval"); intervalStr != "" {
 		config.Scheduler.Interval, err = time.ParseDuration(intervalStr)
 		if err != nil {
@@ -67,5 +107,12 @@ func LoadConfig(configPath string) Config {
 		}
 	}
 
+	if timeoutStr := viper.GetString("database.queryTimeout"); timeoutStr != "" {
+		config.Database.QueryTimeout, err = time.ParseDuration(timeoutStr)
+		if err != nil {
+			log.Fatalf("Invalid database query timeout format, %v", err)
+		}
+	}
+
 	return config
 }
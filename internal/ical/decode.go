@@ -0,0 +1,307 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// ErrMalformed is returned when the input is not an iCalendar object.
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+// untitled is the title of events imported without a SUMMARY.
+const untitled = "Untitled"
+
+// maxLineLength bounds an unfolded content line.
+const maxLineLength = 1 << 20
+
+// Item is a VEVENT read by the Decoder.
+type Item struct {
+	// UID identifies the event; overrides share the UID of their series.
+	UID string
+	// RecurrenceID is set on an override: the original start of the
+	// occurrence it replaces.
+	RecurrenceID time.Time
+	// Event holds the mapped fields, without IDs, owner or calendar.
+	Event storage.Event
+	// Err reports why the VEVENT could not be mapped; Event is then
+	// incomplete.
+	Err error
+}
+
+// Decoder reads the VEVENTs of a VCALENDAR.
+type Decoder struct {
+	r io.Reader
+	// Location is the zone of floating times and all-day dates, UTC if nil.
+	Location *time.Location
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode reads every VEVENT of the input. A VEVENT that cannot be mapped is
+// returned with Err set; the error result is reserved for input that is not
+// a well-formed VCALENDAR.
+func (d *Decoder) Decode() ([]Item, error) {
+	lines, err := unfold(d.r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	var stack []string
+	var event []property
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrMalformed, i+1, err)
+		}
+		switch {
+		case prop.name == "BEGIN":
+			if len(stack) == 0 && !strings.EqualFold(prop.value, "VCALENDAR") {
+				return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", ErrMalformed)
+			}
+			stack = append(stack, strings.ToUpper(prop.value))
+			event = nil
+		case prop.name == "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrMalformed, i+1, prop.value)
+			}
+			if stack[len(stack)-1] == "VEVENT" {
+				items = append(items, d.item(event))
+			}
+			stack = stack[:len(stack)-1]
+		case len(stack) == 0:
+			return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", ErrMalformed)
+		case stack[len(stack)-1] == "VEVENT":
+			event = append(event, prop)
+		}
+	}
+	if len(stack) != 0 || lines == nil {
+		return nil, fmt.Errorf("%w: unterminated VCALENDAR", ErrMalformed)
+	}
+	return items, nil
+}
+
+// item maps the properties of a VEVENT.
+func (d *Decoder) item(props []property) Item {
+	item := Item{Event: storage.Event{Title: untitled}}
+	var duration time.Duration
+	var hasEnd, hasDuration, allDay bool
+	for _, prop := range props {
+		var err error
+		switch prop.name {
+		case "UID":
+			item.UID = unescape(prop.value)
+		case "SUMMARY":
+			item.Event.Title = unescape(prop.value)
+		case "DESCRIPTION":
+			item.Event.Description = unescape(prop.value)
+		case "DTSTART":
+			item.Event.StartTime, allDay, err = d.parseTime(prop, prop.value)
+		case "DTEND":
+			item.Event.EndTime, _, err = d.parseTime(prop, prop.value)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseDuration(prop.value)
+			hasDuration = true
+		case "RRULE":
+			var rule storage.Recurrence
+			if rule, err = storage.ParseRecurrence(prop.value); err == nil {
+				item.Event.RRule = rule.String()
+			}
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				var exdate time.Time
+				if exdate, _, err = d.parseTime(prop, value); err != nil {
+					break
+				}
+				item.Event.ExDates = append(item.Event.ExDates, exdate)
+			}
+		case "RECURRENCE-ID":
+			item.RecurrenceID, _, err = d.parseTime(prop, prop.value)
+		}
+		if err != nil {
+			item.Err = fmt.Errorf("%s: %w", prop.name, err)
+			return item
+		}
+	}
+
+	switch {
+	case item.UID == "":
+		item.Err = errors.New("UID is required")
+	case item.Event.StartTime.IsZero():
+		item.Err = errors.New("DTSTART is required")
+	case hasDuration:
+		item.Event.EndTime = item.Event.StartTime.Add(duration)
+	case !hasEnd && allDay:
+		item.Event.EndTime = item.Event.StartTime.AddDate(0, 0, 1)
+	case !hasEnd:
+		item.Event.EndTime = item.Event.StartTime
+	}
+	item.Event.UID = item.UID
+	// A series recurs in the zone of its DTSTART; UTC needs no zone.
+	if loc := item.Event.StartTime.Location(); item.Event.IsRecurring() && loc.String() != "UTC" {
+		item.Event.TimeZone = loc.String()
+	}
+	return item
+}
+
+// parseTime reads a DATE or DATE-TIME value of prop: in UTC, in the zone of
+// its TZID parameter or, for floating times and dates, in d.Location.
+func (d *Decoder) parseTime(prop property, value string) (time.Time, bool, error) {
+	loc := d.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if tzid, ok := prop.params["TZID"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(strings.TrimPrefix(tzid, "/")); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	return t, false, err
+}
+
+// parseDuration reads an RFC 5545 DURATION such as PT1H30M, P1D or -P2W.
+func parseDuration(value string) (time.Duration, error) {
+	rest := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	if strings.HasPrefix(rest, "-") {
+		sign = -1
+	}
+	rest = strings.TrimLeft(rest, "+-")
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	units := map[byte]time.Duration{
+		'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+	}
+	var total time.Duration
+	number := ""
+	for _, c := range []byte(rest[1:]) {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+		case units[c] != 0 && number != "":
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			total += time.Duration(n) * units[c]
+			number = ""
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+// unfold reads the content lines of r, joining folded continuation lines.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return lines, nil
+}
+
+// parseProperty splits a content line into its upper-cased name, parameters
+// and value. Parameter values may be quoted to contain ";", ":" or ",".
+func parseProperty(line string) (property, error) {
+	prop := property{params: make(map[string]string)}
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return prop, fmt.Errorf("invalid content line %q", line)
+	}
+	prop.name = strings.ToUpper(line[:end])
+	rest := line[end:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return prop, fmt.Errorf("invalid parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return prop, fmt.Errorf("unterminated quote in %q", line)
+			}
+			value, rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return prop, fmt.Errorf("missing value in %q", line)
+			}
+			value, rest = rest[:stop], rest[stop:]
+		}
+		prop.params[name] = value
+	}
+	if !strings.HasPrefix(rest, ":") {
+		return prop, fmt.Errorf("missing value in %q", line)
+	}
+	prop.value = rest[1:]
+	return prop, nil
+}
+
+// unescape reverses Escape.
+func unescape(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i == len(text)-1 {
+			b.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch text[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/stretchr/testify/assert"             //nolint
+	"github.com/stretchr/testify/require"            //nolint
+)
+
+func decode(t *testing.T, loc *time.Location, lines ...string) []Item {
+	t.Helper()
+	decoder := NewDecoder(strings.NewReader(strings.Join(lines, "\r\n")))
+	decoder.Location = loc
+	items, err := decoder.Decode()
+	require.NoError(t, err)
+	return items
+}
+
+func TestDecode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	items := decode(t, moscow,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T030000",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		`SUMMARY:Standup\; team\, all`,
+		`DESCRIPTION:Line one\nLine`,
+		"  two",
+		`DTSTART;TZID="Europe/Berlin":20240301T100000`,
+		"DURATION:PT15M",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"EXDATE;TZID=Europe/Berlin:20240304T100000,20240306T100000",
+		"EXDATE:20240311T090000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:standup@example.com",
+		"RECURRENCE-ID:20240313T090000Z",
+		"SUMMARY:Standup (moved)",
+		"DTSTART:20240313T120000Z",
+		"DTEND:20240313T121500Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:holiday@example.com",
+		"DTSTART;VALUE=DATE:20240308",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken@example.com",
+		"DTSTART;TZID=Mars/Olympus:20240301T100000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:No UID",
+		"DTSTART:20240301T100000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+	require.Len(t, items, 5)
+
+	series := items[0]
+	require.NoError(t, series.Err)
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, "standup@example.com", series.UID)
+	assert.Equal(t, "standup@example.com", series.Event.UID)
+	assert.Equal(t, "Standup; team, all", series.Event.Title)
+	assert.Equal(t, "Line one\nLine two", series.Event.Description)
+	assert.True(t, start.Equal(series.Event.StartTime))
+	assert.True(t, start.Add(15*time.Minute).Equal(series.Event.EndTime))
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", series.Event.RRule)
+	assert.Equal(t, "Europe/Berlin", series.Event.TimeZone)
+	assert.Len(t, series.Event.ExDates, 3)
+	assert.True(t, series.Event.ExDates.Contains(start.AddDate(0, 0, 10)))
+	assert.True(t, series.RecurrenceID.IsZero())
+	assert.Equal(t, uuid.Nil, series.Event.ID)
+
+	override := items[1]
+	require.NoError(t, override.Err)
+	assert.True(t, start.AddDate(0, 0, 12).Equal(override.RecurrenceID))
+	assert.Equal(t, "Standup (moved)", override.Event.Title)
+	assert.Empty(t, override.Event.TimeZone)
+
+	holiday := items[2]
+	require.NoError(t, holiday.Err)
+	assert.Equal(t, untitled, holiday.Event.Title)
+	assert.True(t, time.Date(2024, 3, 8, 0, 0, 0, 0, moscow).Equal(holiday.Event.StartTime))
+	assert.True(t, time.Date(2024, 3, 9, 0, 0, 0, 0, moscow).Equal(holiday.Event.EndTime))
+
+	assert.ErrorContains(t, items[3].Err, "Mars/Olympus")
+	assert.ErrorContains(t, items[4].Err, "UID is required")
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{
+		ID:          uuid.New(),
+		Title:       "Review, " + strings.Repeat("long title ", 10),
+		Description: `Back\slash`,
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		RRule:       "FREQ=DAILY;COUNT=5",
+		ExDates:     storage.Times{start.AddDate(0, 0, 1)},
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	for _, loc := range []*time.Location{nil, berlin} {
+		items := decode(t, nil, strings.Split(encode(t, loc, event), "\r\n")...)
+		require.Len(t, items, 1)
+		require.NoError(t, items[0].Err)
+		decoded := items[0].Event
+		assert.Equal(t, event.ID.String(), decoded.UID)
+		assert.Equal(t, event.Title, decoded.Title)
+		assert.Equal(t, event.Description, decoded.Description)
+		assert.True(t, event.StartTime.Equal(decoded.StartTime))
+		assert.True(t, event.EndTime.Equal(decoded.EndTime))
+		assert.Equal(t, event.RRule, decoded.RRule)
+		require.Len(t, decoded.ExDates, 1)
+		assert.True(t, event.ExDates[0].Equal(decoded.ExDates[0]))
+	}
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"BEGIN:VEVENT\r\nEND:VEVENT",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR",
+		"BEGIN:VCALENDAR\r\nno colon here\r\nEND:VCALENDAR",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VEVENT",
+	} {
+		_, err := NewDecoder(strings.NewReader(input)).Decode()
+		assert.ErrorIs(t, err, ErrMalformed, input)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT15M":     15 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT2H30S": 26*time.Hour + 30*time.Second,
+		"-PT1H":     -time.Hour,
+	} {
+		duration, err := parseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, duration, value)
+	}
+	for _, value := range []string{"", "P", "PT", "1H", "PT1X", "PT5"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,229 @@
+// Package ical reads and writes events in the iCalendar format (RFC 5545).
+package ical
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// ProdID identifies this service as the producer of exported calendars.
+const ProdID = "-//Dendyator//calendar//EN"
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	// maxLineOctets is the length at which content lines are folded.
+	maxLineOctets = 75
+)
+
+// Encoder writes events as a VCALENDAR.
+type Encoder struct {
+	w *bufio.Writer
+	// Name, if set, is written as the X-WR-CALNAME shown by most clients.
+	Name string
+	// Location selects how times are written: in UTC if nil or UTC,
+	// otherwise as local times with a TZID and a matching VTIMEZONE.
+	Location *time.Location
+	// Stamp is the DTSTAMP of every VEVENT, by default the time of Encode.
+	Stamp time.Time
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes one VCALENDAR holding a VEVENT per event. Series keep their
+// RRULE and EXDATEs; overrides carry the UID of their series and a
+// RECURRENCE-ID.
+func (e *Encoder) Encode(events []storage.Event) error {
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + ProdID)
+	e.line("CALSCALE:GREGORIAN")
+	if e.Name != "" {
+		e.line("X-WR-CALNAME:" + Escape(e.Name))
+	}
+	if e.local() {
+		e.timezone(events)
+	}
+	uids := make(map[uuid.UUID]string, len(events))
+	for _, event := range events {
+		uids[event.ID] = uid(event)
+	}
+	for _, event := range events {
+		if err := e.event(event, uids, stamp); err != nil {
+			return err
+		}
+	}
+	e.line("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+// event writes a VEVENT. uids maps the IDs of the encoded events to their
+// UIDs, which overrides share with their series.
+func (e *Encoder) event(event storage.Event, uids map[uuid.UUID]string, stamp time.Time) error {
+	e.line("BEGIN:VEVENT")
+	if event.IsOverride() {
+		seriesUID, ok := uids[event.RecurringEventID]
+		if !ok {
+			seriesUID = event.RecurringEventID.String()
+		}
+		e.line("UID:" + Escape(seriesUID))
+		e.line("RECURRENCE-ID" + e.time(event.RecurrenceID))
+	} else {
+		e.line("UID:" + Escape(uid(event)))
+	}
+	e.line("DTSTAMP:" + stamp.UTC().Format(utcLayout))
+	e.line("DTSTART" + e.time(event.StartTime))
+	e.line("DTEND" + e.time(event.EndTime))
+	e.line("SUMMARY:" + Escape(event.Title))
+	if event.Description != "" {
+		e.line("DESCRIPTION:" + Escape(event.Description))
+	}
+	if event.IsRecurring() {
+		rule, err := storage.ParseRecurrence(event.RRule)
+		if err != nil {
+			return err
+		}
+		e.line("RRULE:" + rule.String())
+		for _, exdate := range event.ExDates {
+			e.line("EXDATE" + e.time(exdate))
+		}
+	}
+	e.line("END:VEVENT")
+	return nil
+}
+
+// uid returns the iCalendar UID of event: the imported one, or else its ID.
+func uid(event storage.Event) string {
+	if event.UID != "" {
+		return event.UID
+	}
+	return event.ID.String()
+}
+
+func (e *Encoder) local() bool {
+	return e.Location != nil && e.Location != time.UTC && e.Location.String() != "UTC"
+}
+
+// time formats t as the parameters and value of a date-time property.
+func (e *Encoder) time(t time.Time) string {
+	if !e.local() {
+		return ":" + t.UTC().Format(utcLayout)
+	}
+	return ";TZID=" + e.Location.String() + ":" + t.In(e.Location).Format(localLayout)
+}
+
+// timezone writes a VTIMEZONE for e.Location with one observance per UTC
+// offset change in the years spanned by events, and the offset in force at
+// the start of that span.
+func (e *Encoder) timezone(events []storage.Event) {
+	first, last := time.Now().Year(), time.Now().Year()
+	for _, event := range events {
+		first = min(first, event.StartTime.In(e.Location).Year())
+		last = max(last, event.EndTime.In(e.Location).Year())
+	}
+	from := time.Date(first-1, 1, 1, 0, 0, 0, 0, e.Location)
+	to := time.Date(last+1, 12, 31, 0, 0, 0, 0, e.Location)
+
+	e.line("BEGIN:VTIMEZONE")
+	e.line("TZID:" + e.Location.String())
+	e.observance(from, offset(from), offset(from))
+	for _, change := range transitions(from, to) {
+		_, before := change.Add(-time.Second).Zone()
+		e.observance(change, before, offset(change))
+	}
+	e.line("END:VTIMEZONE")
+}
+
+func (e *Encoder) observance(at time.Time, offsetFrom, offsetTo int) {
+	kind := "STANDARD"
+	if at.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	name, _ := at.Zone()
+	e.line("BEGIN:" + kind)
+	// DTSTART of an observance is the local time in the offset before it.
+	e.line("DTSTART:" + at.UTC().Add(time.Duration(offsetFrom)*time.Second).Format(localLayout))
+	e.line("TZOFFSETFROM:" + formatOffset(offsetFrom))
+	e.line("TZOFFSETTO:" + formatOffset(offsetTo))
+	e.line("TZNAME:" + Escape(name))
+	e.line("END:" + kind)
+}
+
+func offset(t time.Time) int {
+	_, seconds := t.Zone()
+	return seconds
+}
+
+// transitions returns the instants in [from, to) at which the UTC offset of
+// from's location changes, to the second.
+func transitions(from, to time.Time) []time.Time {
+	var changes []time.Time
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		if offset(day) == offset(next) {
+			continue
+		}
+		seconds := sort.Search(24*60*60, func(s int) bool {
+			return offset(day.Add(time.Duration(s)*time.Second)) != offset(day)
+		})
+		changes = append(changes, day.Add(time.Duration(seconds)*time.Second))
+	}
+	return changes
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	hours, minutes := seconds/3600, seconds%3600/60
+	value := sign + twoDigits(hours) + twoDigits(minutes)
+	if seconds%60 != 0 {
+		value += twoDigits(seconds % 60)
+	}
+	return value
+}
+
+func twoDigits(n int) string {
+	return string([]byte{byte('0' + n/10), byte('0' + n%10)})
+}
+
+// Escape escapes a TEXT value: backslashes, semicolons, commas and newlines.
+func Escape(text string) string {
+	return textEscaper.Replace(text)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// line writes a content line folded into chunks of at most maxLineOctets
+// octets without splitting UTF-8 sequences, ended by CRLF.
+func (e *Encoder) line(content string) {
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		e.w.WriteString(content[:cut])
+		e.w.WriteString("\r\n ")
+		content = content[cut:]
+		// Continuation lines start with a space that counts toward the limit.
+		limit = maxLineOctets - 1
+	}
+	e.w.WriteString(content)
+	e.w.WriteString("\r\n")
+}
@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/stretchr/testify/assert"             //nolint
+	"github.com/stretchr/testify/require"            //nolint
+)
+
+var stamp = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func encode(t *testing.T, loc *time.Location, events ...storage.Event) string {
+	t.Helper()
+	var out bytes.Buffer
+	encoder := NewEncoder(&out)
+	encoder.Location, encoder.Stamp = loc, stamp
+	require.NoError(t, encoder.Encode(events))
+	return out.String()
+}
+
+func TestEncode_UTC(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	series := storage.Event{
+		ID:          uuid.MustParse("6f1c1f8e-2a6b-4c39-9a0c-5b0b6f0c2d10"),
+		Title:       "Standup; team, all",
+		Description: "Line one\nLine two",
+		StartTime:   start,
+		EndTime:     start.Add(15 * time.Minute),
+		RRule:       "FREQ=WEEKLY;BYDAY=MO,WE",
+		ExDates:     storage.Times{start.AddDate(0, 0, 7)},
+	}
+	override := storage.Event{
+		ID:               uuid.New(),
+		Title:            "Standup (moved)",
+		StartTime:        start.Add(2 * time.Hour),
+		EndTime:          start.Add(2*time.Hour + 15*time.Minute),
+		RecurringEventID: series.ID,
+		RecurrenceID:     start.AddDate(0, 0, 2),
+	}
+
+	out := encode(t, nil, series, override)
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ProdID,
+		"CALSCALE:GREGORIAN",
+		"BEGIN:VEVENT",
+		"UID:6f1c1f8e-2a6b-4c39-9a0c-5b0b6f0c2d10",
+		"DTSTAMP:20240101T000000Z",
+		"DTSTART:20240301T100000Z",
+		"DTEND:20240301T101500Z",
+		`SUMMARY:Standup\; team\, all`,
+		`DESCRIPTION:Line one\nLine two`,
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"EXDATE:20240308T100000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:6f1c1f8e-2a6b-4c39-9a0c-5b0b6f0c2d10",
+		"RECURRENCE-ID:20240303T100000Z",
+		"DTSTAMP:20240101T000000Z",
+		"DTSTART:20240301T120000Z",
+		"DTEND:20240301T121500Z",
+		`SUMMARY:Standup (moved)`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), out)
+}
+
+func TestEncode_TZID(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	start := time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)
+
+	out := encode(t, berlin, storage.Event{ID: uuid.New(), Title: "Summer", StartTime: start,
+		EndTime: start.Add(time.Hour)})
+	assert.Contains(t, out, "\r\nDTSTART;TZID=Europe/Berlin:20240701T100000\r\n")
+	assert.Contains(t, out, "\r\nTZID:Europe/Berlin\r\n")
+	// The switch to summer time on 31 March 2024 at 02:00 CET.
+	assert.Contains(t, out, "BEGIN:DAYLIGHT\r\nDTSTART:20240331T020000\r\nTZOFFSETFROM:+0100\r\n"+
+		"TZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT\r\n")
+	assert.Contains(t, out, "BEGIN:STANDARD\r\nDTSTART:20241027T030000\r\nTZOFFSETFROM:+0200\r\n"+
+		"TZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD\r\n")
+}
+
+func TestEncode_Folding(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	title := strings.Repeat("Планёрка ", 20)
+	out := encode(t, nil, storage.Event{ID: uuid.New(), Title: title, StartTime: start, EndTime: start})
+
+	var unfolded []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, line)
+		if strings.HasPrefix(line, " ") {
+			unfolded[len(unfolded)-1] += line[1:]
+			continue
+		}
+		unfolded = append(unfolded, line)
+	}
+	assert.Contains(t, unfolded, "SUMMARY:"+title)
+}
@@ -0,0 +1,168 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// Elector elects one leader among the replicas sharing a lease. The leader
+// renews the lease every third of its TTL; when it stops, crashes or loses
+// the database, another replica takes over within one TTL, or at once if
+// the lease was released.
+type Elector struct {
+	store  storage.LeaseInterface
+	name   string
+	id     string
+	ttl    time.Duration
+	logger app.Logger
+
+	mu     sync.Mutex
+	leader string
+}
+
+// NewElector returns an elector campaigning for the named lease as id, which
+// must be unique among the replicas.
+func NewElector(store storage.LeaseInterface, name, id string, ttl time.Duration, logger app.Logger) *Elector {
+	return &Elector{store: store, name: name, id: id, ttl: ttl, logger: logger}
+}
+
+// Leader returns the holder of the lease as last seen, or "" if unknown.
+func (e *Elector) Leader() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Run campaigns for the lease until ctx is done. While this replica leads,
+// lead runs with a context that is cancelled when the lease cannot be
+// renewed, and at the latest when the last renewal expires, so that lead
+// stops before another replica can take over even if a renewal hangs. Run
+// waits for lead to return before it campaigns on, and releases the lease on
+// the way out.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	var current *term
+	defer e.release()
+	defer func() {
+		if current != nil {
+			current.end()
+		}
+	}()
+
+	for {
+		leading, expires := e.campaign(ctx)
+		if current != nil && (!leading || current.over()) {
+			current.end()
+			current = nil
+		}
+		switch {
+		case leading && current == nil:
+			current = startTerm(ctx, lead, expires)
+		case leading:
+			current.extend(expires)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// term is a period of leadership. Its context is cancelled when the lease
+// expires unless the term is extended first.
+type term struct {
+	cancel context.CancelFunc
+	expiry *time.Timer
+	done   chan struct{}
+}
+
+func startTerm(ctx context.Context, lead func(ctx context.Context), expires time.Time) *term {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &term{cancel: cancel, expiry: time.AfterFunc(time.Until(expires), cancel), done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		lead(ctx)
+	}()
+	return t
+}
+
+// extend moves the end of the term to the expiry of a renewed lease.
+func (t *term) extend(expires time.Time) {
+	t.expiry.Reset(time.Until(expires))
+}
+
+// over reports whether lead has returned, e.g. because the lease expired.
+func (t *term) over() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// end cancels the term and waits for lead to return.
+func (t *term) end() {
+	t.expiry.Stop()
+	t.cancel()
+	<-t.done
+}
+
+// campaign acquires or renews the lease and reports whether this replica
+// leads and until when. A renewal must finish well before the lease expires,
+// and a failed one steps down, since another replica may take over
+// meanwhile. The expiry is counted on the local clock from before the
+// request, so it never falls after the expiry the database recorded.
+func (e *Elector) campaign(ctx context.Context) (bool, time.Time) {
+	expires := time.Now().Add(e.ttl)
+	ctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	lease, err := e.store.AcquireLease(ctx, e.name, e.id, e.ttl)
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			e.logger.Error(fmt.Sprintf("Failed to acquire %s lease: %v", e.name, err))
+		}
+		e.setLeader("")
+		return false, time.Time{}
+	}
+	e.setLeader(lease.Holder)
+	return lease.Holder == e.id, expires
+}
+
+// setLeader logs each change of leader.
+func (e *Elector) setLeader(holder string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if holder == e.leader {
+		return
+	}
+	e.leader = holder
+	switch holder {
+	case "":
+	case e.id:
+		e.logger.Info(fmt.Sprintf("Leader of %s is %s (this replica)", e.name, holder))
+	default:
+		e.logger.Info(fmt.Sprintf("Leader of %s is %s; %s is standing by", e.name, holder, e.id))
+	}
+}
+
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+
+	if err := e.store.ReleaseLease(ctx, e.name, e.id); err != nil {
+		e.logger.Error(fmt.Sprintf("Failed to release %s lease: %v", e.name, err))
+	}
+}
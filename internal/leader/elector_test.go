@@ -0,0 +1,103 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestElectorFailover(t *testing.T) {
+	store := memorystorage.New()
+	ttl := 30 * time.Millisecond
+	leading := make(chan string, 2)
+	lead := func(id string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			leading <- id
+			<-ctx.Done()
+		}
+	}
+
+	first := NewElector(store, "scheduler", "first", ttl, logger.New("info"))
+	ctx1, stop1 := context.WithCancel(context.Background())
+	done1 := make(chan struct{})
+	go func() {
+		defer close(done1)
+		first.Run(ctx1, lead("first"))
+	}()
+	require.Equal(t, "first", <-leading)
+
+	second := NewElector(store, "scheduler", "second", ttl, logger.New("info"))
+	ctx2, stop2 := context.WithCancel(context.Background())
+	defer stop2()
+	go second.Run(ctx2, lead("second"))
+	require.Eventually(t, func() bool { return second.Leader() == "first" }, time.Second, time.Millisecond)
+	assert.Empty(t, leading)
+
+	// The first replica releases the lease on the way out, so the second
+	// takes over within one renewal period.
+	stop1()
+	<-done1
+	select {
+	case id := <-leading:
+		assert.Equal(t, "second", id)
+	case <-time.After(ttl):
+		t.Fatal("no failover")
+	}
+	assert.Equal(t, "second", second.Leader())
+}
+
+// hangingStore grants the lease once and then hangs on every renewal.
+type hangingStore struct {
+	granted bool
+	hang    chan struct{}
+}
+
+func (s *hangingStore) AcquireLease(_ context.Context, name, holder string, ttl time.Duration) (storage.Lease, error) {
+	if s.granted {
+		<-s.hang
+		return storage.Lease{}, context.Canceled
+	}
+	s.granted = true
+	return storage.Lease{Name: name, Holder: holder, ExpiresAt: time.Now().Add(ttl)}, nil
+}
+
+func (s *hangingStore) ReleaseLease(context.Context, string, string) error {
+	return nil
+}
+
+func TestElectorStopsLeadingWhenLeaseExpires(t *testing.T) {
+	store := &hangingStore{hang: make(chan struct{})}
+	ttl := 30 * time.Millisecond
+	ended := make(chan time.Duration, 1)
+
+	elector := NewElector(store, "scheduler", "first", ttl, logger.New("info"))
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx, func(ctx context.Context) {
+			started := time.Now()
+			<-ctx.Done()
+			ended <- time.Since(started)
+		})
+	}()
+
+	// The renewal hangs past its timeout, yet lead stops when the lease
+	// expires rather than when the renewal returns.
+	select {
+	case elapsed := <-ended:
+		assert.Less(t, elapsed, 2*ttl)
+	case <-time.After(time.Second):
+		t.Fatal("lead outlived the lease")
+	}
+	close(store.hang)
+	stop()
+	<-done
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// batch bounds the messages sent in one call to RelayOutbox.
+const batch = 100
+
+// Publisher puts a message on a queue and returns once the broker has
+// confirmed it.
+type Publisher interface {
+	PublishWithID(queue, messageID string, body []byte) error
+}
+
+// Relay drains the outbox to the queues named by the message topics. A
+// message is marked sent only after the broker confirmed it, so a crash
+// in between sends it again: consumers see every message at least once and
+// deduplicate by the message ID.
+type Relay struct {
+	store     storage.OutboxInterface
+	publisher Publisher
+	logger    app.Logger
+}
+
+func NewRelay(store storage.OutboxInterface, publisher Publisher, logger app.Logger) *Relay {
+	return &Relay{store: store, publisher: publisher, logger: logger}
+}
+
+// Drain sends the unsent messages until the outbox is empty or sending
+// fails, and returns the number sent.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		sent, err := r.store.RelayOutbox(ctx, batch, r.send)
+		total += sent
+		if err != nil {
+			return total, fmt.Errorf("relay outbox: %w", err)
+		}
+		if sent < batch {
+			return total, nil
+		}
+	}
+}
+
+// Run drains the outbox every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sent, err := r.Drain(ctx)
+		if sent > 0 {
+			r.logger.Info(fmt.Sprintf("Relayed %d outbox messages", sent))
+		}
+		if err != nil {
+			r.logger.Error(err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) send(message storage.OutboxMessage) error {
+	return r.publisher.PublishWithID(message.Topic, strconv.FormatInt(message.ID, 10), message.Payload)
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+type published struct {
+	queue, id string
+	change    storage.EventChange
+}
+
+type fakePublisher struct {
+	messages []published
+	err      error
+}
+
+func (p *fakePublisher) PublishWithID(queue, messageID string, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	var change storage.EventChange
+	if err := json.Unmarshal(body, &change); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, published{queue, messageID, change})
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	store := memorystorage.New()
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{ID: uuid.New(), UserID: uuid.New(), Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute)}
+	require.NoError(t, store.CreateEvent(ctx, event))
+
+	publisher := &fakePublisher{err: errors.New("connection lost")}
+	relay := NewRelay(store, publisher, logger.New("info"))
+	sent, err := relay.Drain(ctx)
+	require.Error(t, err)
+	assert.Zero(t, sent)
+
+	// The failed message stays in the outbox and goes out with the later one.
+	require.NoError(t, store.DeleteEvent(ctx, event.ID, storage.ScopeAll, time.Time{}))
+	publisher.err = nil
+	sent, err = relay.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	require.Len(t, publisher.messages, 2)
+	created, deleted := publisher.messages[0], publisher.messages[1]
+	assert.Equal(t, storage.EventChangesTopic, created.queue)
+	assert.Equal(t, "1", created.id)
+	assert.Equal(t, storage.ChangeCreated, created.change.Type)
+	require.NotNil(t, created.change.Event)
+	assert.Equal(t, "Standup", created.change.Event.Title)
+	assert.Equal(t, "2", deleted.id)
+	assert.Equal(t, storage.EventChange{Type: storage.ChangeDeleted, EventID: event.ID}, deleted.change)
+
+	sent, err = relay.Drain(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
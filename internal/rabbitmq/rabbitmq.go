@@ -1,16 +1,32 @@
 package rabbitmq
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Dendyator/calendar/internal/logger" //nolint
 	"github.com/streadway/amqp"                     //nolint
 )
 
+// confirmTimeout bounds the wait for the broker to confirm a message.
+const confirmTimeout = 10 * time.Second
+
+// ErrNotConfirmed is returned for a message the broker nacked or did not
+// confirm in time; it may still have been delivered.
+var ErrNotConfirmed = errors.New("message not confirmed by RabbitMQ")
+
 type Client struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	logg    *logger.Logger
+
+	// mu serializes publishing, so that confirmations match messages.
+	mu       sync.Mutex
+	confirms chan amqp.Confirmation
+	// nextTag is the delivery tag of the next confirmed message.
+	nextTag uint64
 }
 
 func New(dsn string, logg *logger.Logger) (*Client, error) {
@@ -50,16 +66,45 @@ func (c *Client) DeclareQueue(name string) error {
 	return err
 }
 
+// EnableConfirms puts the channel into confirm mode: from then on a publish
+// returns only once the broker has taken responsibility for the message.
+func (c *Client) EnableConfirms() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.channel.Confirm(false); err != nil {
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	c.confirms = c.channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	c.nextTag = 1
+	c.logg.Info("RabbitMQ publisher confirms enabled")
+	return nil
+}
+
 func (c *Client) Publish(queue string, body []byte) error {
+	return c.PublishWithID(queue, "", body)
+}
+
+// PublishWithID publishes a persistent message with the given message ID,
+// which consumers can use to drop duplicates.
+func (c *Client) PublishWithID(queue, messageID string, body []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	err := c.channel.Publish(
 		"",    // exchange
 		queue, // routing key (queue name)
 		false, // mandatory
 		false, // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Body:         body,
 		})
+	if err == nil && c.confirms != nil {
+		err = c.waitConfirm()
+	}
 	if err != nil {
 		c.logg.Errorf("Failed to publish message to queue %s: %s", queue, err)
 	} else {
@@ -68,6 +113,32 @@ func (c *Client) Publish(queue string, body []byte) error {
 	return err
 }
 
+// waitConfirm waits for the confirmation of the last message, skipping late
+// ones of messages that timed out before. The caller must hold the mutex.
+func (c *Client) waitConfirm() error {
+	tag := c.nextTag
+	c.nextTag++
+	timer := time.NewTimer(confirmTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case confirm, ok := <-c.confirms:
+			if !ok {
+				return fmt.Errorf("%w: channel closed", ErrNotConfirmed)
+			}
+			if confirm.DeliveryTag < tag {
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("%w: nacked", ErrNotConfirmed)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("%w: timed out", ErrNotConfirmed)
+		}
+	}
+}
+
 func (c *Client) Consume(queue string) (<-chan amqp.Delivery, error) {
 	return c.channel.Consume(
 		queue,
@@ -80,6 +151,20 @@ func (c *Client) Consume(queue string) (<-chan amqp.Delivery, error) {
 	)
 }
 
+// ConsumeWithAck delivers the messages of the queue without acknowledging
+// them: each must be acked once handled, or nacked to have it redelivered.
+func (c *Client) ConsumeWithAck(queue string) (<-chan amqp.Delivery, error) {
+	return c.channel.Consume(
+		queue,
+		"",
+		false, // auto-ack
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,   // args
+	)
+}
+
 func (c *Client) Close() {
 	c.channel.Close()
 	c.conn.Close()
@@ -0,0 +1,77 @@
+// Package reminder works out when the reminders of events fire.
+package reminder
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// Reminder is a reminder of one occurrence of an event.
+type Reminder struct {
+	// EventID is the ID of the event, or of the series for an occurrence.
+	EventID   uuid.UUID
+	UserID    uuid.UUID
+	Title     string
+	StartTime time.Time
+	Offset    time.Duration
+	// FireAt is StartTime minus Offset.
+	FireAt time.Time
+}
+
+// Offsets returns the reminder offsets of the event: its own, or else its
+// owner's entry in defaults, or else storage.FallbackReminders.
+func Offsets(event storage.Event, defaults map[uuid.UUID]storage.Durations) storage.Durations {
+	if event.Reminders != nil {
+		return event.Reminders
+	}
+	if offsets, ok := defaults[event.UserID]; ok {
+		return offsets
+	}
+	return storage.FallbackReminders
+}
+
+// Plan returns the reminders of the events and their occurrences that fire
+// in [from, to), earliest first.
+func Plan(events []storage.Event, defaults map[uuid.UUID]storage.Durations, from, to time.Time,
+) ([]Reminder, error) {
+	var reminders []Reminder
+	for _, event := range events {
+		for _, offset := range Offsets(event, defaults) {
+			occurrences, err := event.Occurrences(from.Add(offset), to.Add(offset))
+			if err != nil {
+				return nil, err
+			}
+			for _, occurrence := range occurrences {
+				reminders = append(reminders, Reminder{
+					EventID:   occurrence.ID,
+					UserID:    occurrence.UserID,
+					Title:     occurrence.Title,
+					StartTime: occurrence.StartTime,
+					Offset:    offset,
+					FireAt:    occurrence.StartTime.Add(-offset),
+				})
+			}
+		}
+	}
+	sort.SliceStable(reminders, func(i, j int) bool { return reminders[i].FireAt.Before(reminders[j].FireAt) })
+	return reminders, nil
+}
+
+// NextFire returns when the first of the event's reminders at the given
+// offsets that fires at or after from fires, or the zero time if none does.
+func NextFire(event storage.Event, offsets storage.Durations, from time.Time) (time.Time, error) {
+	var next time.Time
+	for _, offset := range offsets {
+		start, ok, err := event.NextStart(from.Add(offset))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ok && (next.IsZero() || start.Add(-offset).Before(next)) {
+			next = start.Add(-offset)
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,68 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/stretchr/testify/assert"             //nolint
+	"github.com/stretchr/testify/require"            //nolint
+)
+
+func TestPlan(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	standup := storage.Event{ID: uuid.New(), UserID: alice, Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute), RRule: "FREQ=DAILY", ExDates: storage.Times{start.AddDate(0, 0, 1)},
+		Reminders: storage.Durations{10 * time.Minute, time.Hour}}
+	review := storage.Event{ID: uuid.New(), UserID: bob, Title: "Review", StartTime: start.Add(2 * time.Hour),
+		EndTime: start.Add(3 * time.Hour)}
+	lunch := storage.Event{ID: uuid.New(), UserID: carol, Title: "Lunch", StartTime: start.AddDate(0, 0, 1),
+		EndTime: start.AddDate(0, 0, 1).Add(time.Hour)}
+	muted := storage.Event{ID: uuid.New(), UserID: alice, Title: "Muted", StartTime: start.Add(time.Hour),
+		EndTime: start.Add(2 * time.Hour), Reminders: storage.Durations{}}
+	defaults := map[uuid.UUID]storage.Durations{bob: {30 * time.Minute}}
+
+	reminders, err := Plan([]storage.Event{standup, review, lunch, muted}, defaults, start.Add(-time.Hour),
+		start.AddDate(0, 0, 2).Add(-time.Hour))
+	require.NoError(t, err)
+	type fire struct {
+		title  string
+		fireAt time.Time
+	}
+	var fires []fire
+	for _, reminder := range reminders {
+		assert.Equal(t, reminder.StartTime.Add(-reminder.Offset), reminder.FireAt)
+		fires = append(fires, fire{reminder.Title, reminder.FireAt})
+	}
+	// The Standup on the 5th is cancelled and the one on the 6th fires at the
+	// end of the window; Lunch falls back to a day ahead.
+	assert.Equal(t, []fire{
+		{"Standup", start.Add(-time.Hour)},
+		{"Standup", start.Add(-10 * time.Minute)},
+		{"Lunch", start},
+		{"Review", start.Add(90 * time.Minute)},
+	}, fires)
+}
+
+func TestNextFire(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	standup := storage.Event{ID: uuid.New(), Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute),
+		RRule: "FREQ=DAILY;COUNT=3", ExDates: storage.Times{start.AddDate(0, 0, 1)}}
+	offsets := storage.Durations{10 * time.Minute, time.Hour}
+
+	for _, tc := range []struct {
+		from, want time.Time
+	}{
+		{start.Add(-2 * time.Hour), start.Add(-time.Hour)},
+		{start.Add(-30 * time.Minute), start.Add(-10 * time.Minute)},
+		// The occurrence on the 5th is cancelled.
+		{start, start.AddDate(0, 0, 2).Add(-time.Hour)},
+		{start.AddDate(0, 0, 2), time.Time{}},
+	} {
+		next, err := NextFire(standup, offsets, tc.from)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, next, tc.from)
+	}
+}
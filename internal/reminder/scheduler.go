@@ -0,0 +1,254 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// Queue is the RabbitMQ queue reminders are published to.
+const Queue = "notifications"
+
+const (
+	// queueBatch bounds the notifications queued in one transaction.
+	queueBatch = 100
+	// scanBatch bounds the events planned at a time.
+	scanBatch = 500
+	// claimLease is how long a sender may take over a claimed reminder
+	// before the scheduler publishes it again, and how long a failed one
+	// waits to be retried.
+	claimLease = 5 * time.Minute
+	// maxAttempts bounds how often a reminder is claimed by senders.
+	maxAttempts = 3
+)
+
+// Message is the body of a reminder on Queue.
+type Message struct {
+	EventID uuid.UUID `json:"eventId"`
+	UserID  uuid.UUID `json:"userId"`
+	Title   string    `json:"title"`
+	// StartTime is the start of the occurrence in Unix seconds.
+	StartTime int64 `json:"startTime"`
+	// Offset is how many seconds before StartTime the reminder fires.
+	Offset int64 `json:"offset"`
+}
+
+// Key returns the key of the message's notification in the ledger.
+func (m Message) Key() storage.NotificationKey {
+	return storage.NotificationKey{
+		EventID:       m.EventID,
+		Occurrence:    time.Unix(m.StartTime, 0).UTC(),
+		OffsetSeconds: m.Offset,
+	}
+}
+
+func messageOf(n storage.Notification) Message {
+	return Message{
+		EventID:   n.EventID,
+		UserID:    n.UserID,
+		Title:     n.Title,
+		StartTime: n.Occurrence.Unix(),
+		Offset:    n.OffsetSeconds,
+	}
+}
+
+// Store is what the scheduler reads and writes.
+type Store interface {
+	ListDefaultReminders(ctx context.Context) (map[uuid.UUID]storage.Durations, error)
+	storage.ReminderScanInterface
+	storage.NotificationInterface
+}
+
+// Scheduler plans reminders into the notification ledger and queues them
+// in the outbox when they fall due. The ledger, not the scheduler, remembers
+// what was queued, so a reminder fires once however often Tick runs and
+// across restarts.
+//
+// Planning is incremental: each event records when its first reminder that
+// is not in the ledger fires, and writing the event resets that, so a tick
+// plans only the events due or changed rather than scanning them all.
+type Scheduler struct {
+	store  Store
+	logger app.Logger
+	// from is where the next plan starts: the reminders due before it are
+	// published or pending in the ledger.
+	from time.Time
+}
+
+// NewScheduler returns a scheduler planning reminders that fire from start
+// on.
+func NewScheduler(store Store, logger app.Logger, start time.Time) *Scheduler {
+	return &Scheduler{store: store, logger: logger, from: start}
+}
+
+// Tick plans the reminders firing until the given time, queues those due at
+// now, publishes again those a sender claimed more than claimLease ago
+// without recording the outcome or failed to send, and returns when the next
+// planned reminder fires, or until if none does before.
+func (s *Scheduler) Tick(ctx context.Context, now, until time.Time) (time.Time, error) {
+	if err := s.plan(ctx, until); err != nil {
+		return until, err
+	}
+	s.from = now
+	for {
+		queued, err := s.store.QueueNotifications(ctx, now, queueBatch, outboxMessage)
+		if err != nil {
+			return until, fmt.Errorf("queue reminders: %w", err)
+		}
+		if queued > 0 {
+			s.logger.Info(fmt.Sprintf("Queued %d reminders", queued))
+		}
+		if queued < queueBatch {
+			break
+		}
+	}
+	for {
+		requeued, err := s.store.RequeueNotifications(ctx, now.Add(-claimLease), maxAttempts, queueBatch,
+			outboxMessage)
+		if err != nil {
+			return until, fmt.Errorf("requeue reminders: %w", err)
+		}
+		if requeued > 0 {
+			s.logger.Info(fmt.Sprintf("Requeued %d reminders", requeued))
+		}
+		if requeued < queueBatch {
+			break
+		}
+	}
+	next, err := s.store.NextNotification(ctx, now)
+	if err != nil {
+		return until, fmt.Errorf("find next reminder: %w", err)
+	}
+	if next.IsZero() || next.After(until) {
+		return until, nil
+	}
+	return next, nil
+}
+
+// plan brings the ledger up to until, a batch of events at a time: the
+// reminders of each selected event firing in [from, until) replace its
+// pending ones, and its next reminder moves to the first one from until on.
+func (s *Scheduler) plan(ctx context.Context, until time.Time) error {
+	defaults, err := s.store.ListDefaultReminders(ctx)
+	if err != nil {
+		return fmt.Errorf("list default reminders: %w", err)
+	}
+
+	scan := storage.ReminderScan{Until: until, Limit: scanBatch}
+	total, events := 0, 0
+	for {
+		batch, err := s.store.ListReminderEvents(ctx, scan)
+		if err != nil {
+			return fmt.Errorf("list events: %w", err)
+		}
+		planned, err := s.planBatch(ctx, batch, defaults, until)
+		if err != nil {
+			return err
+		}
+		total += planned
+		events += len(batch)
+		if len(batch) < scan.Limit {
+			break
+		}
+		scan.After = batch[len(batch)-1].ID
+	}
+	if events > 0 {
+		s.logger.Info(fmt.Sprintf("Planned %d reminders of %d events until %v", total, events, until))
+	}
+	return nil
+}
+
+// planBatch plans the reminders of the events and returns how many it
+// planned.
+func (s *Scheduler) planBatch(ctx context.Context, batch []storage.ReminderEvent,
+	defaults map[uuid.UUID]storage.Durations, until time.Time,
+) (int, error) {
+	ids := make([]uuid.UUID, len(batch))
+	events := make([]storage.Event, len(batch))
+	next := make([]storage.NextReminder, len(batch))
+	for i, event := range batch {
+		at, err := NextFire(event.Event, Offsets(event.Event, defaults), until)
+		if err != nil {
+			return 0, err
+		}
+		ids[i], events[i] = event.ID, event.Event
+		next[i] = storage.NextReminder{EventID: event.ID, UpdatedAt: event.UpdatedAt, At: at}
+	}
+	reminders, err := Plan(events, defaults, s.from, until)
+	if err != nil {
+		return 0, err
+	}
+	planned := make([]storage.Notification, len(reminders))
+	for i, r := range reminders {
+		planned[i] = storage.Notification{
+			NotificationKey: storage.NewNotificationKey(r.EventID, r.StartTime, r.Offset),
+			UserID:          r.UserID,
+			Title:           r.Title,
+			FireAt:          r.FireAt,
+			State:           storage.NotificationPending,
+		}
+	}
+	if err := s.store.PlanNotifications(ctx, s.from, until, ids, planned); err != nil {
+		return 0, fmt.Errorf("plan reminders: %w", err)
+	}
+	if err := s.store.SetNextReminders(ctx, next); err != nil {
+		return 0, fmt.Errorf("record next reminders: %w", err)
+	}
+	return len(planned), nil
+}
+
+func outboxMessage(n storage.Notification) (storage.OutboxMessage, error) {
+	return storage.NewOutboxMessage(Queue, messageOf(n))
+}
+
+// ErrDuplicate is returned by Deliver for a message whose notification was
+// already claimed, delivered or failed, or is not in the ledger. A claimed
+// or failed notification is published again by the scheduler if needed.
+var ErrDuplicate = errors.New("reminder already handled")
+
+// ErrNotClaimed is returned by Deliver when the ledger could not be reached
+// to claim the notification; the message is worth delivering again.
+var ErrNotClaimed = errors.New("reminder not claimed")
+
+// Deliver claims the notification of the reminder in body in the ledger,
+// sends it with send and records the outcome. A message that cannot be
+// claimed, because another delivery of it claimed it first, is not sent.
+// If the outcome is not recorded, e.g. because the sender crashed, the
+// scheduler publishes the reminder again after claimLease: a reminder is
+// sent at least once and, unless a sender fails between sending and
+// recording, exactly once.
+func Deliver(ctx context.Context, store storage.NotificationInterface, body []byte,
+	send func(Message) error,
+) (Message, error) {
+	var message Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		return message, fmt.Errorf("decode reminder: %w", err)
+	}
+	_, err := store.ClaimNotification(ctx, message.Key())
+	if errors.Is(err, storage.ErrNotificationNotFound) {
+		return message, ErrDuplicate
+	}
+	if err != nil {
+		return message, fmt.Errorf("%w: %w", ErrNotClaimed, err)
+	}
+
+	state, details := storage.NotificationDelivered, ""
+	sendErr := send(message)
+	if sendErr != nil {
+		state, details = storage.NotificationFailed, sendErr.Error()
+	}
+	err = store.UpdateNotificationState(ctx, message.Key(), state, details)
+	if errors.Is(err, storage.ErrNotificationNotFound) {
+		return message, ErrDuplicate
+	}
+	if err != nil {
+		return message, err
+	}
+	return message, sendErr
+}
@@ -0,0 +1,291 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+// queued drains the outbox and returns the reminders in it.
+func queued(t *testing.T, store *memorystorage.Storage) [][]byte {
+	t.Helper()
+	var bodies [][]byte
+	_, err := store.RelayOutbox(context.Background(), 1000, func(m storage.OutboxMessage) error {
+		if m.Topic == Queue {
+			bodies = append(bodies, m.Payload)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return bodies
+}
+
+func newSchedulerTest(t *testing.T) (*memorystorage.Storage, storage.Event, time.Time) {
+	t.Helper()
+	store := memorystorage.New()
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{ID: uuid.New(), UserID: uuid.New(), Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute), Reminders: storage.Durations{10 * time.Minute}}
+	require.NoError(t, store.CreateEvent(context.Background(), event))
+	return store, event, start
+}
+
+func TestSchedulerQueuesOnce(t *testing.T) {
+	ctx := context.Background()
+	store, event, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+
+	now := start.Add(-30 * time.Minute)
+	next, err := scheduler.Tick(ctx, now, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(-10*time.Minute), next)
+	assert.Empty(t, queued(t, store))
+
+	// A restarted scheduler replans the same window without queueing again.
+	var bodies [][]byte
+	for i := 0; i < 2; i++ {
+		scheduler = NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+		_, err = scheduler.Tick(ctx, next, next.Add(time.Hour))
+		require.NoError(t, err)
+		bodies = append(bodies, queued(t, store)...)
+	}
+	require.Len(t, bodies, 1)
+
+	var message Message
+	require.NoError(t, json.Unmarshal(bodies[0], &message))
+	assert.Equal(t, Message{EventID: event.ID, UserID: event.UserID, Title: "Standup",
+		StartTime: start.Unix(), Offset: 600}, message)
+	n, err := store.GetNotification(ctx, message.Key())
+	require.NoError(t, err)
+	assert.Equal(t, storage.NotificationPublished, n.State)
+}
+
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+	store, _, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+	_, err := scheduler.Tick(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+	body := bodies[0]
+
+	sent := 0
+	send := func(Message) error {
+		sent++
+		return nil
+	}
+	message, err := Deliver(ctx, store, body, send)
+	require.NoError(t, err)
+	_, err = Deliver(ctx, store, body, send)
+	require.ErrorIs(t, err, ErrDuplicate)
+	assert.Equal(t, 1, sent)
+
+	n, err := store.GetNotification(ctx, message.Key())
+	require.NoError(t, err)
+	assert.Equal(t, storage.NotificationDelivered, n.State)
+
+	_, err = Deliver(ctx, store, []byte(`{"eventId":"`+uuid.NewString()+`"}`), send)
+	require.ErrorIs(t, err, ErrDuplicate)
+}
+
+func TestDeliverFailure(t *testing.T) {
+	ctx := context.Background()
+	store, _, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+	_, err := scheduler.Tick(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+
+	sendErr := errors.New("mailbox full")
+	message, err := Deliver(ctx, store, bodies[0], func(Message) error { return sendErr })
+	require.ErrorIs(t, err, sendErr)
+
+	n, err := store.GetNotification(ctx, message.Key())
+	require.NoError(t, err)
+	assert.Equal(t, storage.NotificationFailed, n.State)
+	assert.Equal(t, "mailbox full", n.Details)
+}
+
+func TestSchedulerReplansChangedEvents(t *testing.T) {
+	ctx := context.Background()
+	store, moved, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+
+	next, err := scheduler.Tick(ctx, start.Add(-time.Hour), start)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(-10*time.Minute), next)
+
+	// Within the planned window, one event moves away and another comes in.
+	moved.StartTime, moved.EndTime = moved.StartTime.Add(time.Hour), moved.EndTime.Add(time.Hour)
+	require.NoError(t, store.UpdateEvent(ctx, moved.ID, moved, storage.ScopeAll, time.Time{}))
+	added := storage.Event{ID: uuid.New(), UserID: uuid.New(), Title: "Review", StartTime: start.Add(-15 * time.Minute),
+		EndTime: start, Reminders: storage.Durations{5 * time.Minute}}
+	require.NoError(t, store.CreateEvent(ctx, added))
+
+	next, err = scheduler.Tick(ctx, start.Add(-30*time.Minute), start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(-20*time.Minute), next)
+	_, err = scheduler.Tick(ctx, next, next.Add(time.Hour))
+	require.NoError(t, err)
+
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+	var message Message
+	require.NoError(t, json.Unmarshal(bodies[0], &message))
+	assert.Equal(t, added.ID, message.EventID)
+	_, err = store.GetNotification(ctx, storage.NewNotificationKey(moved.ID, start, 10*time.Minute))
+	require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+}
+
+func TestSchedulerReplansOnDefaultReminders(t *testing.T) {
+	ctx := context.Background()
+	store := memorystorage.New()
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	first := start.AddDate(-1, 0, 0)
+	series := storage.Event{ID: uuid.New(), UserID: uuid.New(), Title: "Standup", StartTime: first,
+		EndTime: first.Add(15 * time.Minute), RRule: "FREQ=DAILY"}
+	require.NoError(t, store.CreateEvent(ctx, series))
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-2*time.Hour))
+
+	// The fallback reminder a day ahead next fires at start, so the series is
+	// left alone until then.
+	next, err := scheduler.Tick(ctx, start.Add(-2*time.Hour), start.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(-time.Hour), next)
+	listed, err := store.ListReminderEvents(ctx, storage.ReminderScan{Until: start, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	require.NoError(t, store.SetDefaultReminders(ctx, series.UserID, storage.Durations{10 * time.Minute}))
+	next, err = scheduler.Tick(ctx, start.Add(-time.Hour), start)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(-10*time.Minute), next)
+	_, err = scheduler.Tick(ctx, next, next.Add(time.Hour))
+	require.NoError(t, err)
+
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+	var message Message
+	require.NoError(t, json.Unmarshal(bodies[0], &message))
+	assert.Equal(t, Message{EventID: series.ID, UserID: series.UserID, Title: "Standup",
+		StartTime: start.Unix(), Offset: 600}, message)
+}
+
+func TestDeliverClaimsOnce(t *testing.T) {
+	ctx := context.Background()
+	store, _, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+	_, err := scheduler.Tick(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+
+	// Redeliveries racing the first delivery are not sent.
+	const deliveries = 8
+	var sent int32
+	errs := make(chan error, deliveries)
+	for i := 0; i < deliveries; i++ {
+		go func() {
+			_, err := Deliver(ctx, store, bodies[0], func(Message) error {
+				atomic.AddInt32(&sent, 1)
+				return nil
+			})
+			errs <- err
+		}()
+	}
+	duplicates := 0
+	for i := 0; i < deliveries; i++ {
+		if err := <-errs; err != nil {
+			require.ErrorIs(t, err, ErrDuplicate)
+			duplicates++
+		}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sent))
+	assert.Equal(t, deliveries-1, duplicates)
+}
+
+func TestSchedulerRequeuesAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	store, _, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+	_, err := scheduler.Tick(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	bodies := queued(t, store)
+	require.Len(t, bodies, 1)
+
+	// A sender claims the reminder and crashes before sending it; the
+	// redelivered message finds it claimed.
+	var message Message
+	require.NoError(t, json.Unmarshal(bodies[0], &message))
+	_, err = store.ClaimNotification(ctx, message.Key())
+	require.NoError(t, err)
+	sent := 0
+	send := func(Message) error {
+		sent++
+		return nil
+	}
+	_, err = Deliver(ctx, store, bodies[0], send)
+	require.ErrorIs(t, err, ErrDuplicate)
+
+	// Within the lease the claim stands; after it the reminder is published
+	// again and delivered once.
+	_, err = scheduler.Tick(ctx, time.Now(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, queued(t, store))
+	later := time.Now().Add(claimLease + time.Minute)
+	_, err = scheduler.Tick(ctx, later, later.Add(time.Hour))
+	require.NoError(t, err)
+	bodies = queued(t, store)
+	require.Len(t, bodies, 1)
+	_, err = Deliver(ctx, store, bodies[0], send)
+	require.NoError(t, err)
+	_, err = Deliver(ctx, store, bodies[0], send)
+	require.ErrorIs(t, err, ErrDuplicate)
+	assert.Equal(t, 1, sent)
+
+	n, err := store.GetNotification(ctx, message.Key())
+	require.NoError(t, err)
+	assert.Equal(t, storage.NotificationDelivered, n.State)
+	assert.Equal(t, 2, n.Attempts)
+
+	_, err = scheduler.Tick(ctx, later.Add(claimLease), later.Add(claimLease+time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, queued(t, store))
+}
+
+func TestSchedulerRetriesFailures(t *testing.T) {
+	ctx := context.Background()
+	store, _, start := newSchedulerTest(t)
+	scheduler := NewScheduler(store, logger.New("info"), start.Add(-time.Hour))
+	_, err := scheduler.Tick(ctx, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	bodies := queued(t, store)
+
+	sendErr := errors.New("mailbox full")
+	attempts := 0
+	for now := time.Now(); len(bodies) > 0; now = now.Add(claimLease + time.Minute) {
+		require.Len(t, bodies, 1)
+		_, err := Deliver(ctx, store, bodies[0], func(Message) error {
+			attempts++
+			return sendErr
+		})
+		require.ErrorIs(t, err, sendErr)
+		later := now.Add(claimLease + time.Minute)
+		_, err = scheduler.Tick(ctx, later, later.Add(time.Hour))
+		require.NoError(t, err)
+		bodies = queued(t, store)
+	}
+	assert.Equal(t, maxAttempts, attempts)
+}
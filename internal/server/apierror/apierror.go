@@ -0,0 +1,84 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/ical"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"google.golang.org/grpc/codes"
+)
+
+// ErrInvalidArgument marks malformed requests rejected before they reach
+// storage (unparsable IDs, bodies or query parameters).
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// Mapping is how an error is reported to API clients.
+type Mapping struct {
+	// Reason is a stable machine-readable identifier, e.g. "NOT_FOUND".
+	Reason     string
+	HTTPStatus int
+	GRPCCode   codes.Code
+	// Public reports whether the error message may be shown to the client.
+	Public bool
+}
+
+var internal = Mapping{
+	Reason:     "INTERNAL",
+	HTTPStatus: http.StatusInternalServerError,
+	GRPCCode:   codes.Internal,
+}
+
+var mappings = []struct {
+	err     error
+	mapping Mapping
+}{
+	{storage.ErrNotFound, Mapping{"NOT_FOUND", http.StatusNotFound, codes.NotFound, true}},
+	{storage.ErrAlreadyExists, Mapping{"ALREADY_EXISTS", http.StatusConflict, codes.AlreadyExists, true}},
+	{storage.ErrDateBusy, Mapping{"DATE_BUSY", http.StatusConflict, codes.FailedPrecondition, true}},
+	{storage.ErrInvalidEvent, Mapping{"INVALID_EVENT", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrCalendarNotFound, Mapping{"NOT_FOUND", http.StatusNotFound, codes.NotFound, true}},
+	{storage.ErrInvalidCalendar, Mapping{"INVALID_CALENDAR", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrInvalidTimeZone, Mapping{"INVALID_TIME_ZONE", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrInvalidRange, Mapping{"INVALID_RANGE", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrInvalidPageToken, Mapping{"INVALID_PAGE_TOKEN", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrInvalidSearch, Mapping{"INVALID_ARGUMENT", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{storage.ErrAPIKeyNotFound, Mapping{"NOT_FOUND", http.StatusNotFound, codes.NotFound, true}},
+	{storage.ErrFeedNotFound, Mapping{"NOT_FOUND", http.StatusNotFound, codes.NotFound, true}},
+	{storage.ErrSubscriptionNotFound, Mapping{"NOT_FOUND", http.StatusNotFound, codes.NotFound, true}},
+	{storage.ErrInvalidSubscription, Mapping{"INVALID_SUBSCRIPTION", http.StatusBadRequest,
+		codes.InvalidArgument, true}},
+	{storage.ErrInvalidReminders, Mapping{"INVALID_REMINDERS", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{app.ErrPreconditionFailed, Mapping{"PRECONDITION_FAILED", http.StatusPreconditionFailed,
+		codes.FailedPrecondition, true}},
+	{ical.ErrMalformed, Mapping{"INVALID_ARGUMENT", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{auth.ErrInvalidScope, Mapping{"INVALID_ARGUMENT", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{ErrInvalidArgument, Mapping{"INVALID_ARGUMENT", http.StatusBadRequest, codes.InvalidArgument, true}},
+	{auth.ErrUnauthenticated, Mapping{"UNAUTHENTICATED", http.StatusUnauthorized, codes.Unauthenticated, true}},
+	{auth.ErrPermissionDenied, Mapping{"PERMISSION_DENIED", http.StatusForbidden, codes.PermissionDenied, true}},
+	{context.DeadlineExceeded, Mapping{"DEADLINE_EXCEEDED", http.StatusGatewayTimeout, codes.DeadlineExceeded, true}},
+	// 499 is the de facto "client closed request" status.
+	{context.Canceled, Mapping{"CANCELED", 499, codes.Canceled, true}},
+}
+
+// Lookup returns the mapping of the first known sentinel wrapped by err.
+// Unknown errors map to an internal error whose message is not public.
+func Lookup(err error) Mapping {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.mapping
+		}
+	}
+	return internal
+}
+
+// Message returns the client-facing message for err.
+func Message(err error) string {
+	if Lookup(err).Public {
+		return err.Error()
+	}
+	return "internal server error"
+}
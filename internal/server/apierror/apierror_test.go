@@ -0,0 +1,48 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/stretchr/testify/assert"             //nolint
+	"google.golang.org/grpc/codes"
+)
+
+func TestLookup(t *testing.T) {
+	testCases := []struct {
+		err        error
+		httpStatus int
+		grpcCode   codes.Code
+	}{
+		{storage.ErrNotFound, http.StatusNotFound, codes.NotFound},
+		{fmt.Errorf("%w: duplicate key value", storage.ErrAlreadyExists), http.StatusConflict, codes.AlreadyExists},
+		{fmt.Errorf("%w: overlap", storage.ErrDateBusy), http.StatusConflict, codes.FailedPrecondition},
+		{fmt.Errorf("%w: title is required", storage.ErrInvalidEvent), http.StatusBadRequest, codes.InvalidArgument},
+		{ErrInvalidArgument, http.StatusBadRequest, codes.InvalidArgument},
+		{fmt.Errorf("%w: If-Match", app.ErrPreconditionFailed), http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{fmt.Errorf("%w: missing token", auth.ErrUnauthenticated), http.StatusUnauthorized, codes.Unauthenticated},
+		{auth.ErrPermissionDenied, http.StatusForbidden, codes.PermissionDenied},
+		{fmt.Errorf("select events: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{context.Canceled, 499, codes.Canceled},
+		{errors.New("connection refused"), http.StatusInternalServerError, codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			mapping := Lookup(tc.err)
+			assert.Equal(t, tc.httpStatus, mapping.HTTPStatus)
+			assert.Equal(t, tc.grpcCode, mapping.GRPCCode)
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "event not found", Message(storage.ErrNotFound))
+	assert.Equal(t, "internal server error", Message(errors.New("pq: password authentication failed")))
+}
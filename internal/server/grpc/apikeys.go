@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/Dendyator/calendar/api/pb"                //nolint
+	"github.com/Dendyator/calendar/internal/auth"            //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+)
+
+func (s *Server) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	s.logg.Info("Creating API key: " + req.GetName())
+	if _, err := auth.RequireAdmin(ctx); err != nil {
+		s.logg.Error("Refused to create API key: " + err.Error())
+		return nil, toStatus(err)
+	}
+	if req.GetUserId() == "" {
+		return nil, toStatus(fmt.Errorf("%w: user_id is required", apierror.ErrInvalidArgument))
+	}
+	userID, err := parseID(req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	key, token, err := auth.NewAPIKey(userID, req.GetName(), req.GetScopes(), fromUnix(req.GetExpiresAt()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.keys.CreateAPIKey(ctx, key); err != nil {
+		s.logg.Error("Failed to create API key: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.CreateAPIKeyResponse{ApiKey: convertToPBAPIKey(key), Token: token}, nil
+}
+
+func (s *Server) ListAPIKeys(ctx context.Context, _ *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	s.logg.Info("Listing API keys")
+	if _, err := auth.RequireAdmin(ctx); err != nil {
+		s.logg.Error("Refused to list API keys: " + err.Error())
+		return nil, toStatus(err)
+	}
+	keys, err := s.keys.ListAPIKeys(ctx)
+	if err != nil {
+		s.logg.Error("Failed to list API keys: " + err.Error())
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListAPIKeysResponse{ApiKeys: make([]*pb.APIKey, len(keys))}
+	for i, key := range keys {
+		resp.ApiKeys[i] = convertToPBAPIKey(key)
+	}
+	return resp, nil
+}
+
+func (s *Server) DeleteAPIKey(ctx context.Context, req *pb.DeleteAPIKeyRequest) (*pb.DeleteAPIKeyResponse, error) {
+	s.logg.Info("Deleting API key: " + req.GetId())
+	if _, err := auth.RequireAdmin(ctx); err != nil {
+		s.logg.Error("Refused to delete API key: " + err.Error())
+		return nil, toStatus(err)
+	}
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.keys.DeleteAPIKey(ctx, id); err != nil {
+		s.logg.Error("Failed to delete API key: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteAPIKeyResponse{}, nil
+}
+
+func convertToPBAPIKey(key storage.APIKey) *pb.APIKey {
+	return &pb.APIKey{
+		Id:         key.ID.String(),
+		UserId:     key.UserID.String(),
+		Name:       key.Name,
+		Scopes:     key.Scopes,
+		ExpiresAt:  toUnix(key.ExpiresAt),
+		LastUsedAt: toUnix(key.LastUsedAt),
+		CreatedAt:  toUnix(key.CreatedAt),
+	}
+}
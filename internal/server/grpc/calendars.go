@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"context"
+
+	pb "github.com/Dendyator/calendar/api/pb"        //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Server) CreateCalendar(ctx context.Context, req *pb.CreateCalendarRequest,
+) (*pb.CreateCalendarResponse, error) {
+	s.logg.Info("Creating calendar: " + req.GetCalendar().GetName())
+	calendar, err := convertFromPBCalendar(req.GetCalendar())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	calendar, err = s.app.CreateCalendar(ctx, calendar)
+	if err != nil {
+		s.logg.Error("Failed to create calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.CreateCalendarResponse{Calendar: convertToPBCalendar(calendar)}, nil
+}
+
+func (s *Server) GetCalendar(ctx context.Context, req *pb.GetCalendarRequest) (*pb.GetCalendarResponse, error) {
+	s.logg.Info("Retrieving calendar ID: " + req.GetId())
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	calendar, err := s.app.GetCalendar(ctx, id)
+	if err != nil {
+		s.logg.Error("Failed to get calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.GetCalendarResponse{Calendar: convertToPBCalendar(calendar)}, nil
+}
+
+func (s *Server) ListCalendars(ctx context.Context, _ *pb.ListCalendarsRequest) (*pb.ListCalendarsResponse, error) {
+	s.logg.Info("Listing calendars")
+	calendars, err := s.app.ListCalendars(ctx)
+	if err != nil {
+		s.logg.Error("Failed to list calendars: " + err.Error())
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListCalendarsResponse{Calendars: make([]*pb.Calendar, len(calendars))}
+	for i, calendar := range calendars {
+		resp.Calendars[i] = convertToPBCalendar(calendar)
+	}
+	return resp, nil
+}
+
+func (s *Server) UpdateCalendar(ctx context.Context, req *pb.UpdateCalendarRequest,
+) (*pb.UpdateCalendarResponse, error) {
+	s.logg.Info("Updating calendar ID: " + req.GetCalendar().GetId())
+	calendar, err := convertFromPBCalendar(req.GetCalendar())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if calendar.ID, err = parseID(req.GetCalendar().GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	calendar, err = s.app.UpdateCalendar(ctx, calendar)
+	if err != nil {
+		s.logg.Error("Failed to update calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.UpdateCalendarResponse{Calendar: convertToPBCalendar(calendar)}, nil
+}
+
+func (s *Server) DeleteCalendar(ctx context.Context, req *pb.DeleteCalendarRequest,
+) (*pb.DeleteCalendarResponse, error) {
+	s.logg.Info("Deleting calendar ID: " + req.GetId())
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.app.DeleteCalendar(ctx, id); err != nil {
+		s.logg.Error("Failed to delete calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteCalendarResponse{}, nil
+}
+
+func (s *Server) ShareCalendar(ctx context.Context, req *pb.ShareCalendarRequest,
+) (*pb.ShareCalendarResponse, error) {
+	s.logg.Info("Sharing calendar ID: " + req.GetEntry().GetCalendarId())
+	calendarID, userID, err := parseACLIDs(req.GetEntry().GetCalendarId(), req.GetEntry().GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	entry := storage.ACLEntry{CalendarID: calendarID, UserID: userID, Role: storage.Role(req.GetEntry().GetRole())}
+	if err := s.app.ShareCalendar(ctx, entry); err != nil {
+		s.logg.Error("Failed to share calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.ShareCalendarResponse{}, nil
+}
+
+func (s *Server) UnshareCalendar(ctx context.Context, req *pb.UnshareCalendarRequest,
+) (*pb.UnshareCalendarResponse, error) {
+	s.logg.Info("Unsharing calendar ID: " + req.GetCalendarId())
+	calendarID, userID, err := parseACLIDs(req.GetCalendarId(), req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.app.UnshareCalendar(ctx, calendarID, userID); err != nil {
+		s.logg.Error("Failed to unshare calendar: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.UnshareCalendarResponse{}, nil
+}
+
+func (s *Server) ListCalendarACL(ctx context.Context, req *pb.ListCalendarACLRequest,
+) (*pb.ListCalendarACLResponse, error) {
+	s.logg.Info("Listing ACL of calendar ID: " + req.GetCalendarId())
+	id, err := parseID(req.GetCalendarId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	entries, err := s.app.ListACL(ctx, id)
+	if err != nil {
+		s.logg.Error("Failed to list calendar ACL: " + err.Error())
+		return nil, toStatus(err)
+	}
+	resp := &pb.ListCalendarACLResponse{Entries: make([]*pb.ACLEntry, len(entries))}
+	for i, entry := range entries {
+		resp.Entries[i] = &pb.ACLEntry{
+			CalendarId: entry.CalendarID.String(),
+			UserId:     entry.UserID.String(),
+			Role:       string(entry.Role),
+		}
+	}
+	return resp, nil
+}
+
+func parseACLIDs(calendarID, userID string) (uuid.UUID, uuid.UUID, error) {
+	calendar, err := parseID(calendarID)
+	if err != nil {
+		return calendar, uuid.Nil, err
+	}
+	user, err := parseID(userID)
+	return calendar, user, err
+}
+
+func convertToPBCalendar(calendar storage.Calendar) *pb.Calendar {
+	return &pb.Calendar{
+		Id:        calendar.ID.String(),
+		OwnerId:   calendar.OwnerID.String(),
+		Name:      calendar.Name,
+		CreatedAt: toUnix(calendar.CreatedAt),
+		Color:     calendar.Color,
+		TimeZone:  calendar.TimeZone,
+	}
+}
+
+// convertFromPBCalendar leaves OwnerID empty if owner_id is not set; the app
+// fills it in from the caller's identity.
+func convertFromPBCalendar(pbCalendar *pb.Calendar) (storage.Calendar, error) {
+	calendar := storage.Calendar{
+		Name:     pbCalendar.GetName(),
+		Color:    pbCalendar.GetColor(),
+		TimeZone: pbCalendar.GetTimeZone(),
+	}
+	if pbCalendar.GetOwnerId() != "" {
+		var err error
+		if calendar.OwnerID, err = parseID(pbCalendar.GetOwnerId()); err != nil {
+			return storage.Calendar{}, err
+		}
+	}
+	return calendar, nil
+}
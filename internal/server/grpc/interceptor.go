@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/Dendyator/calendar/internal/auth" //nolint
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// AuthInterceptor rejects calls the authenticator cannot identify or whose
+// API key lacks the scope of the method, and stores the caller's identity in
+// the call context.
+func AuthInterceptor(authn auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		creds := func(name string) string {
+			if values := md.Get(name); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+		identity, err := authn.Authenticate(ctx, creds)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		ctx = auth.WithIdentity(ctx, identity)
+		if err := auth.RequireScope(ctx, methodScope(info.FullMethod)); err != nil {
+			return nil, toStatus(err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// methodScope returns the API key scope a method needs: Get*, List* and
+// Search* only read events.
+func methodScope(fullMethod string) string {
+	method := path.Base(fullMethod)
+	if strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") || strings.HasPrefix(method, "Search") {
+		return auth.ScopeEventsRead
+	}
+	return auth.ScopeEventsWrite
+}
@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/Dendyator/calendar/api/pb"        //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+func (s *Server) GetDefaultReminders(ctx context.Context, _ *pb.GetDefaultRemindersRequest,
+) (*pb.GetDefaultRemindersResponse, error) {
+	s.logg.Info("Retrieving default reminders")
+	reminders, err := s.app.DefaultReminders(ctx)
+	if err != nil {
+		s.logg.Error("Failed to get default reminders: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.GetDefaultRemindersResponse{Reminders: convertToPBReminders(reminders)}, nil
+}
+
+func (s *Server) SetDefaultReminders(ctx context.Context, req *pb.SetDefaultRemindersRequest,
+) (*pb.SetDefaultRemindersResponse, error) {
+	s.logg.Info("Setting default reminders")
+	reminders, err := s.app.SetDefaultReminders(ctx, convertFromPBReminders(req.GetReminders()))
+	if err != nil {
+		s.logg.Error("Failed to set default reminders: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.SetDefaultRemindersResponse{Reminders: convertToPBReminders(reminders)}, nil
+}
+
+// convertToPBReminders returns nil for nil reminders, which leaves the
+// field unset.
+func convertToPBReminders(reminders storage.Durations) *pb.Reminders {
+	if reminders == nil {
+		return nil
+	}
+	pbReminders := &pb.Reminders{OffsetSeconds: make([]int64, len(reminders))}
+	for i, offset := range reminders {
+		pbReminders.OffsetSeconds[i] = int64(offset / time.Second)
+	}
+	return pbReminders
+}
+
+func convertFromPBReminders(pbReminders *pb.Reminders) storage.Durations {
+	if pbReminders == nil {
+		return nil
+	}
+	reminders := make(storage.Durations, len(pbReminders.GetOffsetSeconds()))
+	for i, seconds := range pbReminders.GetOffsetSeconds() {
+		reminders[i] = time.Duration(seconds) * time.Second
+	}
+	return reminders
+}
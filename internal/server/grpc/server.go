@@ -2,137 +2,229 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"time"
 
-	pb "github.com/Dendyator/calendar/api/pb"        //nolint
-	"github.com/Dendyator/calendar/internal/logger"  //nolint
-	"github.com/Dendyator/calendar/internal/storage" //nolint
-	"github.com/google/uuid"                         //nolint
-	_ "github.com/jackc/pgx/v4/stdlib"               //nolint
+	pb "github.com/Dendyator/calendar/api/pb"                //nolint
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	_ "github.com/jackc/pgx/v4/stdlib"                       //nolint
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	pb.UnimplementedEventServiceServer
-	storage storage.Interface
-	logg    *logger.Logger
+	app  *app.App
+	keys storage.APIKeyInterface
+	logg *logger.Logger
 }
 
-func NewGRPCServer(storage storage.Interface, logg *logger.Logger) *Server {
-	return &Server{storage: storage, logg: logg}
+func NewGRPCServer(application *app.App, keys storage.APIKeyInterface, logg *logger.Logger) *Server {
+	return &Server{app: application, keys: keys, logg: logg}
 }
 
-func (s *Server) CreateEvent(_ context.Context, req *pb.CreateEventRequest) (*pb.CreateEventResponse, error) {
-	s.logg.Info("Creating event: " + req.GetEvent().Title)
-	event := storage.Event{
-		ID:          uuid.New(),
-		Title:       req.GetEvent().Title,
-		Description: req.GetEvent().Description,
-		StartTime:   time.Unix(req.GetEvent().StartTime, 0),
-		EndTime:     time.Unix(req.GetEvent().EndTime, 0),
-		UserID:      uuid.MustParse(req.GetEvent().UserId),
+func (s *Server) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*pb.CreateEventResponse, error) {
+	s.logg.Info("Creating event: " + req.GetEvent().GetTitle())
+	event, err := convertFromPBEvent(req.GetEvent())
+	if err != nil {
+		return nil, toStatus(err)
 	}
-	err := s.storage.CreateEvent(event)
+	event, err = s.app.CreateEvent(ctx, event)
 	if err != nil {
 		s.logg.Error("Failed to create event: " + err.Error())
+		return nil, toStatus(err)
 	}
-	return &pb.CreateEventResponse{}, err
+	return &pb.CreateEventResponse{Event: convertToPBEvent(event)}, nil
 }
 
-func (s *Server) UpdateEvent(_ context.Context, req *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
+func (s *Server) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.UpdateEventResponse, error) {
 	s.logg.Info("Updating event ID: " + req.GetId())
-	newEvent := storage.Event{
-		ID:          uuid.MustParse(req.GetEvent().Id),
-		Title:       req.GetEvent().Title,
-		Description: req.GetEvent().Description,
-		StartTime:   time.Unix(req.GetEvent().StartTime, 0),
-		EndTime:     time.Unix(req.GetEvent().EndTime, 0),
-		UserID:      uuid.MustParse(req.GetEvent().UserId),
+	newEvent, err := convertFromPBEvent(req.GetEvent())
+	if err != nil {
+		return nil, toStatus(err)
 	}
-	err := s.storage.UpdateEvent(newEvent.ID, newEvent)
+	newEvent.ID, err = parseID(req.GetEvent().GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	err = s.app.UpdateEvent(ctx, newEvent.ID, newEvent, convertFromPBScope(req.GetScope()),
+		fromUnix(req.GetRecurrenceId()))
 	if err != nil {
 		s.logg.Error("Failed to update event: " + err.Error())
+		return nil, toStatus(err)
 	}
-	return &pb.UpdateEventResponse{}, err
+	return &pb.UpdateEventResponse{}, nil
 }
 
-func (s *Server) DeleteEvent(_ context.Context, req *pb.DeleteEventRequest) (*pb.DeleteEventResponse, error) {
+func (s *Server) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*pb.DeleteEventResponse, error) {
 	s.logg.Info("Deleting event ID: " + req.GetId())
-	err := s.storage.DeleteEvent(uuid.MustParse(req.GetId()))
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	err = s.app.DeleteEvent(ctx, id, convertFromPBScope(req.GetScope()), fromUnix(req.GetRecurrenceId()))
 	if err != nil {
 		s.logg.Error("Failed to delete event: " + err.Error())
+		return nil, toStatus(err)
 	}
-	return &pb.DeleteEventResponse{}, err
+	return &pb.DeleteEventResponse{}, nil
 }
 
-func (s *Server) GetEvent(_ context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error) {
+func (s *Server) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.GetEventResponse, error) {
 	s.logg.Info("Retrieving event ID: " + req.GetId())
-	event, err := s.storage.GetEvent(uuid.MustParse(req.GetId()))
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	event, err := s.app.GetEvent(ctx, id)
 	if err != nil {
 		s.logg.Error("Failed to get event: " + err.Error())
-		return nil, err
+		return nil, toStatus(err)
 	}
-	return &pb.GetEventResponse{
-		Event: &pb.Event{
-			Id:          event.ID.String(),
-			Title:       event.Title,
-			Description: event.Description,
-			StartTime:   event.StartTime.Unix(),
-			EndTime:     event.EndTime.Unix(),
-			UserId:      event.UserID.String(),
-		},
-	}, nil
+	return &pb.GetEventResponse{Event: convertToPBEvent(event)}, nil
 }
 
-func (s *Server) ListEvents(_ context.Context, _ *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
-	s.logg.Info("Listing all events")
-	events, err := s.storage.ListEvents()
+func (s *Server) ListEvents(ctx context.Context, req *pb.ListEventsRequest) (*pb.ListEventsResponse, error) {
+	s.logg.Info("Listing events")
+	filter, err := convertFromPBFilter(req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	page, err := s.app.ListEvents(ctx, filter)
 	if err != nil {
 		s.logg.Error("Failed to list events: " + err.Error())
-		return nil, err
+		return nil, toStatus(err)
 	}
-	pbEvents := make([]*pb.Event, len(events))
-	for i, event := range events {
-		pbEvents[i] = &pb.Event{
-			Id:          event.ID.String(),
-			Title:       event.Title,
-			Description: event.Description,
-			StartTime:   event.StartTime.Unix(),
-			EndTime:     event.EndTime.Unix(),
-			UserId:      event.UserID.String(),
+	resp := &pb.ListEventsResponse{Events: convertToPBEvents(page.Events)}
+	if page.Next != nil {
+		resp.NextPageToken = page.Next.Token()
+	}
+	return resp, nil
+}
+
+func convertFromPBFilter(req *pb.ListEventsRequest) (storage.EventFilter, error) {
+	filter := storage.EventFilter{
+		Title:      req.GetTitle(),
+		Descending: req.GetDescending(),
+		Limit:      int(req.GetPageSize()),
+	}
+	var err error
+	if filter.CalendarIDs, err = parseIDs(req.GetCalendarIds()); err != nil {
+		return filter, err
+	}
+	if req.GetUserId() != "" {
+		if filter.UserID, err = parseID(req.GetUserId()); err != nil {
+			return filter, err
+		}
+	}
+	if req.GetFrom() != 0 {
+		filter.From = time.Unix(req.GetFrom(), 0)
+	}
+	if req.GetTo() != 0 {
+		filter.To = time.Unix(req.GetTo(), 0)
+	}
+	if req.GetPageToken() != "" {
+		if filter.After, err = storage.ParseCursor(req.GetPageToken()); err != nil {
+			return filter, err
 		}
 	}
-	return &pb.ListEventsResponse{Events: pbEvents}, nil
+	return filter, nil
+}
+
+func (s *Server) ListAllEvents(ctx context.Context, _ *pb.ListAllEventsRequest) (*pb.ListAllEventsResponse, error) {
+	s.logg.Info("Listing events of all users")
+	events, err := s.app.ListAllEvents(ctx)
+	if err != nil {
+		s.logg.Error("Failed to list events: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.ListAllEventsResponse{Events: convertToPBEvents(events)}, nil
 }
 
-func (s *Server) ListEventsByDay(_ context.Context, req *pb.ListEventsByDayRequest,
+func (s *Server) SearchEvents(ctx context.Context, req *pb.SearchEventsRequest) (*pb.SearchEventsResponse, error) {
+	calendarIDs, err := parseIDs(req.GetCalendarIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	results, err := s.app.SearchEvents(ctx, storage.SearchQuery{
+		CalendarIDs: calendarIDs,
+		Text:        req.GetQuery(),
+		Limit:       int(req.GetLimit()),
+	})
+	if err != nil {
+		s.logg.Error("Failed to search events: " + err.Error())
+		return nil, toStatus(err)
+	}
+	resp := &pb.SearchEventsResponse{Results: make([]*pb.SearchResult, len(results))}
+	for i, result := range results {
+		resp.Results[i] = &pb.SearchResult{
+			Event:   convertToPBEvent(result.Event),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) ListEventsInRange(ctx context.Context, req *pb.ListEventsInRangeRequest,
+) (*pb.ListEventsInRangeResponse, error) {
+	calendarIDs, err := parseIDs(req.GetCalendarIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	events, err := s.app.ListEventsInRange(ctx, calendarIDs, time.Unix(req.GetFrom(), 0), time.Unix(req.GetTo(), 0))
+	if err != nil {
+		s.logg.Error("Failed to list events in range: " + err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.ListEventsInRangeResponse{Events: convertToPBEvents(events)}, nil
+}
+
+func (s *Server) ListEventsByDay(ctx context.Context, req *pb.ListEventsByDayRequest,
 ) (*pb.ListEventsByDayResponse, error) {
 	date := time.Unix(req.GetDate(), 0)
-	events, err := s.storage.ListEventsByDay(date)
+	calendarIDs, err := parseIDs(req.GetCalendarIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	events, err := s.app.ListEventsByDay(ctx, calendarIDs, date, req.GetTimeZone())
 	if err != nil {
 		s.logg.Error("Failed to list events by day: " + err.Error())
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &pb.ListEventsByDayResponse{Events: convertToPBEvents(events)}, nil
 }
 
-func (s *Server) ListEventsByWeek(_ context.Context, req *pb.ListEventsByWeekRequest,
+func (s *Server) ListEventsByWeek(ctx context.Context, req *pb.ListEventsByWeekRequest,
 ) (*pb.ListEventsByWeekResponse, error) {
 	start := time.Unix(req.GetStart(), 0)
-	events, err := s.storage.ListEventsByWeek(start)
+	calendarIDs, err := parseIDs(req.GetCalendarIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	events, err := s.app.ListEventsByWeek(ctx, calendarIDs, start, req.GetTimeZone())
 	if err != nil {
 		s.logg.Error("Failed to list events by week: " + err.Error())
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &pb.ListEventsByWeekResponse{Events: convertToPBEvents(events)}, nil
 }
 
-func (s *Server) ListEventsByMonth(_ context.Context, req *pb.ListEventsByMonthRequest,
+func (s *Server) ListEventsByMonth(ctx context.Context, req *pb.ListEventsByMonthRequest,
 ) (*pb.ListEventsByMonthResponse, error) {
 	start := time.Unix(req.GetStart(), 0)
-	events, err := s.storage.ListEventsByMonth(start)
+	calendarIDs, err := parseIDs(req.GetCalendarIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	events, err := s.app.ListEventsByMonth(ctx, calendarIDs, start, req.GetTimeZone())
 	if err != nil {
 		s.logg.Error("Failed to list events by month: " + err.Error())
-		return nil, err
+		return nil, toStatus(err)
 	}
 	return &pb.ListEventsByMonthResponse{Events: convertToPBEvents(events)}, nil
 }
@@ -140,14 +232,123 @@ func (s *Server) ListEventsByMonth(_ context.Context, req *pb.ListEventsByMonthR
 func convertToPBEvents(events []storage.Event) []*pb.Event {
 	pbEvents := make([]*pb.Event, len(events))
 	for i, event := range events {
-		pbEvents[i] = &pb.Event{
-			Id:          event.ID.String(),
-			Title:       event.Title,
-			Description: event.Description,
-			StartTime:   event.StartTime.Unix(),
-			EndTime:     event.EndTime.Unix(),
-			UserId:      event.UserID.String(),
-		}
+		pbEvents[i] = convertToPBEvent(event)
 	}
 	return pbEvents
 }
+
+func convertToPBEvent(event storage.Event) *pb.Event {
+	pbEvent := &pb.Event{
+		Id:           event.ID.String(),
+		Title:        event.Title,
+		Description:  event.Description,
+		StartTime:    event.StartTime.Unix(),
+		EndTime:      event.EndTime.Unix(),
+		UserId:       event.UserID.String(),
+		CalendarId:   event.CalendarID.String(),
+		Rrule:        event.RRule,
+		RecurrenceId: toUnix(event.RecurrenceID),
+		Reminders:    convertToPBReminders(event.Reminders),
+	}
+	for _, exDate := range event.ExDates {
+		pbEvent.Exdates = append(pbEvent.Exdates, exDate.Unix())
+	}
+	if event.IsOverride() {
+		pbEvent.RecurringEventId = event.RecurringEventID.String()
+	}
+	return pbEvent
+}
+
+// convertFromPBEvent leaves UserID and CalendarID empty if user_id and
+// calendar_id are not set; the app fills them in.
+func convertFromPBEvent(pbEvent *pb.Event) (storage.Event, error) {
+	var err error
+	event := storage.Event{
+		Title:        pbEvent.GetTitle(),
+		Description:  pbEvent.GetDescription(),
+		StartTime:    time.Unix(pbEvent.GetStartTime(), 0),
+		EndTime:      time.Unix(pbEvent.GetEndTime(), 0),
+		RRule:        pbEvent.GetRrule(),
+		RecurrenceID: fromUnix(pbEvent.GetRecurrenceId()),
+		Reminders:    convertFromPBReminders(pbEvent.GetReminders()),
+	}
+	if pbEvent.GetUserId() != "" {
+		if event.UserID, err = parseID(pbEvent.GetUserId()); err != nil {
+			return storage.Event{}, err
+		}
+	}
+	if pbEvent.GetCalendarId() != "" {
+		if event.CalendarID, err = parseID(pbEvent.GetCalendarId()); err != nil {
+			return storage.Event{}, err
+		}
+	}
+	for _, exDate := range pbEvent.GetExdates() {
+		event.ExDates = append(event.ExDates, time.Unix(exDate, 0))
+	}
+	if pbEvent.GetRecurringEventId() != "" {
+		if event.RecurringEventID, err = parseID(pbEvent.GetRecurringEventId()); err != nil {
+			return storage.Event{}, err
+		}
+	}
+	return event, nil
+}
+
+func parseID(value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return id, fmt.Errorf("%w: %q is not a valid ID", apierror.ErrInvalidArgument, value)
+	}
+	return id, nil
+}
+
+func parseIDs(values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(values))
+	for i, value := range values {
+		var err error
+		if ids[i], err = parseID(value); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func convertFromPBScope(scope pb.Scope) storage.Scope {
+	switch scope {
+	case pb.Scope_SCOPE_THIS:
+		return storage.ScopeThis
+	case pb.Scope_SCOPE_THIS_AND_FOLLOWING:
+		return storage.ScopeThisAndFollowing
+	default:
+		return storage.ScopeAll
+	}
+}
+
+// toStatus converts err into a gRPC status using the shared apierror mapping,
+// attaching the reason as an ErrorInfo detail.
+func toStatus(err error) error {
+	mapping := apierror.Lookup(err)
+	st := status.New(mapping.GRPCCode, apierror.Message(err))
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: mapping.Reason,
+		Domain: "calendar",
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// fromUnix and toUnix map the zero time.Time to 0 and back.
+func fromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
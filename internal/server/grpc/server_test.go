@@ -2,71 +2,242 @@ package grpc
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
-	pb "github.com/Dendyator/calendar/api/pb"        //nolint
-	"github.com/Dendyator/calendar/internal/logger"  //nolint
-	"github.com/Dendyator/calendar/internal/storage" //nolint
-	"github.com/google/uuid"                         //nolint
-	"github.com/stretchr/testify/assert"             //nolint
+	pb "github.com/Dendyator/calendar/api/pb"                             //nolint
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) CreateEvent(event storage.Event) error {
-	args := m.Called(event)
+func (m *MockStorage) CreateEvent(ctx context.Context, event storage.Event) error {
+	args := m.Called(ctx, event)
 	return args.Error(0)
 }
 
-func (m *MockStorage) UpdateEvent(id uuid.UUID, newEvent storage.Event) error {
-	args := m.Called(id, newEvent)
+func (m *MockStorage) GetEventByUID(ctx context.Context, calendarID uuid.UUID, uid string) (storage.Event, error) {
+	args := m.Called(ctx, calendarID, uid)
+	return args.Get(0).(storage.Event), args.Error(1)
+}
+
+func (m *MockStorage) ReplaceResource(ctx context.Context, calendarID uuid.UUID, name string,
+	plan func(current []storage.Event) (storage.SeriesChange, error),
+) error {
+	return m.Called(ctx, calendarID, name, plan).Error(0)
+}
+
+func (m *MockStorage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	args := m.Called(ctx, id, newEvent, scope, recurrenceID)
 	return args.Error(0)
 }
 
-func (m *MockStorage) DeleteEvent(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockStorage) DeleteEvent(ctx context.Context, id uuid.UUID, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	args := m.Called(ctx, id, scope, recurrenceID)
 	return args.Error(0)
 }
 
-func (m *MockStorage) GetEvent(id uuid.UUID) (storage.Event, error) {
-	args := m.Called(id)
+func (m *MockStorage) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEvents() ([]storage.Event, error) {
-	args := m.Called()
+func (m *MockStorage) ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(storage.EventPage), args.Error(1)
+}
+
+func (m *MockStorage) ListAllEvents(ctx context.Context) ([]storage.Event, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]storage.Event), args.Error(1)
+}
+
+func (m *MockStorage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]storage.SearchResult), args.Error(1)
+}
+
+func (m *MockStorage) CreateFeed(ctx context.Context, feed storage.Feed) error {
+	args := m.Called(ctx, feed)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetFeed(ctx context.Context, id uuid.UUID) (storage.Feed, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Feed), args.Error(1)
+}
+
+func (m *MockStorage) ListFeeds(ctx context.Context, userID uuid.UUID) ([]storage.Feed, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Feed), args.Error(1)
+}
+
+func (m *MockStorage) UpdateFeedSecret(ctx context.Context, id uuid.UUID, secretHash string) error {
+	args := m.Called(ctx, id, secretHash)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CreateSubscription(ctx context.Context, subscription storage.Subscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetSubscription(ctx context.Context, id uuid.UUID) (storage.Subscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]storage.Subscription, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) ListDueSubscriptions(ctx context.Context, now time.Time) ([]storage.Subscription, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) UpdateSubscriptionState(ctx context.Context, id uuid.UUID, state storage.SubscriptionState,
+) error {
+	args := m.Called(ctx, id, state)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetDefaultReminders(ctx context.Context, userID uuid.UUID) (storage.Durations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(storage.Durations), args.Error(1)
+}
+
+func (m *MockStorage) SetDefaultReminders(ctx context.Context, userID uuid.UUID, reminders storage.Durations) error {
+	args := m.Called(ctx, userID, reminders)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ListDefaultReminders(ctx context.Context) (map[uuid.UUID]storage.Durations, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[uuid.UUID]storage.Durations), args.Error(1)
+}
+
+func (m *MockStorage) ListEventsInRange(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, from, to)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEventsByDay(date time.Time) ([]storage.Event, error) {
-	args := m.Called(date)
+func (m *MockStorage) ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, date, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEventsByWeek(start time.Time) ([]storage.Event, error) {
-	args := m.Called(start)
+func (m *MockStorage) ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, start time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, start, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEventsByMonth(start time.Time) ([]storage.Event, error) {
-	args := m.Called(start)
+func (m *MockStorage) ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, start time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, start, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) DeleteOldEvents(before time.Time) error {
-	args := m.Called(before)
+func (m *MockStorage) DeleteOldEvents(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
 	return args.Error(0)
 }
 
+func (m *MockStorage) CreateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	return m.Called(ctx, calendar).Error(0)
+}
+
+func (m *MockStorage) GetCalendar(ctx context.Context, id uuid.UUID) (storage.Calendar, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Calendar), args.Error(1)
+}
+
+func (m *MockStorage) UpdateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	return m.Called(ctx, calendar).Error(0)
+}
+
+func (m *MockStorage) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockStorage) ListCalendars(ctx context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Calendar), args.Error(1)
+}
+
+func (m *MockStorage) GetRole(ctx context.Context, calendarID, userID uuid.UUID) (storage.Role, error) {
+	args := m.Called(ctx, calendarID, userID)
+	return args.Get(0).(storage.Role), args.Error(1)
+}
+
+func (m *MockStorage) SetACL(ctx context.Context, entry storage.ACLEntry) error {
+	return m.Called(ctx, entry).Error(0)
+}
+
+func (m *MockStorage) DeleteACL(ctx context.Context, calendarID, userID uuid.UUID) error {
+	return m.Called(ctx, calendarID, userID).Error(0)
+}
+
+func (m *MockStorage) ListACL(ctx context.Context, calendarID uuid.UUID) ([]storage.ACLEntry, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]storage.ACLEntry), args.Error(1)
+}
+
+// ownCalendar makes userID the owner of a new calendar in the mocked
+// storage and returns its ID.
+func ownCalendar(m *MockStorage, userID uuid.UUID) uuid.UUID {
+	calendarID := uuid.New()
+	m.On("ListCalendars", mock.Anything, userID).
+		Return([]storage.Calendar{{ID: calendarID, OwnerID: userID}}, nil).Maybe()
+	m.On("GetRole", mock.Anything, calendarID, userID).Return(storage.RoleOwner, nil).Maybe()
+	return calendarID
+}
+
+// asUser returns a call context authenticated as userID.
+func asUser(userID uuid.UUID) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
+}
+
 func TestCreateEvent(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	event := &pb.Event{
 		Title:       "Test Event",
@@ -76,34 +247,62 @@ func TestCreateEvent(t *testing.T) {
 		UserId:      uuid.New().String(),
 	}
 
-	mockStorage.On("CreateEvent", mock.Anything).Return(nil)
+	ownCalendar(mockStorage, uuid.MustParse(event.UserId))
+	mockStorage.On("CreateEvent", mock.Anything, mock.Anything).Return(nil)
 
-	resp, err := server.CreateEvent(context.Background(), &pb.CreateEventRequest{Event: event})
+	resp, err := server.CreateEvent(asUser(uuid.MustParse(event.UserId)), &pb.CreateEventRequest{Event: event})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	mockStorage.AssertExpectations(t)
 }
 
+func TestCreateEvent_DateBusy(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	event := &pb.Event{
+		Title:     "Overlapping Event",
+		StartTime: time.Now().Unix(),
+		EndTime:   time.Now().Add(1 * time.Hour).Unix(),
+		UserId:    uuid.New().String(),
+	}
+
+	ownCalendar(mockStorage, uuid.MustParse(event.UserId))
+	mockStorage.On("CreateEvent", mock.Anything, mock.Anything).Return(storage.ErrDateBusy)
+
+	_, err := server.CreateEvent(asUser(uuid.MustParse(event.UserId)), &pb.CreateEventRequest{Event: event})
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	mockStorage.AssertExpectations(t)
+}
+
 func TestUpdateEvent(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	eventID := uuid.New().String()
+	userID := uuid.New()
 	newEvent := &pb.Event{
 		Id:          eventID,
 		Title:       "Updated Event",
 		Description: "This is an updated test event.",
 		StartTime:   time.Now().Unix(),
 		EndTime:     time.Now().Add(1 * time.Hour).Unix(),
-		UserId:      uuid.New().String(),
+		UserId:      userID.String(),
 	}
 
-	mockStorage.On("UpdateEvent", mock.Anything, mock.Anything).Return(nil)
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("GetEvent", mock.Anything, mock.Anything).
+		Return(storage.Event{UserID: userID, CalendarID: calendarID}, nil)
+	mockStorage.On("UpdateEvent", mock.Anything, mock.Anything, mock.Anything, storage.ScopeAll, time.Time{}).
+		Return(nil)
 
-	resp, err := server.UpdateEvent(context.Background(), &pb.UpdateEventRequest{Id: eventID, Event: newEvent})
+	resp, err := server.UpdateEvent(asUser(userID), &pb.UpdateEventRequest{Id: eventID, Event: newEvent})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -114,13 +313,17 @@ func TestDeleteEvent(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	eventID := uuid.New().String()
+	userID := uuid.New()
 
-	mockStorage.On("DeleteEvent", mock.Anything).Return(nil)
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("GetEvent", mock.Anything, mock.Anything).
+		Return(storage.Event{UserID: userID, CalendarID: calendarID}, nil)
+	mockStorage.On("DeleteEvent", mock.Anything, mock.Anything, storage.ScopeAll, time.Time{}).Return(nil)
 
-	resp, err := server.DeleteEvent(context.Background(), &pb.DeleteEventRequest{Id: eventID})
+	resp, err := server.DeleteEvent(asUser(userID), &pb.DeleteEventRequest{Id: eventID})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -131,7 +334,7 @@ func TestGetEvent(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	eventID := uuid.New()
 	expectedEvent := storage.Event{
@@ -142,10 +345,11 @@ func TestGetEvent(t *testing.T) {
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
 	}
+	expectedEvent.CalendarID = ownCalendar(mockStorage, expectedEvent.UserID)
 
-	mockStorage.On("GetEvent", eventID).Return(expectedEvent, nil)
+	mockStorage.On("GetEvent", mock.Anything, eventID).Return(expectedEvent, nil)
 
-	resp, err := server.GetEvent(context.Background(), &pb.GetEventRequest{Id: eventID.String()})
+	resp, err := server.GetEvent(asUser(expectedEvent.UserID), &pb.GetEventRequest{Id: eventID.String()})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -153,11 +357,40 @@ func TestGetEvent(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 
+func TestGetEvent_NotFound(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	eventID := uuid.New()
+	mockStorage.On("GetEvent", mock.Anything, eventID).Return(storage.Event{}, storage.ErrNotFound)
+
+	_, err := server.GetEvent(asUser(uuid.New()), &pb.GetEventRequest{Id: eventID.String()})
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Len(t, st.Details(), 1)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetEvent_InvalidID(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	_, err := server.GetEvent(context.Background(), &pb.GetEventRequest{Id: "not-a-uuid"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockStorage.AssertNotCalled(t, "GetEvent", mock.Anything)
+}
+
 func TestListEvents(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	events := []storage.Event{
 		{
@@ -178,9 +411,12 @@ func TestListEvents(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("ListEvents").Return(events, nil)
+	userID := uuid.New()
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("ListEvents", mock.Anything, storage.EventFilter{CalendarIDs: []uuid.UUID{calendarID}, Limit: 50}).
+		Return(storage.EventPage{Events: events}, nil)
 
-	resp, err := server.ListEvents(context.Background(), &pb.ListEventsRequest{})
+	resp, err := server.ListEvents(asUser(userID), &pb.ListEventsRequest{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -192,7 +428,7 @@ func TestListEventsByDay(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	date := time.Now().Truncate(time.Second)
 	events := []storage.Event{
@@ -206,9 +442,12 @@ func TestListEventsByDay(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("ListEventsByDay", date).Return(events, nil)
+	calendarID := ownCalendar(mockStorage, events[0].UserID)
+	inMoscow := mock.MatchedBy(func(loc *time.Location) bool { return loc.String() == "Europe/Moscow" })
+	mockStorage.On("ListEventsByDay", mock.Anything, []uuid.UUID{calendarID}, date, inMoscow).Return(events, nil)
 
-	resp, err := server.ListEventsByDay(context.Background(), &pb.ListEventsByDayRequest{Date: date.Unix()})
+	resp, err := server.ListEventsByDay(asUser(events[0].UserID),
+		&pb.ListEventsByDayRequest{Date: date.Unix(), TimeZone: "Europe/Moscow"})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -220,7 +459,7 @@ func TestListEventsByWeek(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	start := time.Now().Truncate(time.Second)
 	events := []storage.Event{
@@ -234,9 +473,10 @@ func TestListEventsByWeek(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("ListEventsByWeek", start).Return(events, nil)
+	calendarID := ownCalendar(mockStorage, events[0].UserID)
+	mockStorage.On("ListEventsByWeek", mock.Anything, []uuid.UUID{calendarID}, start, time.UTC).Return(events, nil)
 
-	resp, err := server.ListEventsByWeek(context.Background(), &pb.ListEventsByWeekRequest{Start: start.Unix()})
+	resp, err := server.ListEventsByWeek(asUser(events[0].UserID), &pb.ListEventsByWeekRequest{Start: start.Unix()})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
@@ -248,7 +488,7 @@ func TestListEventsByMonth(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	server := NewGRPCServer(mockStorage, logg)
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
 
 	start := time.Now().Truncate(time.Second)
 	events := []storage.Event{
@@ -262,12 +502,195 @@ func TestListEventsByMonth(t *testing.T) {
 		},
 	}
 
-	mockStorage.On("ListEventsByMonth", start).Return(events, nil)
+	calendarID := ownCalendar(mockStorage, events[0].UserID)
+	mockStorage.On("ListEventsByMonth", mock.Anything, []uuid.UUID{calendarID}, start, time.UTC).Return(events, nil)
 
-	resp, err := server.ListEventsByMonth(context.Background(), &pb.ListEventsByMonthRequest{Start: start.Unix()})
+	resp, err := server.ListEventsByMonth(asUser(events[0].UserID), &pb.ListEventsByMonthRequest{Start: start.Unix()})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.Equal(t, len(events), len(resp.Events))
 	mockStorage.AssertExpectations(t)
 }
+
+func TestGetEvent_OtherUser(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	event := storage.Event{ID: uuid.New(), Title: "Private", UserID: uuid.New(), CalendarID: uuid.New()}
+	caller := uuid.New()
+	mockStorage.On("GetEvent", mock.Anything, event.ID).Return(event, nil)
+	mockStorage.On("GetRole", mock.Anything, event.CalendarID, caller).Return(storage.RoleNone, nil)
+
+	_, err := server.GetEvent(asUser(caller), &pb.GetEventRequest{Id: event.ID.String()})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListAllEvents(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	_, err := server.ListAllEvents(asUser(uuid.New()), &pb.ListAllEventsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	mockStorage.On("ListAllEvents", mock.Anything).Return([]storage.Event{{ID: uuid.New()}}, nil)
+	admin := auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New(), Admin: true})
+	resp, err := server.ListAllEvents(admin, &pb.ListAllEventsRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 1)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	interceptor := AuthInterceptor(&auth.HeaderAuthenticator{})
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return auth.FromContext(ctx)
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	userID := uuid.New()
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(strings.ToLower(auth.UserIDHeader), userID.String()))
+	identity, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, identity.(auth.Identity).UserID)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	server := NewGRPCServer(app.New(logg, store), store, logg)
+	req := &pb.CreateAPIKeyRequest{UserId: uuid.New().String(), Name: "bot", Scopes: []string{auth.ScopeEventsRead}}
+
+	_, err := server.CreateAPIKey(asUser(uuid.New()), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	admin := auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New(), Admin: true})
+	resp, err := server.CreateAPIKey(admin, req)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+
+	list, err := server.ListAPIKeys(admin, &pb.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.ApiKeys, 1)
+
+	_, err = server.DeleteAPIKey(admin, &pb.DeleteAPIKeyRequest{Id: resp.ApiKey.Id})
+	assert.NoError(t, err)
+	_, err = server.DeleteAPIKey(admin, &pb.DeleteAPIKeyRequest{Id: resp.ApiKey.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAuthInterceptor_APIKeyScope(t *testing.T) {
+	store := memorystorage.New()
+	key, token, err := auth.NewAPIKey(uuid.New(), "bot", []string{auth.ScopeEventsRead}, time.Time{})
+	assert.NoError(t, err)
+	assert.NoError(t, store.CreateAPIKey(context.Background(), key))
+
+	interceptor := AuthInterceptor(auth.NewAPIKeyAuthenticator(store))
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(strings.ToLower(auth.APIKeyHeader), token))
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/api.EventService/ListEvents"}, handler)
+	assert.NoError(t, err)
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/api.EventService/CreateEvent"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestShareCalendar(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	server := NewGRPCServer(app.New(logg, store), store, logg)
+	owner, teammate := uuid.New(), uuid.New()
+
+	created, err := server.CreateCalendar(asUser(owner),
+		&pb.CreateCalendarRequest{Calendar: &pb.Calendar{Name: "Team"}})
+	assert.NoError(t, err)
+	calendarID := created.Calendar.Id
+	event := &pb.Event{
+		Title:      "Planning",
+		StartTime:  time.Now().Unix(),
+		EndTime:    time.Now().Add(time.Hour).Unix(),
+		CalendarId: calendarID,
+	}
+	createdEvent, err := server.CreateEvent(asUser(owner), &pb.CreateEventRequest{Event: event})
+	assert.NoError(t, err)
+	get := &pb.GetEventRequest{Id: createdEvent.Event.Id}
+
+	_, err = server.GetEvent(asUser(teammate), get)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	share := &pb.ACLEntry{CalendarId: calendarID, UserId: teammate.String(), Role: "viewer"}
+	_, err = server.ShareCalendar(asUser(teammate), &pb.ShareCalendarRequest{Entry: share})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = server.ShareCalendar(asUser(owner), &pb.ShareCalendarRequest{Entry: share})
+	assert.NoError(t, err)
+
+	_, err = server.GetEvent(asUser(teammate), get)
+	assert.NoError(t, err)
+	_, err = server.DeleteEvent(asUser(teammate), &pb.DeleteEventRequest{Id: createdEvent.Event.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	acl, err := server.ListCalendarACL(asUser(owner), &pb.ListCalendarACLRequest{CalendarId: calendarID})
+	assert.NoError(t, err)
+	assert.Len(t, acl.Entries, 1)
+}
+
+func TestListEventsInRange(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	from := time.Now().Truncate(time.Second)
+	to := from.Add(48 * time.Hour)
+	userID := uuid.New()
+	events := []storage.Event{{ID: uuid.New(), Title: "Conference", StartTime: from.Add(-time.Hour), EndTime: to,
+		UserID: userID}}
+
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("ListEventsInRange", mock.Anything, []uuid.UUID{calendarID}, from, to).Return(events, nil)
+
+	resp, err := server.ListEventsInRange(asUser(userID),
+		&pb.ListEventsInRangeRequest{From: from.Unix(), To: to.Unix()})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 1)
+	mockStorage.AssertExpectations(t)
+
+	_, err = server.ListEventsInRange(asUser(userID), &pb.ListEventsInRangeRequest{From: to.Unix(), To: from.Unix()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestSearchEvents(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	server := NewGRPCServer(app.New(logg, mockStorage), nil, logg)
+
+	userID := uuid.New()
+	calendarID := ownCalendar(mockStorage, userID)
+	event := storage.Event{ID: uuid.New(), Title: "Q3 budget", StartTime: time.Now(), EndTime: time.Now(),
+		UserID: userID, CalendarID: calendarID}
+	query := storage.SearchQuery{CalendarIDs: []uuid.UUID{calendarID}, Text: "budget", Limit: 20}
+	mockStorage.On("SearchEvents", mock.Anything, query).
+		Return([]storage.SearchResult{{Event: event, Rank: 1, Snippet: "Q3 <b>budget</b>"}}, nil)
+
+	resp, err := server.SearchEvents(asUser(userID), &pb.SearchEventsRequest{Query: "budget"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, event.ID.String(), resp.Results[0].Event.Id)
+	assert.Equal(t, "Q3 <b>budget</b>", resp.Results[0].Snippet)
+	mockStorage.AssertExpectations(t)
+
+	_, err = server.SearchEvents(asUser(userID), &pb.SearchEventsRequest{Query: "  "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, auth.ScopeEventsRead, methodScope("/api.EventService/SearchEvents"))
+}
@@ -0,0 +1,112 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/auth"            //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	"github.com/gorilla/mux"                                 //nolint
+)
+
+const apiKeyPath = "/admin/api-keys/{id:[0-9a-fA-F-]{36}}"
+
+type createAPIKeyRequest struct {
+	UserID    uuid.UUID `json:"userId"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type createAPIKeyResponse struct {
+	APIKey storage.APIKey `json:"apiKey"`
+	// Token is the secret to send in the X-API-Key header; it cannot be
+	// retrieved again.
+	Token string `json:"token"`
+}
+
+func createAPIKeyHandler(keys storage.APIKeyInterface, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for an API key")
+		if _, err := auth.RequireAdmin(r.Context()); err != nil {
+			logg.Errorf("Refused to create API key: %v", err)
+			writeError(w, err)
+			return
+		}
+		var req createAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logg.Errorf("Failed to decode API key: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		if req.UserID == uuid.Nil {
+			writeError(w, fmt.Errorf("%w: userId is required", apierror.ErrInvalidArgument))
+			return
+		}
+		key, token, err := auth.NewAPIKey(req.UserID, req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			logg.Errorf("Failed to generate API key: %v", err)
+			writeError(w, err)
+			return
+		}
+		if err := keys.CreateAPIKey(r.Context(), key); err != nil {
+			logg.Errorf("Failed to create API key: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("API key created: %s", key.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(createAPIKeyResponse{APIKey: key, Token: token})
+	}
+}
+
+func listAPIKeysHandler(keys storage.APIKeyInterface, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing API keys")
+		if _, err := auth.RequireAdmin(r.Context()); err != nil {
+			logg.Errorf("Refused to list API keys: %v", err)
+			writeError(w, err)
+			return
+		}
+		list, err := keys.ListAPIKeys(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to list API keys: %v", err)
+			writeError(w, err)
+			return
+		}
+		if list == nil {
+			list = []storage.APIKey{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+func deleteAPIKeyHandler(keys storage.APIKeyInterface, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for an API key")
+		if _, err := auth.RequireAdmin(r.Context()); err != nil {
+			logg.Errorf("Refused to delete API key: %v", err)
+			writeError(w, err)
+			return
+		}
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: API key ID: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		if err := keys.DeleteAPIKey(r.Context(), id); err != nil {
+			logg.Errorf("Failed to delete API key: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("API key deleted: %s", id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
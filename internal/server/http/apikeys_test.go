@@ -0,0 +1,74 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	admin := uuid.New()
+	headerAuthn, err := auth.NewHeaderAuthenticator([]string{admin.String()})
+	assert.NoError(t, err)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, app.New(logg, store), store,
+		auth.Chain(auth.NewAPIKeyAuthenticator(store), headerAuthn))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	bot := uuid.New()
+	body, _ := json.Marshal(createAPIKeyRequest{UserID: bot, Name: "bot", Scopes: []string{auth.ScopeEventsRead}})
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/api-keys",
+		bytes.NewBuffer(body))
+	assert.NoError(t, err)
+	req.Header.Set(auth.UserIDHeader, bot.String())
+	assert.Equal(t, http.StatusForbidden, serve(req).Code)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, "/admin/api-keys",
+		bytes.NewBuffer(body))
+	assert.NoError(t, err)
+	req.Header.Set(auth.UserIDHeader, admin.String())
+	rr := serve(req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created createAPIKeyResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	stored, err := store.GetAPIKey(context.Background(), created.APIKey.ID)
+	assert.NoError(t, err)
+	assert.NotContains(t, rr.Body.String(), stored.SecretHash)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "/events", nil)
+	assert.NoError(t, err)
+	req.Header.Set(auth.APIKeyHeader, created.Token)
+	assert.Equal(t, http.StatusOK, serve(req).Code)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, "/events",
+		bytes.NewBufferString(`{"Title":"from a bot"}`))
+	assert.NoError(t, err)
+	req.Header.Set(auth.APIKeyHeader, created.Token)
+	assert.Equal(t, http.StatusForbidden, serve(req).Code)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodDelete,
+		"/admin/api-keys/"+created.APIKey.ID.String(), nil)
+	assert.NoError(t, err)
+	req.Header.Set(auth.UserIDHeader, admin.String())
+	assert.Equal(t, http.StatusOK, serve(req).Code)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodGet, "/events", nil)
+	assert.NoError(t, err)
+	req.Header.Set(auth.APIKeyHeader, created.Token)
+	assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+}
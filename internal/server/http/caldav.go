@@ -0,0 +1,593 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/auth"            //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	"github.com/gorilla/mux"                                 //nolint
+)
+
+// The CalDAV (RFC 4791) subset served under davRoot: principal and calendar
+// discovery with PROPFIND, calendar-query and calendar-multiget REPORTs, and
+// GET, PUT and DELETE of calendar object resources with ETags. Every
+// calendar the caller can read is a calendar collection; every event or
+// series, with its overrides, is a resource named after its UID.
+const (
+	davRoot          = "/dav/"
+	davPrincipalPath = davRoot + "principals/{userId:[0-9a-fA-F-]{36}}/"
+	davHomePath      = davRoot + "calendars/"
+	davCalendarPath  = davHomePath + "{id:[0-9a-fA-F-]{36}}/"
+	davObjectPath    = davCalendarPath + "{file:[^/]+\\.ics}"
+
+	methodPropfind = "PROPFIND"
+	methodReport   = "REPORT"
+
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+	nsApple  = "http://apple.com/ns/ical/"
+
+	davContentType       = "application/xml; charset=utf-8"
+	davAllowedMethods    = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	davComplianceClasses = "1, 3, calendar-access"
+	// icsSuffix is the extension of calendar object resources.
+	icsSuffix = ".ics"
+)
+
+var (
+	propResourceType    = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName     = xml.Name{Space: nsDAV, Local: "displayname"}
+	propPrincipal       = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL    = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propETag            = xml.Name{Space: nsDAV, Local: "getetag"}
+	propContentType     = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCalendarHome    = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propCalendarData    = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propComponentSet    = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCTag            = xml.Name{Space: nsCS, Local: "getctag"}
+	propCalendarColor   = xml.Name{Space: nsApple, Local: "calendar-color"}
+	reportCalendarQuery = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	reportCalendarMulti = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+)
+
+// registerCalDAV mounts the CalDAV endpoints on the router.
+func registerCalDAV(router *mux.Router, application *app.App, logg *logger.Logger) {
+	router.Handle("/.well-known/caldav", http.RedirectHandler(davRoot, http.StatusMovedPermanently))
+	router.PathPrefix(davRoot).Methods(http.MethodOptions).HandlerFunc(davOptionsHandler)
+	for _, path := range []string{davRoot, davPrincipalPath, davHomePath} {
+		router.HandleFunc(path, propfindHandler(application, logg)).Methods(methodPropfind)
+	}
+	calendarPaths := []string{davCalendarPath, strings.TrimSuffix(davCalendarPath, "/")}
+	for _, path := range calendarPaths {
+		router.HandleFunc(path, propfindHandler(application, logg)).Methods(methodPropfind)
+		router.HandleFunc(path, reportHandler(application, logg)).Methods(methodReport)
+	}
+	router.HandleFunc(davObjectPath, propfindHandler(application, logg)).Methods(methodPropfind)
+	router.HandleFunc(davObjectPath, getResourceHandler(application, logg)).Methods(http.MethodGet, http.MethodHead)
+	router.HandleFunc(davObjectPath, putResourceHandler(application, logg)).Methods(http.MethodPut)
+	router.HandleFunc(davObjectPath, deleteResourceHandler(application, logg)).Methods(http.MethodDelete)
+}
+
+func davOptionsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("DAV", davComplianceClasses)
+	w.Header().Set("Allow", davAllowedMethods)
+	w.WriteHeader(http.StatusOK)
+}
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"DAV: multistatus"`
+	Responses []davResponse `xml:"response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Propstats []davPropstat `xml:"DAV: propstat,omitempty"`
+	Status    string        `xml:"DAV: status,omitempty"`
+}
+
+type davPropstat struct {
+	Prop   davProps `xml:"DAV: prop"`
+	Status string   `xml:"DAV: status"`
+}
+
+type davProps struct {
+	Props []davProperty
+}
+
+// davProperty is a property with its value as raw XML.
+type davProperty struct {
+	XMLName  xml.Name
+	InnerXML string `xml:",innerxml"`
+}
+
+type davPropNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+// davRequest is the body of a PROPFIND or REPORT. Without a prop element
+// every property but calendar-data is returned.
+type davRequest struct {
+	XMLName xml.Name
+	Prop    *davPropNames `xml:"DAV: prop"`
+	Hrefs   []string      `xml:"DAV: href"`
+	Filter  *struct {
+		Comp compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type compFilter struct {
+	Name      string       `xml:"name,attr"`
+	Comps     []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	TimeRange *struct {
+		Start string `xml:"start,attr"`
+		End   string `xml:"end,attr"`
+	} `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+}
+
+// requested returns the requested property names, or nil for all.
+func (r davRequest) requested() []xml.Name {
+	if r.Prop == nil {
+		return nil
+	}
+	names := make([]xml.Name, len(r.Prop.Names))
+	for i, name := range r.Prop.Names {
+		names[i] = name.XMLName
+	}
+	return names
+}
+
+func readDAVRequest(r *http.Request) (davRequest, error) {
+	var request davRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize))
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return request, err
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		return request, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err)
+	}
+	return request, nil
+}
+
+// davResource is a resource being described in a multistatus response.
+type davResource struct {
+	href  string
+	props map[xml.Name]string
+}
+
+// response returns the requested properties, or every property but
+// calendar-data if names is nil, grouped by status.
+func (d davResource) response(names []xml.Name) davResponse {
+	found := davPropstat{Status: "HTTP/1.1 200 OK"}
+	missing := davPropstat{Status: "HTTP/1.1 404 Not Found"}
+	if names == nil {
+		for name := range d.props {
+			if name != propCalendarData {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		value, ok := d.props[name]
+		if ok {
+			found.Prop.Props = append(found.Prop.Props, davProperty{XMLName: name, InnerXML: value})
+		} else {
+			missing.Prop.Props = append(missing.Prop.Props, davProperty{XMLName: name})
+		}
+	}
+	response := davResponse{Href: d.href}
+	for _, propstat := range []davPropstat{found, missing} {
+		if len(propstat.Prop.Props) > 0 {
+			response.Propstats = append(response.Propstats, propstat)
+		}
+	}
+	return response
+}
+
+func davHref(href string) string {
+	return "<href xmlns=\"DAV:\">" + escapeXML(href) + "</href>"
+}
+
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+func calendarHref(id uuid.UUID) string {
+	return davHomePath + id.String() + "/"
+}
+
+func resourceHref(calendarID uuid.UUID, name string) string {
+	return calendarHref(calendarID) + url.PathEscape(name) + icsSuffix
+}
+
+func principalHref(identity auth.Identity) string {
+	return davRoot + "principals/" + identity.UserID.String() + "/"
+}
+
+// propfindHandler describes the root, a principal, the calendar home, a
+// calendar or a resource and, with "Depth: 1", its members.
+func propfindHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling PROPFIND request for ", r.URL.Path)
+
+		request, err := readDAVRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		identity, err := auth.FromContext(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		depth := r.Header.Get("Depth") != "0"
+		user := map[xml.Name]string{
+			propPrincipal:    davHref(principalHref(identity)),
+			propCalendarHome: davHref(davHomePath),
+		}
+
+		var resources []davResource
+		vars := mux.Vars(r)
+		switch {
+		case vars["file"] != "":
+			resource, calendar, err := davObject(r, application)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			object, err := objectResource(calendar, resource, request.requested())
+			if err != nil {
+				logg.Errorf("Failed to encode events to iCalendar: %v", err)
+				writeError(w, err)
+				return
+			}
+			resources = append(resources, object)
+		case vars["id"] != "":
+			resources, err = calendarResources(r, application, user, depth)
+			if err != nil {
+				logg.Errorf("Failed to list CalDAV resources: %v", err)
+				writeError(w, err)
+				return
+			}
+		case vars["userId"] != "":
+			if vars["userId"] != identity.UserID.String() {
+				writeError(w, storage.ErrNotFound)
+				return
+			}
+			props := withProps(user, map[xml.Name]string{
+				propPrincipalURL: davHref(principalHref(identity)),
+				propResourceType: `<principal xmlns="DAV:"/>`,
+			})
+			resources = append(resources, davResource{href: principalHref(identity), props: props})
+		case r.URL.Path == davHomePath:
+			props := withProps(user, map[xml.Name]string{propResourceType: `<collection xmlns="DAV:"/>`})
+			resources = append(resources, davResource{href: davHomePath, props: props})
+			if depth {
+				calendars, err := application.ListCalendars(r.Context())
+				if err != nil {
+					logg.Errorf("Failed to list calendars: %v", err)
+					writeError(w, err)
+					return
+				}
+				for _, calendar := range calendars {
+					resources = append(resources, collectionResource(calendar, user, ""))
+				}
+			}
+		default:
+			props := withProps(user, map[xml.Name]string{propResourceType: `<collection xmlns="DAV:"/>`})
+			resources = append(resources, davResource{href: davRoot, props: props})
+		}
+		responses := make([]davResponse, len(resources))
+		for i, resource := range resources {
+			responses[i] = resource.response(request.requested())
+		}
+		writeMultistatus(w, logg, responses)
+	}
+}
+
+// calendarResources describes the calendar of the request and, if members
+// is set, its resources.
+func calendarResources(r *http.Request, application *app.App, user map[xml.Name]string, members bool,
+) ([]davResource, error) {
+	calendarID, err := parseVarID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := application.GetCalendar(r.Context(), calendarID)
+	if err != nil {
+		return nil, err
+	}
+	objects, ctag, err := application.ListResources(r.Context(), calendarID)
+	if err != nil {
+		return nil, err
+	}
+	resources := []davResource{collectionResource(calendar, user, ctag)}
+	if members {
+		for _, object := range objects {
+			resource, _ := objectResource(calendar, object, nil)
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func collectionResource(calendar storage.Calendar, user map[xml.Name]string, ctag string) davResource {
+	props := map[xml.Name]string{
+		propResourceType:  `<collection xmlns="DAV:"/><calendar xmlns="` + nsCalDAV + `"/>`,
+		propDisplayName:   escapeXML(calendar.Name),
+		propComponentSet:  `<comp xmlns="` + nsCalDAV + `" name="VEVENT"/>`,
+		propCalendarColor: escapeXML(calendar.Color),
+	}
+	if ctag != "" {
+		props[propCTag] = escapeXML(ctag)
+		props[propETag] = escapeXML(ctag)
+	}
+	return davResource{href: calendarHref(calendar.ID), props: withProps(user, props)}
+}
+
+// withProps returns the union of the property sets.
+func withProps(sets ...map[xml.Name]string) map[xml.Name]string {
+	props := make(map[xml.Name]string)
+	for _, set := range sets {
+		for name, value := range set {
+			props[name] = value
+		}
+	}
+	return props
+}
+
+// objectResource describes a calendar object resource. Its calendar-data is
+// only encoded if it is among the requested names.
+func objectResource(calendar storage.Calendar, resource app.Resource, names []xml.Name) (davResource, error) {
+	props := map[xml.Name]string{
+		propResourceType: "",
+		propETag:         escapeXML(resource.ETag()),
+		propContentType:  icalContentType,
+	}
+	for _, name := range names {
+		if name != propCalendarData {
+			continue
+		}
+		data, err := encodeCalendar(calendar.Location(), resource.Events)
+		if err != nil {
+			return davResource{}, err
+		}
+		props[propCalendarData] = escapeXML(string(data))
+	}
+	return davResource{href: resourceHref(calendar.ID, resource.Name), props: props}, nil
+}
+
+func writeMultistatus(w http.ResponseWriter, logg *logger.Logger, responses []davResponse) {
+	body, err := xml.Marshal(davMultistatus{Responses: responses})
+	if err != nil {
+		logg.Errorf("Failed to encode multistatus: %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", davContentType)
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
+
+// reportHandler answers calendar-query and calendar-multiget REPORTs on a
+// calendar collection.
+func reportHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling REPORT request for ", r.URL.Path)
+
+		request, err := readDAVRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		calendarID, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		calendar, err := application.GetCalendar(r.Context(), calendarID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var objects []app.Resource
+		var missing []string
+		switch request.XMLName {
+		case reportCalendarQuery:
+			objects, err = queryResources(r, application, calendarID, request)
+		case reportCalendarMulti:
+			for _, href := range request.Hrefs {
+				object, err := application.GetResource(r.Context(), calendarID, resourceName(calendarID, href))
+				switch {
+				case err == nil:
+					objects = append(objects, object)
+				case apierror.Lookup(err).HTTPStatus == http.StatusNotFound:
+					missing = append(missing, href)
+				default:
+					writeError(w, err)
+					return
+				}
+			}
+		default:
+			err = fmt.Errorf("%w: unsupported report %s", apierror.ErrInvalidArgument, request.XMLName.Local)
+		}
+		if err != nil {
+			logg.Errorf("Failed to run CalDAV report: %v", err)
+			writeError(w, err)
+			return
+		}
+
+		names := request.requested()
+		responses := make([]davResponse, 0, len(objects)+len(missing))
+		for _, object := range objects {
+			resource, err := objectResource(calendar, object, names)
+			if err != nil {
+				logg.Errorf("Failed to encode events to iCalendar: %v", err)
+				writeError(w, err)
+				return
+			}
+			responses = append(responses, resource.response(names))
+		}
+		for _, href := range missing {
+			responses = append(responses, davResponse{Href: href, Status: "HTTP/1.1 404 Not Found"})
+		}
+		writeMultistatus(w, logg, responses)
+	}
+}
+
+// queryResources applies the filter of a calendar-query: the VCALENDAR
+// component, optionally narrowed to VEVENTs overlapping a time-range.
+func queryResources(r *http.Request, application *app.App, calendarID uuid.UUID, request davRequest,
+) ([]app.Resource, error) {
+	if request.Filter == nil || request.Filter.Comp.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: the filter must select VCALENDAR", apierror.ErrInvalidArgument)
+	}
+	comps := request.Filter.Comp.Comps
+	if len(comps) == 0 {
+		return application.QueryResources(r.Context(), calendarID, time.Time{}, time.Time{})
+	}
+	var vevent *compFilter
+	for i := range comps {
+		if comps[i].Name == "VEVENT" {
+			vevent = &comps[i]
+		}
+	}
+	if vevent == nil {
+		// Only VEVENTs are stored.
+		return []app.Resource{}, nil
+	}
+	var from, to time.Time
+	if vevent.TimeRange != nil {
+		var err error
+		if from, err = parseDAVTime(vevent.TimeRange.Start); err != nil {
+			return nil, err
+		}
+		if to, err = parseDAVTime(vevent.TimeRange.End); err != nil {
+			return nil, err
+		}
+	}
+	return application.QueryResources(r.Context(), calendarID, from, to)
+}
+
+// parseDAVTime reads a UTC time-range bound; an empty bound is zero.
+func parseDAVTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("20060102T150405Z", value)
+	if err != nil {
+		return t, fmt.Errorf("%w: time-range: %w", apierror.ErrInvalidArgument, err)
+	}
+	return t, nil
+}
+
+// resourceName returns the name of the resource an href of the calendar
+// points to.
+func resourceName(calendarID uuid.UUID, href string) string {
+	if parsed, err := url.Parse(href); err == nil {
+		href = parsed.Path
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(href, calendarHref(calendarID)), icsSuffix)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// davObject loads the resource addressed by the request.
+func davObject(r *http.Request, application *app.App) (app.Resource, storage.Calendar, error) {
+	calendarID, err := parseVarID(r, "id")
+	if err != nil {
+		return app.Resource{}, storage.Calendar{}, err
+	}
+	calendar, err := application.GetCalendar(r.Context(), calendarID)
+	if err != nil {
+		return app.Resource{}, calendar, err
+	}
+	resource, err := application.GetResource(r.Context(), calendarID, objectName(r))
+	return resource, calendar, err
+}
+
+func objectName(r *http.Request) string {
+	return strings.TrimSuffix(mux.Vars(r)["file"], icsSuffix)
+}
+
+func getResourceHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for CalDAV resource ", r.URL.Path)
+
+		resource, calendar, err := davObject(r, application)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("ETag", resource.ETag())
+		writeCalendar(w, logg, calendar.Location(), resource.Events)
+	}
+}
+
+func putResourceHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling PUT request for CalDAV resource ", r.URL.Path)
+
+		calendarID, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		body := http.MaxBytesReader(w, r.Body, maxImportSize)
+		resource, created, err := application.PutResource(r.Context(), calendarID, objectName(r), body,
+			davPreconditions(r))
+		if err != nil {
+			logg.Errorf("Failed to store CalDAV resource: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("ETag", resource.ETag())
+		if created {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func deleteResourceHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for CalDAV resource ", r.URL.Path)
+
+		calendarID, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		err = application.DeleteResource(r.Context(), calendarID, objectName(r), davPreconditions(r))
+		if err != nil {
+			logg.Errorf("Failed to delete CalDAV resource: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func davPreconditions(r *http.Request) app.Preconditions {
+	return app.Preconditions{IfMatch: r.Header.Get("If-Match"), IfNoneMatch: r.Header.Get("If-None-Match")}
+}
@@ -0,0 +1,188 @@
+package internalhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+const standupICS = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"DTSTART:20240101T090000Z\r\n" +
+	"DTEND:20240101T091500Z\r\n" +
+	"RRULE:FREQ=DAILY;COUNT=3\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID:20240102T090000Z\r\n" +
+	"SUMMARY:Standup (late)\r\n" +
+	"DTSTART:20240102T110000Z\r\n" +
+	"DTEND:20240102T111500Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestCalDAV(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		auth.Chain(auth.NewAPIKeyAuthenticator(store), &auth.HeaderAuthenticator{}))
+	owner := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+	calendar, err := application.CreateCalendar(ctx, storage.Calendar{Name: "Team & friends"})
+	require.NoError(t, err)
+	key, secret, err := auth.NewAPIKey(owner, "phone",
+		[]string{auth.ScopeEventsRead, auth.ScopeEventsWrite}, time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, key))
+
+	serve := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("phone", secret)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	calendarPath := "/dav/calendars/" + calendar.ID.String() + "/"
+	objectPath := calendarPath + "standup@example.com.ics"
+
+	rr := serve(http.MethodGet, "/.well-known/caldav", "")
+	assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+	assert.Equal(t, davRoot, rr.Header().Get("Location"))
+
+	rr = serve(methodPropfind, davRoot, `<propfind xmlns="DAV:"><prop><current-user-principal/></prop></propfind>`,
+		"Depth", "0")
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/dav/principals/"+owner.String()+"/</href>")
+
+	rr = serve(methodPropfind, "/dav/principals/"+owner.String()+"/", "")
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<calendar-home-set xmlns="`+nsCalDAV+`"><href xmlns="DAV:">/dav/calendars/`)
+
+	rr = serve(methodPropfind, davHomePath, "", "Depth", "1")
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<href xmlns="DAV:">`+calendarPath+"</href>")
+	assert.Contains(t, rr.Body.String(), `<calendar xmlns="`+nsCalDAV+`"/>`)
+	assert.Contains(t, rr.Body.String(), "Team &amp; friends")
+
+	rr = serve(http.MethodPut, objectPath, standupICS, "If-None-Match", "*")
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, http.StatusPreconditionFailed,
+		serve(http.MethodPut, objectPath, standupICS, "If-None-Match", "*").Code)
+	assert.Equal(t, http.StatusBadRequest,
+		serve(http.MethodPut, calendarPath+"other.ics", standupICS).Code)
+
+	rr = serve(http.MethodGet, objectPath, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Body.String(), "RECURRENCE-ID:20240102T090000Z\r\n")
+
+	rr = serve(methodPropfind, calendarPath, `<propfind xmlns="DAV:"><prop><getetag/></prop></propfind>`,
+		"Depth", "1")
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<href xmlns="DAV:">`+objectPath+"</href>")
+	assert.Contains(t, rr.Body.String(), strings.ReplaceAll(etag, `"`, "&#34;"))
+
+	query := `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/><C:calendar-data/></D:prop>
+		<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">
+		<C:time-range start="%s" end="%s"/></C:comp-filter></C:comp-filter></C:filter></C:calendar-query>`
+	rr = serve(methodReport, calendarPath, fmt.Sprintf(query, "20240102T000000Z", "20240103T000000Z"))
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SUMMARY:Standup (late)")
+	rr = serve(methodReport, calendarPath, fmt.Sprintf(query, "20240201T000000Z", "20240301T000000Z"))
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "standup@example.com")
+
+	// Dropping the override restores the occurrence.
+	withoutOverride := standupICS[:strings.Index(standupICS, "BEGIN:VEVENT\r\nUID:standup@example.com\r\nRECURRENCE")] +
+		"END:VCALENDAR\r\n"
+	assert.Equal(t, http.StatusPreconditionFailed,
+		serve(http.MethodPut, objectPath, withoutOverride, "If-Match", `"stale"`).Code)
+	rr = serve(http.MethodPut, objectPath, withoutOverride, "If-Match", etag)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	etag = rr.Header().Get("ETag")
+
+	multiget := `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+		<D:prop><D:getetag/><C:calendar-data/></D:prop>
+		<D:href>` + objectPath + `</D:href><D:href>` + calendarPath + `missing.ics</D:href></C:calendar-multiget>`
+	rr = serve(methodReport, calendarPath, multiget)
+	require.Equal(t, http.StatusMultiStatus, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "RECURRENCE-ID")
+	assert.Contains(t, rr.Body.String(), `<href xmlns="DAV:">`+calendarPath+
+		`missing.ics</href><status xmlns="DAV:">HTTP/1.1 404 Not Found</status>`)
+	events, err := application.ListEventsInRange(ctx, nil, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, objectPath, "", "If-Match", etag).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, objectPath, "").Code)
+
+	req := httptest.NewRequest(methodPropfind, davRoot, nil)
+	rr = httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, `Basic realm="calendar"`, rr.Header().Get("WWW-Authenticate"))
+}
+
+func TestCalDAVConcurrentPut(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner := uuid.New()
+	calendar, err := application.CreateCalendar(asUser(owner), storage.Calendar{Name: "Team"})
+	require.NoError(t, err)
+	objectPath := "/dav/calendars/" + calendar.ID.String() + "/standup@example.com.ics"
+
+	put := func(body string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, objectPath, strings.NewReader(body))
+		req.Header.Set(auth.UserIDHeader, owner.String())
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	rr := put(standupICS, "If-None-Match", "*")
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	etag := rr.Header().Get("ETag")
+
+	// Every writer saw the same ETag, so only the first may win.
+	const writers = 8
+	codes := make(chan int, writers)
+	for i := 0; i < writers; i++ {
+		body := strings.Replace(standupICS, "SUMMARY:Standup\r\n", fmt.Sprintf("SUMMARY:Standup %d\r\n", i), 1)
+		go func() { codes <- put(body, "If-Match", etag).Code }()
+	}
+	counts := make(map[int]int)
+	for i := 0; i < writers; i++ {
+		counts[<-codes]++
+	}
+	assert.Equal(t, map[int]int{http.StatusNoContent: 1, http.StatusPreconditionFailed: writers - 1}, counts)
+}
@@ -0,0 +1,210 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	"github.com/gorilla/mux"                                 //nolint
+)
+
+const (
+	// calendarPath matches a single calendar addressed by its UUID.
+	calendarPath = "/calendars/{id:[0-9a-fA-F-]{36}}"
+	// aclPath matches the ACL entry of a user in a calendar.
+	aclPath = calendarPath + "/acl/{userId:[0-9a-fA-F-]{36}}"
+)
+
+type shareCalendarRequest struct {
+	Role string `json:"role"`
+}
+
+func createCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for a calendar")
+		var calendar storage.Calendar
+		if err := json.NewDecoder(r.Body).Decode(&calendar); err != nil {
+			logg.Errorf("Failed to decode calendar: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		calendar, err := application.CreateCalendar(r.Context(), calendar)
+		if err != nil {
+			logg.Errorf("Failed to create calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Calendar created: %s", calendar.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(calendar)
+	}
+}
+
+func listCalendarsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing calendars")
+		calendars, err := application.ListCalendars(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to list calendars: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(calendars)
+	}
+}
+
+func getCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for a calendar")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		calendar, err := application.GetCalendar(r.Context(), id)
+		if err != nil {
+			logg.Errorf("Failed to get calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(calendar)
+	}
+}
+
+// updateCalendarHandler replaces the name, color and time zone of the
+// calendar.
+func updateCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling PUT request for a calendar")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var calendar storage.Calendar
+		if err := json.NewDecoder(r.Body).Decode(&calendar); err != nil {
+			logg.Errorf("Failed to decode calendar: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		calendar.ID = id
+		calendar, err = application.UpdateCalendar(r.Context(), calendar)
+		if err != nil {
+			logg.Errorf("Failed to update calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Calendar updated: %s", id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(calendar)
+	}
+}
+
+func deleteCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for a calendar")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := application.DeleteCalendar(r.Context(), id); err != nil {
+			logg.Errorf("Failed to delete calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Calendar deleted: %s", id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func listACLHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for a calendar ACL")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		entries, err := application.ListACL(r.Context(), id)
+		if err != nil {
+			logg.Errorf("Failed to list calendar ACL: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
+
+// shareCalendarHandler grants the user in the path the role in the body,
+// replacing an earlier grant.
+func shareCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling PUT request for a calendar ACL entry")
+		entry, err := parseACLEntry(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var req shareCalendarRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logg.Errorf("Failed to decode ACL entry: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		entry.Role = storage.Role(req.Role)
+		if err := application.ShareCalendar(r.Context(), entry); err != nil {
+			logg.Errorf("Failed to share calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Calendar %s shared with %s as %s", entry.CalendarID, entry.UserID, entry.Role)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+func unshareCalendarHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for a calendar ACL entry")
+		entry, err := parseACLEntry(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := application.UnshareCalendar(r.Context(), entry.CalendarID, entry.UserID); err != nil {
+			logg.Errorf("Failed to unshare calendar: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Calendar %s no longer shared with %s", entry.CalendarID, entry.UserID)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func parseACLEntry(r *http.Request) (storage.ACLEntry, error) {
+	var entry storage.ACLEntry
+	var err error
+	if entry.CalendarID, err = parseVarID(r, "id"); err != nil {
+		return entry, err
+	}
+	entry.UserID, err = parseVarID(r, "userId")
+	return entry, err
+}
+
+func parseVarID(r *http.Request, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r)[name])
+	if err != nil {
+		return id, fmt.Errorf("%w: %s: %w", apierror.ErrInvalidArgument, name, err)
+	}
+	return id, nil
+}
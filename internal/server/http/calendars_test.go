@@ -0,0 +1,79 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+)
+
+func TestCalendarSharing(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, app.New(logg, store), store,
+		&auth.HeaderAuthenticator{})
+	serve := func(userID uuid.UUID, method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, bytes.NewBufferString(body))
+		assert.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, userID.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	owner, teammate := uuid.New(), uuid.New()
+
+	rr := serve(owner, http.MethodPost, "/calendars", `{"name":"Team"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var calendar storage.Calendar
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendar))
+	assert.Equal(t, owner, calendar.OwnerID)
+	calendarPath := "/calendars/" + calendar.ID.String()
+
+	rr = serve(owner, http.MethodPost, "/events", `{"Title":"Planning","StartTime":"2024-01-01T10:00:00Z",`+
+		`"EndTime":"2024-01-01T11:00:00Z","CalendarID":"`+calendar.ID.String()+`"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var event storage.Event
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &event))
+
+	assert.Equal(t, http.StatusNotFound, serve(teammate, http.MethodGet, "/events/"+event.ID.String(), "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(teammate, http.MethodGet, calendarPath, "").Code)
+
+	aclPath := calendarPath + "/acl/" + teammate.String()
+	assert.Equal(t, http.StatusBadRequest, serve(owner, http.MethodPut, aclPath, `{"role":"admin"}`).Code)
+	assert.Equal(t, http.StatusOK, serve(owner, http.MethodPut, aclPath, `{"role":"editor"}`).Code)
+
+	assert.Equal(t, http.StatusOK, serve(teammate, http.MethodGet, "/events/"+event.ID.String(), "").Code)
+	rr = serve(teammate, http.MethodGet, "/calendars", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), calendar.ID.String())
+	assert.Equal(t, http.StatusForbidden, serve(teammate, http.MethodDelete, calendarPath, "").Code)
+	rr = serve(teammate, http.MethodGet, "/events?calendar="+calendar.ID.String(), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), event.ID.String())
+	assert.Equal(t, http.StatusBadRequest, serve(teammate, http.MethodGet, "/events?calendar=work", "").Code)
+
+	rr = serve(owner, http.MethodPut, calendarPath, `{"name":"On-call","color":"#ff0000","timeZone":"Europe/Berlin"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendar))
+	assert.Equal(t, "Europe/Berlin", calendar.TimeZone)
+	assert.Equal(t, http.StatusForbidden, serve(teammate, http.MethodPut, calendarPath, `{"name":"Mine"}`).Code)
+
+	rr = serve(owner, http.MethodGet, calendarPath+"/acl", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var entries []storage.ACLEntry
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	assert.Equal(t, []storage.ACLEntry{{CalendarID: calendar.ID, UserID: teammate, Role: storage.RoleEditor}}, entries)
+
+	assert.Equal(t, http.StatusOK, serve(owner, http.MethodDelete, aclPath, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(teammate, http.MethodGet, "/events/"+event.ID.String(), "").Code)
+}
@@ -0,0 +1,143 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	"github.com/gorilla/mux"                                 //nolint
+)
+
+const (
+	// feedPath matches a single feed addressed by its UUID.
+	feedPath = "/feeds/{id:[0-9a-fA-F-]{36}}"
+	// feedURLPath is the public URL of a feed; the token authenticates it.
+	feedURLPath = "/feeds/{token:[^/]+}.ics"
+)
+
+type createFeedRequest struct {
+	// CalendarID is empty for a feed of every calendar the caller can read.
+	CalendarID uuid.UUID `json:"calendarId"`
+	Name       string    `json:"name"`
+}
+
+type feedResponse struct {
+	Feed storage.Feed `json:"feed"`
+	// URL is the path of the feed relative to the server; it cannot be
+	// retrieved again.
+	URL string `json:"url"`
+}
+
+func feedURL(token string) string {
+	return "/feeds/" + token + ".ics"
+}
+
+func createFeedHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for a feed")
+		var req createFeedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logg.Errorf("Failed to decode feed: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		feed, token, err := application.CreateFeed(r.Context(), req.CalendarID, req.Name)
+		if err != nil {
+			logg.Errorf("Failed to create feed: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Feed created: %s", feed.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(feedResponse{Feed: feed, URL: feedURL(token)})
+	}
+}
+
+func listFeedsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing feeds")
+		feeds, err := application.ListFeeds(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to list feeds: %v", err)
+			writeError(w, err)
+			return
+		}
+		if feeds == nil {
+			feeds = []storage.Feed{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(feeds)
+	}
+}
+
+func rotateFeedHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for rotating a feed")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		feed, token, err := application.RotateFeed(r.Context(), id)
+		if err != nil {
+			logg.Errorf("Failed to rotate feed: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Feed rotated: %s", feed.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(feedResponse{Feed: feed, URL: feedURL(token)})
+	}
+}
+
+func deleteFeedHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for a feed")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := application.DeleteFeed(r.Context(), id); err != nil {
+			logg.Errorf("Failed to delete feed: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Feed deleted: %s", id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// serveFeedHandler serves a feed to calendar clients, which poll it without
+// other credentials than its token. Clients may reuse the document for
+// app.FeedMaxAge and revalidate it with If-None-Match after that.
+func serveFeedHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, err := application.RenderFeed(r.Context(), mux.Vars(r)["token"], time.Now())
+		if err != nil {
+			logg.Errorf("Failed to render feed: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(app.FeedMaxAge/time.Second)))
+		w.Header().Set("ETag", document.ETag)
+		if r.Header.Get("If-None-Match") == document.ETag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", icalContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(document.Body)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write(document.Body)
+	}
+}
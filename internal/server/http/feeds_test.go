@@ -0,0 +1,96 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestFeeds(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner, stranger := uuid.New(), uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	standup, err := application.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute)})
+	require.NoError(t, err)
+	serve := func(userID uuid.UUID, method, path, body string, header ...string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		if userID != uuid.Nil {
+			req.Header.Set(auth.UserIDHeader, userID.String())
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) feedResponse {
+		var response feedResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response
+	}
+
+	rr := serve(owner, http.MethodPost, "/feeds",
+		`{"calendarId":"`+standup.CalendarID.String()+`","name":"Phone"}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	created := decode(rr)
+	assert.Equal(t, "Phone", created.Feed.Name)
+	assert.True(t, strings.HasPrefix(created.URL, "/feeds/"+created.Feed.ID.String()+"."), created.URL)
+
+	// The feed needs no other credentials and can be revalidated.
+	rr = serve(uuid.Nil, http.MethodGet, created.URL, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, icalContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "private, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.Contains(t, rr.Body.String(), "SUMMARY:Standup\r\n")
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	rr = serve(uuid.Nil, http.MethodGet, created.URL, "", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = serve(owner, http.MethodGet, "/feeds", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var feeds []storage.Feed
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&feeds))
+	require.Len(t, feeds, 1)
+	assert.Equal(t, created.Feed.ID, feeds[0].ID)
+
+	// Only the owner can rotate; the old URL stops working.
+	feedPath := "/feeds/" + created.Feed.ID.String()
+	assert.Equal(t, http.StatusNotFound, serve(stranger, http.MethodPost, feedPath+"/rotate", "").Code)
+	rr = serve(owner, http.MethodPost, feedPath+"/rotate", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	rotated := decode(rr)
+	assert.NotEqual(t, created.URL, rotated.URL)
+	assert.Equal(t, http.StatusNotFound, serve(uuid.Nil, http.MethodGet, created.URL, "").Code)
+	assert.Equal(t, http.StatusOK, serve(uuid.Nil, http.MethodGet, rotated.URL, "").Code)
+
+	assert.Equal(t, http.StatusOK, serve(owner, http.MethodDelete, feedPath, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(uuid.Nil, http.MethodGet, rotated.URL, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(uuid.Nil, http.MethodGet, "/feeds/garbage.ics", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(uuid.Nil, http.MethodGet, "/feeds", "").Code)
+	assert.Equal(t, http.StatusNotFound,
+		serve(stranger, http.MethodPost, "/feeds", `{"calendarId":"`+standup.CalendarID.String()+`"}`).Code)
+}
@@ -0,0 +1,133 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/ical"            //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+)
+
+// icalContentType is the media type of iCalendar responses.
+const icalContentType = "text/calendar; charset=utf-8"
+
+// maxImportSize bounds the body of POST /events/import.
+const maxImportSize = 10 << 20
+
+// exportEventsHandler serves the caller's events as an iCalendar file. The
+// optional "from" and "to" parameters keep the events overlapping that
+// window; times are written in the "tz" time zone.
+func exportEventsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for exporting events")
+
+		calendarIDs, err := parseCalendarFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		loc, err := application.Location(r.Context(), r.URL.Query().Get("tz"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		var from, to time.Time
+		if r.URL.Query().Has("from") || r.URL.Query().Has("to") {
+			if from, err = parseTimeParam(r, "from", loc); err != nil {
+				writeError(w, err)
+				return
+			}
+			if to, err = parseTimeParam(r, "to", loc); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		events, err := application.ExportEvents(r.Context(), calendarIDs, from, to)
+		if err != nil {
+			logg.Errorf("Failed to export events: %v", err)
+			writeError(w, err)
+			return
+		}
+		writeCalendar(w, logg, loc, events)
+	}
+}
+
+// exportEventHandler serves a single event as an iCalendar file.
+func exportEventHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for exporting an event")
+
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		event, err := application.GetEvent(r.Context(), id)
+		if err != nil {
+			logg.Errorf("Failed to get event: %v", err)
+			writeError(w, err)
+			return
+		}
+		loc, err := application.Location(r.Context(), r.URL.Query().Get("tz"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeCalendar(w, logg, loc, []storage.Event{event})
+	}
+}
+
+func writeCalendar(w http.ResponseWriter, logg *logger.Logger, loc *time.Location, events []storage.Event) {
+	body, err := encodeCalendar(loc, events)
+	if err != nil {
+		logg.Errorf("Failed to encode events to iCalendar: %v", err)
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", icalContentType)
+	w.Write(body)
+}
+
+func encodeCalendar(loc *time.Location, events []storage.Event) ([]byte, error) {
+	var body bytes.Buffer
+	encoder := ical.NewEncoder(&body)
+	encoder.Location = loc
+	if err := encoder.Encode(events); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// importEventsHandler imports the iCalendar file in the request body into
+// the "calendar" calendar, or the caller's default calendar. Floating times
+// are read in the "tz" time zone, by default the calendar's.
+func importEventsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for importing events")
+
+		var calendarID uuid.UUID
+		if value := r.URL.Query().Get("calendar"); value != "" {
+			var err error
+			if calendarID, err = uuid.Parse(value); err != nil {
+				writeError(w, fmt.Errorf("%w: calendar: %w", apierror.ErrInvalidArgument, err))
+				return
+			}
+		}
+		body := http.MaxBytesReader(w, r.Body, maxImportSize)
+		result, err := application.ImportEvents(r.Context(), calendarID, body, r.URL.Query().Get("tz"))
+		if err != nil {
+			logg.Errorf("Failed to import events: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
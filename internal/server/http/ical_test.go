@@ -0,0 +1,112 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestExportEvents(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner, stranger := uuid.New(), uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	standup, err := application.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute), RRule: "FREQ=DAILY"})
+	require.NoError(t, err)
+	party := start.AddDate(0, 1, 0).Add(10 * time.Hour)
+	_, err = application.CreateEvent(ctx, storage.Event{Title: "Party", StartTime: party,
+		EndTime: party.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	serve := func(userID uuid.UUID, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		require.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, userID.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(owner, "/events.ics")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, icalContentType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "SUMMARY:Standup\r\n")
+	assert.Contains(t, rr.Body.String(), "RRULE:FREQ=DAILY\r\n")
+	assert.Contains(t, rr.Body.String(), "SUMMARY:Party\r\n")
+
+	rr = serve(owner, "/events.ics?from=2024-01-10&to=2024-01-11&tz=Europe/Moscow")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "DTSTART;TZID=Europe/Moscow:20240101T120000\r\n")
+	assert.NotContains(t, rr.Body.String(), "Party")
+
+	rr = serve(owner, "/events/"+standup.ID.String()+".ics")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "UID:"+standup.ID.String()+"\r\n")
+	assert.NotContains(t, rr.Body.String(), "Party")
+
+	assert.Equal(t, http.StatusNotFound, serve(stranger, "/events/"+standup.ID.String()+".ics").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(owner, "/events.ics?from=2024-01-10").Code)
+}
+
+func TestImportEvents(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	standup, err := application.CreateEvent(ctx, storage.Event{Title: "Standup", StartTime: start,
+		EndTime: start.Add(15 * time.Minute), RRule: "FREQ=DAILY"})
+	require.NoError(t, err)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, owner.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Re-importing an export matches the events by UID.
+	exported := serve(http.MethodGet, "/events.ics", "").Body.String()
+	rr := serve(http.MethodPost, "/events/import", exported)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var result app.ImportResult
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+	assert.Equal(t, app.ImportResult{Unchanged: 1, Errors: []app.ImportError{}}, result)
+
+	renamed := strings.Replace(exported, "SUMMARY:Standup", "SUMMARY:Daily", 1)
+	rr = serve(http.MethodPost, "/events/import?calendar="+standup.CalendarID.String(), renamed)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+	assert.Equal(t, 1, result.Updated)
+	stored, err := application.GetEvent(ctx, standup.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Daily", stored.Title)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/events/import", "not a calendar").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/events/import?calendar=x", exported).Code)
+	assert.Equal(t, http.StatusNotFound,
+		serve(http.MethodPost, "/events/import?calendar="+uuid.NewString(), exported).Code)
+}
@@ -0,0 +1,198 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+)
+
+// periodLister is one of the App.ListEventsBy* methods.
+type periodLister func(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, timeZone string,
+) ([]storage.Event, error)
+
+// listEventsInRangeHandler lists the events overlapping the window given by
+// the "from" and "to" query parameters. Times without an offset are read in
+// the "tz" time zone, by default the one of the caller's default calendar.
+func listEventsInRangeHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing events in range")
+
+		calendarIDs, err := parseCalendarFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		loc, err := application.Location(r.Context(), r.URL.Query().Get("tz"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		from, err := parseTimeParam(r, "from", loc)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		to, err := parseTimeParam(r, "to", loc)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		events, err := application.ListEventsInRange(r.Context(), calendarIDs, from, to)
+		if err != nil {
+			logg.Errorf("Failed to list events: %v", err)
+			writeError(w, err)
+			return
+		}
+		writeEvents(w, logg, events)
+	}
+}
+
+// listEventsByPeriodHandler lists the events of the day, week or month
+// containing the "date" query parameter (now if absent), cut at midnight in
+// the "tz" time zone.
+func listEventsByPeriodHandler(application *app.App, list periodLister, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing events of ", r.URL.Path)
+
+		calendarIDs, err := parseCalendarFilter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		loc, err := application.Location(r.Context(), r.URL.Query().Get("tz"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		date := time.Now()
+		if r.URL.Query().Has("date") {
+			if date, err = parseTimeParam(r, "date", loc); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		events, err := list(r.Context(), calendarIDs, date, loc.String())
+		if err != nil {
+			logg.Errorf("Failed to list events: %v", err)
+			writeError(w, err)
+			return
+		}
+		writeEvents(w, logg, events)
+	}
+}
+
+// searchEventsHandler finds events by the words of the "q" query parameter,
+// optionally in the given calendars and with at most "limit" results.
+func searchEventsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for searching events")
+
+		query := storage.SearchQuery{Text: r.URL.Query().Get("q")}
+		var err error
+		if query.CalendarIDs, err = parseCalendarFilter(r); err != nil {
+			writeError(w, err)
+			return
+		}
+		if value := r.URL.Query().Get("limit"); value != "" {
+			if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 1 {
+				writeError(w, fmt.Errorf("%w: limit must be a positive integer", apierror.ErrInvalidArgument))
+				return
+			}
+		}
+		results, err := application.SearchEvents(r.Context(), query)
+		if err != nil {
+			logg.Errorf("Failed to search events: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			logg.Errorf("Failed to encode search results to JSON: %v", err)
+		}
+	}
+}
+
+// parseEventFilter reads the GET /events query: calendar (repeatable), user,
+// title, from and to (bounding the start time, read like parseTimeParam in
+// the tz time zone), sort (asc or desc), limit and page_token.
+func parseEventFilter(r *http.Request, application *app.App) (storage.EventFilter, error) {
+	query := r.URL.Query()
+	filter := storage.EventFilter{Title: query.Get("title")}
+	var err error
+	if filter.CalendarIDs, err = parseCalendarFilter(r); err != nil {
+		return filter, err
+	}
+	if value := query.Get("user"); value != "" {
+		if filter.UserID, err = uuid.Parse(value); err != nil {
+			return filter, fmt.Errorf("%w: user: %w", apierror.ErrInvalidArgument, err)
+		}
+	}
+	if query.Has("from") || query.Has("to") {
+		loc, err := application.Location(r.Context(), query.Get("tz"))
+		if err != nil {
+			return filter, err
+		}
+		if query.Has("from") {
+			if filter.From, err = parseTimeParam(r, "from", loc); err != nil {
+				return filter, err
+			}
+		}
+		if query.Has("to") {
+			if filter.To, err = parseTimeParam(r, "to", loc); err != nil {
+				return filter, err
+			}
+		}
+	}
+	switch query.Get("sort") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, fmt.Errorf("%w: sort must be asc or desc", apierror.ErrInvalidArgument)
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			return filter, fmt.Errorf("%w: limit must be a positive integer", apierror.ErrInvalidArgument)
+		}
+	}
+	if token := query.Get("page_token"); token != "" {
+		if filter.After, err = storage.ParseCursor(token); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// localLayouts are the ISO 8601 forms without an offset accepted by
+// parseTimeParam, read as local time in the request's time zone.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// parseTimeParam reads a required query parameter holding Unix seconds or an
+// ISO 8601 time: RFC 3339, or a date or date-time without offset in loc.
+func parseTimeParam(r *http.Request, name string, loc *time.Location) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: %s is required", apierror.ErrInvalidArgument, name)
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %s: expected ISO 8601 or Unix time", apierror.ErrInvalidArgument, name)
+}
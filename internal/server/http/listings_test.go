@@ -0,0 +1,161 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestListEventsByPeriod(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	userID := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
+
+	calendar, err := application.CreateCalendar(ctx, storage.Calendar{Name: "Home", TimeZone: "America/New_York"})
+	require.NoError(t, err)
+	// Tuesday 2 January 2024, 02:00 UTC is still Monday evening in New York.
+	start := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)
+	_, err = application.CreateEvent(ctx, storage.Event{Title: "Dinner", StartTime: start,
+		EndTime: start.Add(time.Hour), CalendarID: calendar.ID})
+	require.NoError(t, err)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantCount  int
+	}{
+		{"/events/day?date=2024-01-01", http.StatusOK, 1},
+		{"/events/day?date=2024-01-02", http.StatusOK, 0},
+		{"/events/day?date=2024-01-02&tz=UTC", http.StatusOK, 1},
+		{"/events/day?date=1704160800&tz=Europe/Moscow", http.StatusOK, 1},
+		{"/events/day?date=2024-01-01T23:30:00-05:00", http.StatusOK, 1},
+		{"/events/week?date=2024-01-07", http.StatusOK, 1},
+		{"/events/week?date=2024-01-01&tz=Asia/Tokyo", http.StatusOK, 1},
+		{"/events/month?date=2023-12-31T23:00:00", http.StatusOK, 0},
+		{"/events/month?date=2024-01-31&calendar=" + calendar.ID.String(), http.StatusOK, 1},
+		{"/events/range?from=2024-01-01T21:00&to=2024-01-01T22:00", http.StatusOK, 1},
+		{"/events/range?from=2024-01-02&to=2024-01-03&tz=UTC", http.StatusOK, 1},
+		{"/events/day?date=01.01.2024", http.StatusBadRequest, 0},
+		{"/events/week?tz=Mars/Olympus", http.StatusBadRequest, 0},
+		{"/events/range?from=2024-01-01", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, tt.query, nil)
+			require.NoError(t, err)
+			req.Header.Set(auth.UserIDHeader, userID.String())
+			rr := httptest.NewRecorder()
+			server.httpServer.Handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var events []storage.Event
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+			assert.Len(t, events, tt.wantCount)
+		})
+	}
+}
+
+func TestListEventsHandler_Pagination(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	userID := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		_, err := application.CreateEvent(ctx, storage.Event{Title: "Slot", StartTime: at,
+			EndTime: at.Add(30 * time.Minute)})
+		require.NoError(t, err)
+	}
+	serve := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events?"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, userID.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var starts []time.Time
+	query := "sort=desc&limit=2&from=2024-01-01T10:00:00Z"
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		rr := serve(query)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var events []storage.Event
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+		for _, event := range events {
+			starts = append(starts, event.StartTime)
+		}
+		token := rr.Header().Get(nextPageTokenHeader)
+		if token == "" {
+			break
+		}
+		query = "sort=desc&limit=2&from=2024-01-01T10:00:00Z&page_token=" + token
+	}
+	require.Len(t, starts, 4)
+	assert.True(t, starts[0].Equal(start.Add(4*time.Hour)))
+	assert.True(t, starts[3].Equal(start.Add(time.Hour)))
+
+	for _, query := range []string{"sort=up", "limit=0", "page_token=%21", "user=me"} {
+		assert.Equal(t, http.StatusBadRequest, serve(query).Code, query)
+	}
+}
+
+func TestSearchEventsHandler(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner, stranger := uuid.New(), uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	_, err := application.CreateEvent(ctx, storage.Event{Title: "Q3 budget review", StartTime: start,
+		EndTime: start.Add(time.Hour)})
+	require.NoError(t, err)
+	serve := func(userID uuid.UUID, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events/search?"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, userID.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(owner, "q=budget+Q3")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var results []storage.SearchResult
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "Q3 budget review", results[0].Event.Title)
+	assert.Equal(t, "<b>Q3</b> <b>budget</b> review", results[0].Snippet)
+
+	assert.JSONEq(t, "[]", serve(stranger, "q=budget").Body.String())
+	assert.Equal(t, http.StatusBadRequest, serve(owner, "q=%3F%21").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(owner, "q=budget&limit=-1").Code)
+}
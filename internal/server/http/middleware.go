@@ -2,8 +2,10 @@ package internalhttp
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Dendyator/calendar/internal/auth"   //nolint:depguard
 	"github.com/Dendyator/calendar/internal/logger" //nolint:depguard
 )
 
@@ -45,3 +47,57 @@ func loggingMiddleware(logg *logger.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// readMethods only need the events:read scope; every other method needs
+// events:write.
+var readMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	methodPropfind:     true,
+	methodReport:       true,
+}
+
+// authMiddleware rejects requests the authenticator cannot identify or
+// whose API key lacks the scope of the method, and stores the caller's
+// identity in the request context. An API key can also be sent as the
+// password of HTTP Basic authentication, which is what CalDAV clients use.
+func authMiddleware(authn auth.Authenticator, logg *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authn.Authenticate(r.Context(), credentials(r))
+			if err != nil {
+				logg.Errorf("Failed to authenticate request: %v", err)
+				if strings.HasPrefix(r.URL.Path, davRoot) {
+					w.Header().Set("WWW-Authenticate", `Basic realm="calendar"`)
+				}
+				writeError(w, err)
+				return
+			}
+			ctx := auth.WithIdentity(r.Context(), identity)
+			scope := auth.ScopeEventsWrite
+			if readMethods[r.Method] {
+				scope = auth.ScopeEventsRead
+			}
+			if err := auth.RequireScope(ctx, scope); err != nil {
+				logg.Errorf("Refused request: %v", err)
+				writeError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// credentials reads the request headers, taking the API key from the Basic
+// authentication password when there is no API key header.
+func credentials(r *http.Request) auth.Credentials {
+	return func(name string) string {
+		value := r.Header.Get(name)
+		if name == auth.APIKeyHeader && value == "" {
+			_, password, _ := r.BasicAuth()
+			return password
+		}
+		return value
+	}
+}
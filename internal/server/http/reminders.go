@@ -0,0 +1,55 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+)
+
+// remindersPath holds the caller's default reminders.
+const remindersPath = "/settings/reminders"
+
+type remindersBody struct {
+	// Reminders are Go durations such as "15m"; null restores the fallback
+	// of one day and an empty list turns reminders off.
+	Reminders storage.Durations `json:"reminders"`
+}
+
+func getRemindersHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for default reminders")
+		reminders, err := application.DefaultReminders(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to get default reminders: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(remindersBody{Reminders: reminders})
+	}
+}
+
+func setRemindersHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling PUT request for default reminders")
+		var body remindersBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			logg.Errorf("Failed to decode reminders: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		reminders, err := application.SetDefaultReminders(r.Context(), body.Reminders)
+		if err != nil {
+			logg.Errorf("Failed to set default reminders: %v", err)
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(remindersBody{Reminders: reminders})
+	}
+}
@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestReminders(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, application, store,
+		&auth.HeaderAuthenticator{})
+	owner := uuid.New()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(auth.UserIDHeader, owner.String())
+		rr := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(http.MethodGet, remindersPath, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"reminders":["24h0m0s"]}`, rr.Body.String())
+
+	rr = serve(http.MethodPut, remindersPath, `{"reminders":["10m","1h"]}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"reminders":["10m0s","1h0m0s"]}`, rr.Body.String())
+	stored, err := store.GetDefaultReminders(context.Background(), owner)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Durations{10 * time.Minute, time.Hour}, stored)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, remindersPath, `{"reminders":["90s"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, remindersPath, `{"reminders":[60]}`).Code)
+	rr = serve(http.MethodPut, remindersPath, `{"reminders":null}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"reminders":["24h0m0s"]}`, rr.Body.String())
+
+	// Events carry their own reminders; invalid ones are rejected.
+	rr = serve(http.MethodPost, "/events", `{"Title":"Standup","StartTime":"2024-01-01T09:00:00Z",`+
+		`"EndTime":"2024-01-01T09:15:00Z","Reminders":["5m"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var event storage.Event
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&event))
+	assert.Equal(t, storage.Durations{5 * time.Minute}, event.Reminders)
+	rr = serve(http.MethodPost, "/events", `{"Title":"Standup","StartTime":"2024-01-02T09:00:00Z",`+
+		`"EndTime":"2024-01-02T09:15:00Z","Reminders":["-5m"]}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -3,16 +3,27 @@ package internalhttp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"time"
 
-	"github.com/Dendyator/calendar/internal/logger"  //nolint
-	"github.com/Dendyator/calendar/internal/storage" //nolint
-	"github.com/google/uuid"                         //nolint
-	"github.com/gorilla/mux"                         //nolint
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/auth"            //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+	"github.com/google/uuid"                                 //nolint
+	"github.com/gorilla/mux"                                 //nolint
 )
 
+// eventPath matches a single event addressed by its UUID.
+const eventPath = "/events/{id:[0-9a-fA-F-]{36}}"
+
+// nextPageTokenHeader carries the page_token of the next page of GET /events;
+// it is absent on the last page.
+const nextPageTokenHeader = "X-Next-Page-Token"
+
 type Server struct {
 	httpServer *http.Server
 }
@@ -22,20 +33,59 @@ type ServerConfig struct {
 	Port string
 }
 
-func NewServer(cfg ServerConfig, logg *logger.Logger, store storage.Interface) *Server {
-	router := mux.NewRouter()
-
+func NewServer(cfg ServerConfig, logg *logger.Logger, application *app.App, keys storage.APIKeyInterface,
+	authn auth.Authenticator,
+) *Server {
+	root := mux.NewRouter()
 	logg.Info("Setting up routes...")
-	router.HandleFunc("/events", listEventsHandler(store, logg)).Methods(http.MethodGet)
-	router.HandleFunc("/events", createEventHandler(store, logg)).Methods(http.MethodPost)
-	router.HandleFunc("/events/{id:[0-9]+}", getEventHandler(store, logg)).Methods(http.MethodGet)
-	router.HandleFunc("/events/{id:[0-9]+}", updateEventHandler(store, logg)).Methods(http.MethodPut)
-	router.HandleFunc("/events/{id:[0-9]+}", deleteEventHandler(store, logg)).Methods(http.MethodDelete)
+	// Feeds are fetched by calendar clients that only know the secret URL.
+	root.HandleFunc(feedURLPath, serveFeedHandler(application, logg)).Methods(http.MethodGet, http.MethodHead)
+
+	router := root.PathPrefix("/").Subrouter()
+	router.Use(authMiddleware(authn, logg))
+	router.HandleFunc("/admin/events", listAllEventsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/api-keys", listAPIKeysHandler(keys, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/admin/api-keys", createAPIKeyHandler(keys, logg)).Methods(http.MethodPost)
+	router.HandleFunc(apiKeyPath, deleteAPIKeyHandler(keys, logg)).Methods(http.MethodDelete)
+	router.HandleFunc("/events", listEventsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/events", createEventHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc("/events.ics", exportEventsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/events/import", importEventsHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc(eventPath+".ics", exportEventHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/events/search", searchEventsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/events/range", listEventsInRangeHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/events/day", listEventsByPeriodHandler(application, application.ListEventsByDay, logg)).
+		Methods(http.MethodGet)
+	router.HandleFunc("/events/week", listEventsByPeriodHandler(application, application.ListEventsByWeek, logg)).
+		Methods(http.MethodGet)
+	router.HandleFunc("/events/month", listEventsByPeriodHandler(application, application.ListEventsByMonth, logg)).
+		Methods(http.MethodGet)
+	router.HandleFunc(eventPath, getEventHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc(eventPath, updateEventHandler(application, logg)).Methods(http.MethodPut)
+	router.HandleFunc(eventPath, deleteEventHandler(application, logg)).Methods(http.MethodDelete)
+	router.HandleFunc("/calendars", listCalendarsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/calendars", createCalendarHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc(calendarPath, getCalendarHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc(calendarPath, updateCalendarHandler(application, logg)).Methods(http.MethodPut)
+	router.HandleFunc(calendarPath, deleteCalendarHandler(application, logg)).Methods(http.MethodDelete)
+	router.HandleFunc(calendarPath+"/acl", listACLHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc(aclPath, shareCalendarHandler(application, logg)).Methods(http.MethodPut)
+	router.HandleFunc(aclPath, unshareCalendarHandler(application, logg)).Methods(http.MethodDelete)
+	router.HandleFunc("/feeds", listFeedsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/feeds", createFeedHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc(feedPath+"/rotate", rotateFeedHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc(feedPath, deleteFeedHandler(application, logg)).Methods(http.MethodDelete)
+	router.HandleFunc("/subscriptions", listSubscriptionsHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc("/subscriptions", createSubscriptionHandler(application, logg)).Methods(http.MethodPost)
+	router.HandleFunc(subscriptionPath, deleteSubscriptionHandler(application, logg)).Methods(http.MethodDelete)
+	router.HandleFunc(remindersPath, getRemindersHandler(application, logg)).Methods(http.MethodGet)
+	router.HandleFunc(remindersPath, setRemindersHandler(application, logg)).Methods(http.MethodPut)
+	registerCalDAV(router, application, logg)
 	logg.Info("Routes set up completed!")
 
 	srv := &http.Server{
 		Addr:              net.JoinHostPort(cfg.Host, cfg.Port),
-		Handler:           loggingMiddleware(logg)(router),
+		Handler:           loggingMiddleware(logg)(root),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 	return &Server{
@@ -55,66 +105,96 @@ func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
 
-func listEventsHandler(store storage.Interface, logg *logger.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
+func listEventsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		logg.Info("Handling GET request for listing events")
 
-		events, err := store.ListEvents()
+		filter, err := parseEventFilter(r, application)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		page, err := application.ListEvents(r.Context(), filter)
 		if err != nil {
 			logg.Errorf("Failed to list events: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
+		if page.Next != nil {
+			w.Header().Set(nextPageTokenHeader, page.Next.Token())
+		}
+		writeEvents(w, logg, page.Events)
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
+// listAllEventsHandler lists the events of every user; admins only.
+func listAllEventsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing events of all users")
 
-		if len(events) == 0 {
-			logg.Info("No events found")
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("[]"))
+		events, err := application.ListAllEvents(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to list events: %v", err)
+			writeError(w, err)
 			return
 		}
+		writeEvents(w, logg, events)
+	}
+}
 
-		err = json.NewEncoder(w).Encode(events)
-		if err != nil {
-			logg.Errorf("Failed to encode events to JSON: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
-		logg.Info("Events successfully listed.")
+func writeEvents(w http.ResponseWriter, logg *logger.Logger, events []storage.Event) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if len(events) == 0 {
+		logg.Info("No events found")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("[]"))
+		return
+	}
+
+	err := json.NewEncoder(w).Encode(events)
+	if err != nil {
+		logg.Errorf("Failed to encode events to JSON: %v", err)
+		writeError(w, err)
 	}
+	logg.Info("Events successfully listed.")
 }
 
-func createEventHandler(store storage.Interface, logg *logger.Logger) http.HandlerFunc {
+func createEventHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logg.Infof("Handling POST request")
 		var event storage.Event
 		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
 			logg.Errorf("Failed to decode event: %v", err)
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
 			return
 		}
-		if err := store.CreateEvent(event); err != nil {
+		event, err := application.CreateEvent(r.Context(), event)
+		if err != nil {
 			logg.Errorf("Failed to create event: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, err)
 			return
 		}
 		logg.Infof("Event created: %s", event.ID)
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(event)
 	}
 }
 
-func getEventHandler(store storage.Interface, logg *logger.Logger) http.HandlerFunc {
+func getEventHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logg.Infof("Handling GET request for a single event")
-		id := r.URL.Path[len("/events/"):]
-		parse, err := uuid.Parse(id)
+		id, err := parseEventID(r)
 		if err != nil {
+			logg.Errorf("Failed to parse event ID: %v", err)
+			writeError(w, err)
 			return
 		}
-		event, err := store.GetEvent(parse)
+		event, err := application.GetEvent(r.Context(), id)
 		if err != nil {
 			logg.Errorf("Failed to get event: %v", err)
-			http.Error(w, "Event not found", http.StatusNotFound)
+			writeError(w, err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -122,23 +202,30 @@ func getEventHandler(store storage.Interface, logg *logger.Logger) http.HandlerF
 	}
 }
 
-func updateEventHandler(store storage.Interface, logg *logger.Logger) http.HandlerFunc {
+func updateEventHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logg.Infof("Handling PUT request")
-		id := r.URL.Path[len("/events/"):]
-		parse, err := uuid.Parse(id)
+		id, err := parseEventID(r)
 		if err != nil {
+			logg.Errorf("Failed to parse event ID: %v", err)
+			writeError(w, err)
+			return
+		}
+		scope, recurrenceID, err := parseScope(r)
+		if err != nil {
+			logg.Errorf("Failed to parse scope: %v", err)
+			writeError(w, err)
 			return
 		}
 		var event storage.Event
 		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
 			logg.Errorf("Failed to decode event: %v", err)
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
 			return
 		}
-		if err := store.UpdateEvent(parse, event); err != nil {
+		if err := application.UpdateEvent(r.Context(), id, event, scope, recurrenceID); err != nil {
 			logg.Errorf("Failed to update event: %v", err)
-			http.Error(w, "Event not found", http.StatusNotFound)
+			writeError(w, err)
 			return
 		}
 		logg.Infof("Event updated: %s", id)
@@ -146,20 +233,91 @@ func updateEventHandler(store storage.Interface, logg *logger.Logger) http.Handl
 	}
 }
 
-func deleteEventHandler(store storage.Interface, logg *logger.Logger) http.HandlerFunc {
+func deleteEventHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logg.Infof("Handling DELETE request")
-		id := r.URL.Path[len("/events/"):]
-		parse, err := uuid.Parse(id)
+		id, err := parseEventID(r)
 		if err != nil {
+			logg.Errorf("Failed to parse event ID: %v", err)
+			writeError(w, err)
 			return
 		}
-		if err := store.DeleteEvent(parse); err != nil {
+		scope, recurrenceID, err := parseScope(r)
+		if err != nil {
+			logg.Errorf("Failed to parse scope: %v", err)
+			writeError(w, err)
+			return
+		}
+		if err := application.DeleteEvent(r.Context(), id, scope, recurrenceID); err != nil {
 			logg.Errorf("Failed to delete event: %v", err)
-			http.Error(w, "Event not found", http.StatusNotFound)
+			writeError(w, err)
 			return
 		}
 		logg.Infof("Event deleted: %s", id)
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError reports err to the client with the status code and reason from
+// the shared apierror mapping.
+func writeError(w http.ResponseWriter, err error) {
+	mapping := apierror.Lookup(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(mapping.HTTPStatus)
+	json.NewEncoder(w).Encode(errorResponse{Error: errorBody{
+		Code:    mapping.Reason,
+		Message: apierror.Message(err),
+	}})
+}
+
+func parseEventID(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(r.URL.Path[len("/events/"):])
+	if err != nil {
+		return id, fmt.Errorf("%w: event ID: %w", apierror.ErrInvalidArgument, err)
+	}
+	return id, nil
+}
+
+// parseCalendarFilter reads the repeatable "calendar" query parameter that
+// restricts a listing to the given calendars.
+func parseCalendarFilter(r *http.Request) ([]uuid.UUID, error) {
+	values := r.URL.Query()["calendar"]
+	ids := make([]uuid.UUID, len(values))
+	for i, value := range values {
+		var err error
+		if ids[i], err = uuid.Parse(value); err != nil {
+			return nil, fmt.Errorf("%w: calendar: %w", apierror.ErrInvalidArgument, err)
+		}
+	}
+	return ids, nil
+}
+
+// parseScope reads the "scope" (all, this, following) and "recurrence_id"
+// (RFC 3339 start of the occurrence) query parameters of a recurring edit.
+func parseScope(r *http.Request) (storage.Scope, time.Time, error) {
+	scope, err := storage.ParseScope(r.URL.Query().Get("scope"))
+	if err != nil {
+		return scope, time.Time{}, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err)
+	}
+	var recurrenceID time.Time
+	if value := r.URL.Query().Get("recurrence_id"); value != "" {
+		recurrenceID, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return scope, recurrenceID, fmt.Errorf("%w: recurrence_id: %w", apierror.ErrInvalidArgument, err)
+		}
+	}
+	if scope != storage.ScopeAll && recurrenceID.IsZero() {
+		return scope, recurrenceID, fmt.Errorf("%w: recurrence_id is required for this scope",
+			apierror.ErrInvalidArgument)
+	}
+	return scope, recurrenceID, nil
+}
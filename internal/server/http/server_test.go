@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/auth"    //nolint
 	"github.com/Dendyator/calendar/internal/logger"  //nolint
 	"github.com/Dendyator/calendar/internal/storage" //nolint
 	"github.com/google/uuid"                         //nolint
@@ -20,61 +23,228 @@ type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) DeleteOldEvents(before time.Time) error {
-	args := m.Called(before)
+func (m *MockStorage) DeleteOldEvents(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
 	return args.Error(0)
 }
 
-func (m *MockStorage) ListEventsByDay(date time.Time) ([]storage.Event, error) {
-	args := m.Called(date)
+func (m *MockStorage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]storage.SearchResult), args.Error(1)
+}
+
+func (m *MockStorage) CreateFeed(ctx context.Context, feed storage.Feed) error {
+	args := m.Called(ctx, feed)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetFeed(ctx context.Context, id uuid.UUID) (storage.Feed, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Feed), args.Error(1)
+}
+
+func (m *MockStorage) ListFeeds(ctx context.Context, userID uuid.UUID) ([]storage.Feed, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Feed), args.Error(1)
+}
+
+func (m *MockStorage) UpdateFeedSecret(ctx context.Context, id uuid.UUID, secretHash string) error {
+	args := m.Called(ctx, id, secretHash)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CreateSubscription(ctx context.Context, subscription storage.Subscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetSubscription(ctx context.Context, id uuid.UUID) (storage.Subscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]storage.Subscription, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) ListDueSubscriptions(ctx context.Context, now time.Time) ([]storage.Subscription, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]storage.Subscription), args.Error(1)
+}
+
+func (m *MockStorage) UpdateSubscriptionState(ctx context.Context, id uuid.UUID, state storage.SubscriptionState,
+) error {
+	args := m.Called(ctx, id, state)
+	return args.Error(0)
+}
+
+func (m *MockStorage) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetDefaultReminders(ctx context.Context, userID uuid.UUID) (storage.Durations, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(storage.Durations), args.Error(1)
+}
+
+func (m *MockStorage) SetDefaultReminders(ctx context.Context, userID uuid.UUID, reminders storage.Durations) error {
+	args := m.Called(ctx, userID, reminders)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ListDefaultReminders(ctx context.Context) (map[uuid.UUID]storage.Durations, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[uuid.UUID]storage.Durations), args.Error(1)
+}
+
+func (m *MockStorage) ListEventsInRange(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, from, to)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEventsByWeek(start time.Time) ([]storage.Event, error) {
-	args := m.Called(start)
+func (m *MockStorage) ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, date, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEventsByMonth(start time.Time) ([]storage.Event, error) {
-	args := m.Called(start)
+func (m *MockStorage) ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, start time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, start, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) ListEvents() ([]storage.Event, error) {
-	args := m.Called()
+func (m *MockStorage) ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, start time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	args := m.Called(ctx, calendarIDs, start, loc)
 	return args.Get(0).([]storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) CreateEvent(event storage.Event) error {
-	return m.Called(event).Error(0)
+func (m *MockStorage) ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(storage.EventPage), args.Error(1)
+}
+
+func (m *MockStorage) ListAllEvents(ctx context.Context) ([]storage.Event, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]storage.Event), args.Error(1)
+}
+
+func (m *MockStorage) CreateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	return m.Called(ctx, calendar).Error(0)
+}
+
+func (m *MockStorage) GetCalendar(ctx context.Context, id uuid.UUID) (storage.Calendar, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(storage.Calendar), args.Error(1)
+}
+
+func (m *MockStorage) UpdateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	return m.Called(ctx, calendar).Error(0)
+}
+
+func (m *MockStorage) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockStorage) ListCalendars(ctx context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]storage.Calendar), args.Error(1)
+}
+
+func (m *MockStorage) GetRole(ctx context.Context, calendarID, userID uuid.UUID) (storage.Role, error) {
+	args := m.Called(ctx, calendarID, userID)
+	return args.Get(0).(storage.Role), args.Error(1)
+}
+
+func (m *MockStorage) SetACL(ctx context.Context, entry storage.ACLEntry) error {
+	return m.Called(ctx, entry).Error(0)
 }
 
-func (m *MockStorage) GetEvent(id uuid.UUID) (storage.Event, error) {
-	args := m.Called(id)
+func (m *MockStorage) DeleteACL(ctx context.Context, calendarID, userID uuid.UUID) error {
+	return m.Called(ctx, calendarID, userID).Error(0)
+}
+
+func (m *MockStorage) ListACL(ctx context.Context, calendarID uuid.UUID) ([]storage.ACLEntry, error) {
+	args := m.Called(ctx, calendarID)
+	return args.Get(0).([]storage.ACLEntry), args.Error(1)
+}
+
+// ownCalendar makes userID the owner of a new calendar in the mocked
+// storage and returns its ID.
+func ownCalendar(m *MockStorage, userID uuid.UUID) uuid.UUID {
+	calendarID := uuid.New()
+	m.On("ListCalendars", mock.Anything, userID).
+		Return([]storage.Calendar{{ID: calendarID, OwnerID: userID}}, nil).Maybe()
+	m.On("GetRole", mock.Anything, calendarID, userID).Return(storage.RoleOwner, nil).Maybe()
+	return calendarID
+}
+
+// asUser returns a request context authenticated as userID.
+func asUser(userID uuid.UUID) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{UserID: userID})
+}
+
+func (m *MockStorage) CreateEvent(ctx context.Context, event storage.Event) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *MockStorage) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) UpdateEvent(id uuid.UUID, event storage.Event) error {
-	return m.Called(id, event).Error(0)
+func (m *MockStorage) GetEventByUID(ctx context.Context, calendarID uuid.UUID, uid string) (storage.Event, error) {
+	args := m.Called(ctx, calendarID, uid)
+	return args.Get(0).(storage.Event), args.Error(1)
 }
 
-func (m *MockStorage) DeleteEvent(id uuid.UUID) error {
-	return m.Called(id).Error(0)
+func (m *MockStorage) ReplaceResource(ctx context.Context, calendarID uuid.UUID, name string,
+	plan func(current []storage.Event) (storage.SeriesChange, error),
+) error {
+	return m.Called(ctx, calendarID, name, plan).Error(0)
+}
+
+func (m *MockStorage) UpdateEvent(ctx context.Context, id uuid.UUID, event storage.Event, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	return m.Called(ctx, id, event, scope, recurrenceID).Error(0)
+}
+
+func (m *MockStorage) DeleteEvent(ctx context.Context, id uuid.UUID, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	return m.Called(ctx, id, scope, recurrenceID).Error(0)
 }
 
 func TestListEventsHandler_EmptyList(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, mockStorage)
+	NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, app.New(logg, mockStorage), nil,
+		&auth.HeaderAuthenticator{})
 
-	mockStorage.On("ListEvents").Return([]storage.Event{}, nil)
+	userID := uuid.New()
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("ListEvents", mock.Anything, storage.EventFilter{CalendarIDs: []uuid.UUID{calendarID}, Limit: 50}).
+		Return(storage.EventPage{Events: []storage.Event{}}, nil)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events", nil)
+	req, err := http.NewRequestWithContext(asUser(userID), http.MethodGet, "/events", nil)
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := listEventsHandler(mockStorage, logg)
+	handler := listEventsHandler(app.New(logg, mockStorage), logg)
 
 	handler.ServeHTTP(rr, req)
 
@@ -92,7 +262,8 @@ func TestListEventsHandler_WithEvents(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
 
-	NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, mockStorage)
+	NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, app.New(logg, mockStorage), nil,
+		&auth.HeaderAuthenticator{})
 
 	event := storage.Event{
 		ID:          uuid.New(),
@@ -103,13 +274,15 @@ func TestListEventsHandler_WithEvents(t *testing.T) {
 		UserID:      uuid.New(),
 	}
 
-	mockStorage.On("ListEvents").Return([]storage.Event{event}, nil)
+	calendarID := ownCalendar(mockStorage, event.UserID)
+	mockStorage.On("ListEvents", mock.Anything, storage.EventFilter{CalendarIDs: []uuid.UUID{calendarID}, Limit: 50}).
+		Return(storage.EventPage{Events: []storage.Event{event}}, nil)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events", nil)
+	req, err := http.NewRequestWithContext(asUser(event.UserID), http.MethodGet, "/events", nil)
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := listEventsHandler(mockStorage, logg)
+	handler := listEventsHandler(app.New(logg, mockStorage), logg)
 
 	handler.ServeHTTP(rr, req)
 
@@ -138,23 +311,54 @@ func TestCreateEventHandler(t *testing.T) {
 		EndTime:     fixedTime.Add(1 * time.Hour),
 		UserID:      uuid.New(),
 	}
+	event.CalendarID = ownCalendar(mockStorage, event.UserID)
 
 	eventJSON, _ := json.Marshal(event)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+	req, err := http.NewRequestWithContext(asUser(event.UserID), http.MethodPost,
 		"/events", bytes.NewBuffer(eventJSON))
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := createEventHandler(mockStorage, logg)
+	handler := createEventHandler(app.New(logg, mockStorage), logg)
 
-	mockStorage.On("CreateEvent", event).Return(nil)
+	mockStorage.On("CreateEvent", mock.Anything, event).Return(nil)
 
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusCreated, rr.Code)
 }
 
+func TestCreateEventHandler_DateBusy(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+	fixedTime := time.Date(2024, 11, 11, 13, 4, 0, 0, time.UTC)
+
+	event := storage.Event{
+		ID:        uuid.New(),
+		Title:     "Overlapping Event",
+		StartTime: fixedTime,
+		EndTime:   fixedTime.Add(1 * time.Hour),
+		UserID:    uuid.New(),
+	}
+	event.CalendarID = ownCalendar(mockStorage, event.UserID)
+
+	eventJSON, _ := json.Marshal(event)
+
+	req, err := http.NewRequestWithContext(asUser(event.UserID), http.MethodPost,
+		"/events", bytes.NewBuffer(eventJSON))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := createEventHandler(app.New(logg, mockStorage), logg)
+
+	mockStorage.On("CreateEvent", mock.Anything, event).Return(storage.ErrDateBusy)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
 func TestGetEventHandler(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
@@ -167,15 +371,16 @@ func TestGetEventHandler(t *testing.T) {
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
 	}
+	event.CalendarID = ownCalendar(mockStorage, event.UserID)
 
-	mockStorage.On("GetEvent", event.ID).Return(event, nil)
+	mockStorage.On("GetEvent", mock.Anything, event.ID).Return(event, nil)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+	req, err := http.NewRequestWithContext(asUser(event.UserID), http.MethodGet,
 		"/events/"+event.ID.String(), nil)
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := getEventHandler(mockStorage, logg)
+	handler := getEventHandler(app.New(logg, mockStorage), logg)
 
 	handler.ServeHTTP(rr, req)
 
@@ -187,6 +392,47 @@ func TestGetEventHandler(t *testing.T) {
 	assert.Equal(t, event.ID, returnedEvent.ID)
 }
 
+func TestGetEventHandler_NotFound(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	eventID := uuid.New()
+	mockStorage.On("GetEvent", mock.Anything, eventID).Return(storage.Event{}, storage.ErrNotFound)
+
+	req, err := http.NewRequestWithContext(asUser(uuid.New()), http.MethodGet,
+		"/events/"+eventID.String(), nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := getEventHandler(app.New(logg, mockStorage), logg)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var body errorResponse
+	err = json.Unmarshal(rr.Body.Bytes(), &body)
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT_FOUND", body.Error.Code)
+	assert.Equal(t, "event not found", body.Error.Message)
+}
+
+func TestGetEventHandler_InvalidID(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events/not-a-uuid", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler := getEventHandler(app.New(logg, mockStorage), logg)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockStorage.AssertNotCalled(t, "GetEvent", mock.Anything)
+}
+
 func TestUpdateEventHandler(t *testing.T) {
 	mockStorage := new(MockStorage)
 	logg := logger.New("info")
@@ -200,17 +446,19 @@ func TestUpdateEventHandler(t *testing.T) {
 		EndTime:     fixedTime.Add(1 * time.Hour),
 		UserID:      uuid.New(),
 	}
+	event.CalendarID = ownCalendar(mockStorage, event.UserID)
 
 	eventJSON, _ := json.Marshal(event)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut,
+	req, err := http.NewRequestWithContext(asUser(event.UserID), http.MethodPut,
 		"/events/"+event.ID.String(), bytes.NewBuffer(eventJSON))
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := updateEventHandler(mockStorage, logg)
+	handler := updateEventHandler(app.New(logg, mockStorage), logg)
 
-	mockStorage.On("UpdateEvent", event.ID, event).Return(nil)
+	mockStorage.On("GetEvent", mock.Anything, event.ID).Return(event, nil)
+	mockStorage.On("UpdateEvent", mock.Anything, event.ID, event, storage.ScopeAll, time.Time{}).Return(nil)
 
 	handler.ServeHTTP(rr, req)
 
@@ -222,17 +470,149 @@ func TestDeleteEventHandler(t *testing.T) {
 	logg := logger.New("info")
 
 	eventID := uuid.New()
+	userID := uuid.New()
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete,
+	req, err := http.NewRequestWithContext(asUser(userID), http.MethodDelete,
 		"/events/"+eventID.String(), nil)
 	assert.NoError(t, err)
 
 	rr := httptest.NewRecorder()
-	handler := deleteEventHandler(mockStorage, logg)
+	handler := deleteEventHandler(app.New(logg, mockStorage), logg)
+
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("GetEvent", mock.Anything, eventID).
+		Return(storage.Event{ID: eventID, UserID: userID, CalendarID: calendarID}, nil)
+	mockStorage.On("DeleteEvent", mock.Anything, eventID, storage.ScopeAll, time.Time{}).Return(nil)
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestGetEventHandler_OtherUser(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	event := storage.Event{ID: uuid.New(), Title: "Private", UserID: uuid.New(), CalendarID: uuid.New()}
+	caller := uuid.New()
+	mockStorage.On("GetEvent", mock.Anything, event.ID).Return(event, nil)
+	mockStorage.On("GetRole", mock.Anything, event.CalendarID, caller).Return(storage.RoleNone, nil)
+
+	req, err := http.NewRequestWithContext(asUser(caller), http.MethodGet,
+		"/events/"+event.ID.String(), nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	getEventHandler(app.New(logg, mockStorage), logg).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestListAllEventsHandler(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+	mockStorage.On("ListAllEvents", mock.Anything).Return([]storage.Event{{ID: uuid.New()}}, nil)
+
+	req, err := http.NewRequestWithContext(asUser(uuid.New()), http.MethodGet, "/admin/events", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	listAllEventsHandler(app.New(logg, mockStorage), logg).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	admin := auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New(), Admin: true})
+	req, err = http.NewRequestWithContext(admin, http.MethodGet, "/admin/events", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	listAllEventsHandler(app.New(logg, mockStorage), logg).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestServer_RequiresUser(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+	server := NewServer(ServerConfig{Host: "localhost", Port: "8080"}, logg, app.New(logg, mockStorage), nil,
+		&auth.HeaderAuthenticator{})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "/events", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	userID := uuid.New()
+	calendarID := ownCalendar(mockStorage, userID)
+	mockStorage.On("ListEvents", mock.Anything, storage.EventFilter{CalendarIDs: []uuid.UUID{calendarID}, Limit: 50}).
+		Return(storage.EventPage{Events: []storage.Event{}}, nil)
+	req.Header.Set(auth.UserIDHeader, userID.String())
+	rr = httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
 
-	mockStorage.On("DeleteEvent", eventID).Return(nil)
+func TestCreateEventHandler_OwnerFromIdentity(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+	fixedTime := time.Date(2024, 11, 11, 13, 4, 0, 0, time.UTC)
 
+	event := storage.Event{
+		ID:        uuid.New(),
+		Title:     "Someone else's event",
+		StartTime: fixedTime,
+		EndTime:   fixedTime.Add(1 * time.Hour),
+		UserID:    uuid.New(),
+	}
+	eventJSON, _ := json.Marshal(event)
+
+	caller := uuid.New()
+	req, err := http.NewRequestWithContext(asUser(caller), http.MethodPost, "/events", bytes.NewBuffer(eventJSON))
+	assert.NoError(t, err)
+
+	stored := event
+	stored.UserID = caller
+	stored.CalendarID = ownCalendar(mockStorage, caller)
+	mockStorage.On("CreateEvent", mock.Anything, stored).Return(nil)
+
+	rr := httptest.NewRecorder()
+	createEventHandler(app.New(logg, mockStorage), logg).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestListEventsInRangeHandler(t *testing.T) {
+	mockStorage := new(MockStorage)
+	logg := logger.New("info")
+
+	userID := uuid.New()
+	calendarID := ownCalendar(mockStorage, userID)
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	conference := storage.Event{ID: uuid.New(), Title: "Conference", StartTime: from.Add(-24 * time.Hour),
+		EndTime: from.Add(48 * time.Hour), UserID: userID, CalendarID: calendarID}
+	mockStorage.On("ListEventsInRange", mock.Anything, []uuid.UUID{calendarID}, from, mock.Anything).
+		Return([]storage.Event{conference}, nil)
+
+	handler := listEventsInRangeHandler(app.New(logg, mockStorage), logg)
+	target := fmt.Sprintf("/events/range?from=2024-01-02T00:00:00Z&to=%d", to.Unix())
+	req, err := http.NewRequestWithContext(asUser(userID), http.MethodGet, target, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	var events []storage.Event
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	assert.Len(t, events, 1)
+	mockStorage.AssertExpectations(t)
+
+	for _, query := range []string{"from=2024-01-02T00:00:00Z", "from=yesterday&to=1704240000",
+		"from=1704240000&to=1704153600"} {
+		req, err := http.NewRequestWithContext(asUser(userID), http.MethodGet, "/events/range?"+query, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
 }
@@ -0,0 +1,72 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Dendyator/calendar/internal/app"             //nolint
+	"github.com/Dendyator/calendar/internal/logger"          //nolint
+	"github.com/Dendyator/calendar/internal/server/apierror" //nolint
+	"github.com/Dendyator/calendar/internal/storage"         //nolint
+)
+
+// subscriptionPath matches a single subscription addressed by its UUID.
+const subscriptionPath = "/subscriptions/{id:[0-9a-fA-F-]{36}}"
+
+func createSubscriptionHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling POST request for a subscription")
+		var subscription storage.Subscription
+		if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+			logg.Errorf("Failed to decode subscription: %v", err)
+			writeError(w, fmt.Errorf("%w: %w", apierror.ErrInvalidArgument, err))
+			return
+		}
+		subscription, err := application.CreateSubscription(r.Context(), subscription)
+		if err != nil {
+			logg.Errorf("Failed to create subscription: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Subscription created: %s", subscription.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(subscription)
+	}
+}
+
+func listSubscriptionsHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling GET request for listing subscriptions")
+		subscriptions, err := application.ListSubscriptions(r.Context())
+		if err != nil {
+			logg.Errorf("Failed to list subscriptions: %v", err)
+			writeError(w, err)
+			return
+		}
+		if subscriptions == nil {
+			subscriptions = []storage.Subscription{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(subscriptions)
+	}
+}
+
+func deleteSubscriptionHandler(application *app.App, logg *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logg.Info("Handling DELETE request for a subscription")
+		id, err := parseVarID(r, "id")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := application.DeleteSubscription(r.Context(), id); err != nil {
+			logg.Errorf("Failed to delete subscription: %v", err)
+			writeError(w, err)
+			return
+		}
+		logg.Infof("Subscription deleted: %s", id)
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// APIKey is a long-lived credential of a service client. Only a hash of the
+// secret is stored.
+type APIKey struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"userId"`
+	Name       string    `db:"name" json:"name"`
+	SecretHash string    `db:"secret_hash" json:"-"`
+	Scopes     Strings   `db:"scopes" json:"scopes"`
+	// ExpiresAt and LastUsedAt are zero for keys that never expire or were
+	// never used.
+	ExpiresAt  time.Time `db:"expires_at" json:"expiresAt"`
+	LastUsedAt time.Time `db:"last_used_at" json:"lastUsedAt"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+// Expired reports whether the key can no longer be used at now.
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+type APIKeyInterface interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAPIKey(ctx context.Context, id uuid.UUID) (APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, id uuid.UUID) error
+	// TouchAPIKey records that the key was used at the given time.
+	TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// Strings is a list of plain words stored as a Postgres text array. The
+// words must not contain commas, quotes or braces.
+type Strings []string
+
+func (s Strings) Contains(value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Strings) Value() (driver.Value, error) {
+	return "{" + strings.Join(s, ",") + "}", nil
+}
+
+func (s *Strings) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into Strings", src)
+	}
+
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}")
+	if raw == "" {
+		*s = Strings{}
+		return nil
+	}
+	*s = strings.Split(raw, ",")
+	return nil
+}
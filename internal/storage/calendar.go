@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// Calendar groups events. Its owner and the users it is shared with through
+// ACL entries may access its events according to their Role.
+type Calendar struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	OwnerID uuid.UUID `db:"owner_id" json:"ownerId"`
+	Name    string    `db:"name" json:"name"`
+	// Color is a "#rrggbb" hint for clients; empty means the client's choice.
+	Color string `db:"color" json:"color"`
+	// TimeZone is the IANA name of the zone the calendar's days are shown in.
+	TimeZone  string    `db:"time_zone" json:"timeZone"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidateCalendar checks the name, color and time zone of a calendar.
+func ValidateCalendar(calendar Calendar) error {
+	if calendar.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCalendar)
+	}
+	if calendar.Color != "" && !colorPattern.MatchString(calendar.Color) {
+		return fmt.Errorf("%w: color %q is not #rrggbb", ErrInvalidCalendar, calendar.Color)
+	}
+	if _, err := time.LoadLocation(calendar.TimeZone); err != nil || calendar.TimeZone == "" {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidCalendar, calendar.TimeZone)
+	}
+	return nil
+}
+
+// Location returns the calendar's time zone, or UTC if it is not set.
+func (c Calendar) Location() *time.Location {
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Role is the access level of a user to a calendar.
+type Role string
+
+const (
+	RoleNone   Role = ""
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var roleRanks = map[Role]int{RoleNone: 0, RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+func ParseRole(value string) (Role, error) {
+	role := Role(value)
+	if role == RoleNone || roleRanks[role] == 0 {
+		return RoleNone, fmt.Errorf("%w: unknown role %q", ErrInvalidCalendar, value)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants at least the required access.
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// ACLEntry shares a calendar with a user.
+type ACLEntry struct {
+	CalendarID uuid.UUID `db:"calendar_id" json:"calendarId"`
+	UserID     uuid.UUID `db:"user_id" json:"userId"`
+	Role       Role      `db:"role" json:"role"`
+}
+
+type CalendarInterface interface {
+	CreateCalendar(ctx context.Context, calendar Calendar) error
+	GetCalendar(ctx context.Context, id uuid.UUID) (Calendar, error)
+	// UpdateCalendar changes the name, color and time zone of a calendar.
+	UpdateCalendar(ctx context.Context, calendar Calendar) error
+	// DeleteCalendar deletes the calendar with its events and ACL entries.
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
+	// ListCalendars returns the calendars userID owns or was granted access
+	// to, oldest first.
+	ListCalendars(ctx context.Context, userID uuid.UUID) ([]Calendar, error)
+	// GetRole returns the role of userID in the calendar: RoleOwner for its
+	// owner, the ACL entry's role for others and RoleNone without access.
+	GetRole(ctx context.Context, calendarID, userID uuid.UUID) (Role, error)
+	// SetACL grants entry.Role to entry.UserID, replacing a previous grant.
+	SetACL(ctx context.Context, entry ACLEntry) error
+	DeleteACL(ctx context.Context, calendarID, userID uuid.UUID) error
+	ListACL(ctx context.Context, calendarID uuid.UUID) ([]ACLEntry, error)
+}
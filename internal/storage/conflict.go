@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// ConflictPolicy decides what happens when an event overlaps another event
+// of the same user.
+type ConflictPolicy string
+
+const (
+	ConflictReject ConflictPolicy = "reject"
+	ConflictAllow  ConflictPolicy = "allow"
+	ConflictWarn   ConflictPolicy = "warn"
+)
+
+// conflictHorizon limits how far two open-ended series are checked for
+// overlaps, counted from the start of the later one.
+const conflictHorizon = 365 * 24 * time.Hour
+
+// openEnd is the end of the span of an open-ended series.
+var openEnd = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// ConflictHandler is called for every overlap accepted under ConflictWarn.
+type ConflictHandler func(event, conflict Event)
+
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(strings.ToLower(value)); policy {
+	case "":
+		return ConflictReject, nil
+	case ConflictReject, ConflictAllow, ConflictWarn:
+		return policy, nil
+	default:
+		return ConflictReject, fmt.Errorf("unknown conflict policy %q", value)
+	}
+}
+
+// Span returns the interval covered by the event and, for a series, by its
+// occurrences up to the end of the rule, or up to openEnd if it has none.
+func (e Event) Span() (time.Time, time.Time) {
+	if !e.IsRecurring() {
+		return e.StartTime, e.EndTime
+	}
+	rule, dtstart, err := e.recurrence()
+	if err != nil || (rule.Count == 0 && rule.Until.IsZero()) {
+		return e.StartTime, openEnd
+	}
+	end := e.StartTime
+	if !rule.Until.IsZero() {
+		end = rule.Until
+	}
+	if rule.Count > 0 {
+		if starts := rule.Between(dtstart, dtstart, openEnd); len(starts) > 0 {
+			end = starts[len(starts)-1]
+		}
+	}
+	return e.StartTime, end.Add(e.EndTime.Sub(e.StartTime))
+}
+
+// CheckConflicts checks every saved event against the existing events of its
+// user. Existing events that are being saved or deleted in the same change
+// are skipped. Under ConflictReject the first overlap is returned as
+// ErrDateBusy; under ConflictWarn every overlap is reported to onConflict.
+func CheckConflicts(policy ConflictPolicy, onConflict ConflictHandler, change SeriesChange,
+	existing []Event,
+) error {
+	if policy == ConflictAllow {
+		return nil
+	}
+
+	skip := make(map[uuid.UUID]bool, len(change.Save)+len(change.Delete))
+	for _, event := range change.Save {
+		skip[event.ID] = true
+	}
+	for _, id := range change.Delete {
+		skip[id] = true
+	}
+	others := make([]Event, 0, len(existing)+len(change.Save))
+	for _, event := range existing {
+		if !skip[event.ID] {
+			others = append(others, event)
+		}
+	}
+
+	for i, event := range change.Save {
+		candidates := append(append([]Event{}, others...), change.Save[i+1:]...)
+		for _, candidate := range candidates {
+			if candidate.UserID != event.UserID || !overlaps(event, candidate) {
+				continue
+			}
+			if policy == ConflictWarn {
+				if onConflict != nil {
+					onConflict(event, candidate)
+				}
+				continue
+			}
+			return fmt.Errorf("%w: event %s overlaps event %s", ErrDateBusy, event.ID, candidate.ID)
+		}
+	}
+	return nil
+}
+
+func overlaps(a, b Event) bool {
+	aStart, aEnd := a.Span()
+	bStart, bEnd := b.Span()
+	if !aStart.Before(bEnd) || !bStart.Before(aEnd) {
+		return false
+	}
+
+	// Each series is expanded only over the window both events cover.
+	from, to := maxTime(aStart, bStart), minTime(aEnd, bEnd)
+	if to.Equal(openEnd) {
+		to = from.Add(conflictHorizon)
+	}
+	aOccurrences, err := a.Occurrences(from.Add(-a.EndTime.Sub(a.StartTime)), to)
+	if err != nil {
+		return false
+	}
+	bOccurrences, err := b.Occurrences(from.Add(-b.EndTime.Sub(b.StartTime)), to)
+	if err != nil {
+		return false
+	}
+	for _, x := range aOccurrences {
+		for _, y := range bOccurrences {
+			if x.StartTime.Before(y.EndTime) && y.StartTime.Before(x.EndTime) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              //nolint
+	"github.com/stretchr/testify/require" //nolint
+)
+
+func TestCheckConflicts(t *testing.T) {
+	user := uuid.New()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	standup := Event{
+		ID:        uuid.New(),
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		UserID:    user,
+		RRule:     "FREQ=WEEKLY;BYDAY=MO,TH",
+	}
+
+	thursday := Event{
+		ID:        uuid.New(),
+		StartTime: start.AddDate(0, 0, 10).Add(15 * time.Minute),
+		EndTime:   start.AddDate(0, 0, 10).Add(time.Hour),
+		UserID:    user,
+	}
+	afterStandup := thursday
+	afterStandup.StartTime = start.AddDate(0, 0, 10).Add(30 * time.Minute)
+	otherUser := thursday
+	otherUser.UserID = uuid.New()
+
+	testCases := []struct {
+		name     string
+		event    Event
+		policy   ConflictPolicy
+		busy     bool
+		warnings int
+	}{
+		{name: "overlaps occurrence", event: thursday, policy: ConflictReject, busy: true},
+		{name: "adjacent", event: afterStandup, policy: ConflictReject},
+		{name: "other user", event: otherUser, policy: ConflictReject},
+		{name: "allow", event: thursday, policy: ConflictAllow},
+		{name: "warn", event: thursday, policy: ConflictWarn, warnings: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := 0
+			onConflict := func(_, _ Event) { warnings++ }
+
+			err := CheckConflicts(tc.policy, onConflict, SeriesChange{Save: []Event{tc.event}}, []Event{standup})
+			if tc.busy {
+				require.ErrorIs(t, err, ErrDateBusy)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.warnings, warnings)
+		})
+	}
+}
+
+func TestCheckConflicts_SkipsChangedEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := Event{ID: uuid.New(), StartTime: start, EndTime: start.Add(time.Hour), UserID: uuid.New()}
+	moved := event
+	moved.StartTime = start.Add(30 * time.Minute)
+
+	err := CheckConflicts(ConflictReject, nil, SeriesChange{Save: []Event{moved}}, []Event{event})
+	require.NoError(t, err)
+}
+
+func TestCheckConflicts_OldSeries(t *testing.T) {
+	user := uuid.New()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	daily := Event{ID: uuid.New(), StartTime: start, EndTime: start.Add(30 * time.Minute), UserID: user,
+		RRule: "FREQ=DAILY"}
+	day := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	oneOff := Event{ID: uuid.New(), StartTime: day.Add(15 * time.Minute), EndTime: day.Add(time.Hour), UserID: user}
+	later := daily
+	later.ID, later.StartTime, later.EndTime = uuid.New(), day.Add(-time.Hour), day.Add(15*time.Minute)
+
+	err := CheckConflicts(ConflictReject, nil, SeriesChange{Save: []Event{oneOff}}, []Event{daily})
+	require.ErrorIs(t, err, ErrDateBusy)
+	err = CheckConflicts(ConflictReject, nil, SeriesChange{Save: []Event{later}}, []Event{daily})
+	require.ErrorIs(t, err, ErrDateBusy)
+}
@@ -0,0 +1,44 @@
+package storage
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no event has the requested ID.
+	ErrNotFound = errors.New("event not found")
+	// ErrAlreadyExists is returned when an event with the same ID is already stored.
+	ErrAlreadyExists = errors.New("event already exists")
+	// ErrDateBusy is returned when an event overlaps another event of the same
+	// user and the conflict policy rejects it.
+	ErrDateBusy = errors.New("date is busy")
+	// ErrInvalidEvent is returned when an event fails validation.
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrCalendarNotFound is returned when no calendar, or no ACL entry of a
+	// calendar, matches the request.
+	ErrCalendarNotFound = errors.New("calendar not found")
+	// ErrInvalidCalendar is returned when a calendar or ACL entry fails validation.
+	ErrInvalidCalendar = errors.New("invalid calendar")
+	// ErrInvalidTimeZone is returned for an unknown IANA time zone name.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	// ErrInvalidRange is returned for a listing window that is empty or too long.
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrInvalidPageToken is returned for a page token not issued by ListEvents.
+	ErrInvalidPageToken = errors.New("invalid page token")
+	// ErrInvalidSearch is returned for a search query without words.
+	ErrInvalidSearch = errors.New("invalid search query")
+	// ErrAPIKeyNotFound is returned when no API key has the requested ID.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrFeedNotFound is returned when no feed has the requested ID or
+	// secret.
+	ErrFeedNotFound = errors.New("feed not found")
+	// ErrSubscriptionNotFound is returned when no subscription has the
+	// requested ID.
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrInvalidSubscription is returned when a subscription fails validation.
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrInvalidReminders is returned for default reminders that fail
+	// validation.
+	ErrInvalidReminders = errors.New("invalid reminders")
+	// ErrNotificationNotFound is returned when no notification has the
+	// requested key, or it is in a final state.
+	ErrNotificationNotFound = errors.New("notification not found")
+)
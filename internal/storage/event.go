@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid" //nolint
@@ -13,16 +15,183 @@ type Event struct {
 	StartTime   time.Time `db:"start_time"`
 	EndTime     time.Time `db:"end_time"`
 	UserID      uuid.UUID `db:"user_id"`
+	// CalendarID is the calendar the event belongs to; access to the event
+	// follows the caller's role in that calendar.
+	CalendarID uuid.UUID `db:"calendar_id"`
+	RRule      string    `db:"rrule"`
+	// ExDates are cancelled (or overridden) occurrence starts of the series.
+	ExDates Times `db:"exdates"`
+	// RecurringEventID and RecurrenceID are set on an override: the series it
+	// belongs to and the original start of the occurrence it replaces.
+	RecurringEventID uuid.UUID `db:"recurring_event_id"`
+	RecurrenceID     time.Time `db:"recurrence_id"`
+	// UID is the iCalendar UID of an imported event or series, unique within
+	// its calendar; empty for events created through the API.
+	UID string `db:"uid"`
+	// Reminders are the offsets before each occurrence's start at which
+	// its owner is reminded; nil uses the owner's default reminders and an
+	// empty list turns reminders off.
+	Reminders Durations `db:"reminders"`
+	// TimeZone is the IANA zone the series recurs in: its occurrences keep
+	// their local start time across DST changes. Empty recurs in the zone
+	// of StartTime.
+	TimeZone string `db:"time_zone"`
 }
 
 type Interface interface {
-	CreateEvent(event Event) error
-	UpdateEvent(id uuid.UUID, newEvent Event) error
-	DeleteEvent(id uuid.UUID) error
-	GetEvent(id uuid.UUID) (Event, error)
-	ListEvents() ([]Event, error)
-	ListEventsByDay(date time.Time) ([]Event, error)
-	ListEventsByWeek(start time.Time) ([]Event, error)
-	ListEventsByMonth(start time.Time) ([]Event, error)
-	DeleteOldEvents(before time.Time) error
+	CreateEvent(ctx context.Context, event Event) error
+	UpdateEvent(ctx context.Context, id uuid.UUID, newEvent Event, scope Scope, recurrenceID time.Time) error
+	DeleteEvent(ctx context.Context, id uuid.UUID, scope Scope, recurrenceID time.Time) error
+	GetEvent(ctx context.Context, id uuid.UUID) (Event, error)
+	// GetEventByUID returns the event or series of the calendar with the
+	// given iCalendar UID, or ErrNotFound.
+	GetEventByUID(ctx context.Context, calendarID uuid.UUID, uid string) (Event, error)
+	// ReplaceResource rewrites the event or series of the calendar named
+	// name, its UID or, for an event stored without one, its ID, together
+	// with its overrides in one transaction. Replacements of the same name
+	// are serialised: plan gets the stored events, the series first, or none,
+	// and returns the change to write; an error from plan aborts.
+	ReplaceResource(ctx context.Context, calendarID uuid.UUID, name string,
+		plan func(current []Event) (SeriesChange, error)) error
+	// ListEvents returns one page of the events matching the filter.
+	ListEvents(ctx context.Context, filter EventFilter) (EventPage, error)
+	// ListEventsInRange returns the events and occurrences of the given
+	// calendars overlapping [from, to), including those that started earlier.
+	ListEventsInRange(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time) ([]Event, error)
+	// ListEventsByDay, ListEventsByWeek and ListEventsByMonth are
+	// ListEventsInRange over the day, ISO week or month containing the given
+	// time, with boundaries at local midnight in loc (UTC if nil).
+	ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, loc *time.Location) ([]Event, error)
+	ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, loc *time.Location) ([]Event, error)
+	ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, date time.Time, loc *time.Location,
+	) ([]Event, error)
+	// ListAllEvents returns the events of every user. It is meant for admins
+	// and background jobs, never for a plain user's request.
+	ListAllEvents(ctx context.Context) ([]Event, error)
+	DeleteOldEvents(ctx context.Context, before time.Time) error
+}
+
+// IsRecurring reports whether the event carries a recurrence rule.
+func (e Event) IsRecurring() bool {
+	return e.RRule != ""
+}
+
+// Occurrences expands a recurring event into the occurrences starting in
+// [from, to), skipping ExDates. Every occurrence keeps the ID of the series.
+// A one-off event is returned as is when it starts inside the window.
+func (e Event) Occurrences(from, to time.Time) ([]Event, error) {
+	if !e.IsRecurring() {
+		if e.StartTime.Before(from) || !e.StartTime.Before(to) {
+			return nil, nil
+		}
+		return []Event{e}, nil
+	}
+
+	rule, dtstart, err := e.recurrence()
+	if err != nil {
+		return nil, err
+	}
+	duration := e.EndTime.Sub(e.StartTime)
+	starts := rule.Between(dtstart, from, to)
+	occurrences := make([]Event, 0, len(starts))
+	for _, start := range starts {
+		if e.ExDates.Contains(start) {
+			continue
+		}
+		occurrence := e
+		occurrence.StartTime = start
+		occurrence.EndTime = start.Add(duration)
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+// recurrence parses the rule of a series and returns it with the start of
+// the series in the zone it recurs in.
+func (e Event) recurrence() (Recurrence, time.Time, error) {
+	rule, err := ParseRecurrence(e.RRule)
+	if err != nil || e.TimeZone == "" {
+		return rule, e.StartTime, err
+	}
+	loc, err := cachedLocation(e.TimeZone)
+	if err != nil {
+		return rule, e.StartTime, err
+	}
+	return rule, e.StartTime.In(loc), nil
+}
+
+// NextStart returns the start of the first (not cancelled) occurrence of the
+// event starting at or after from, and false if there is none.
+func (e Event) NextStart(from time.Time) (time.Time, bool, error) {
+	if !e.IsRecurring() {
+		return e.StartTime, !e.StartTime.Before(from), nil
+	}
+	rule, dtstart, err := e.recurrence()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for {
+		start, ok := rule.Next(dtstart, from)
+		if !ok || !e.ExDates.Contains(start) {
+			return start, ok, nil
+		}
+		from = start.Add(time.Nanosecond)
+	}
+}
+
+// Overlaps reports whether the event intersects [from, to). An event without
+// duration overlaps the window it starts in.
+func (e Event) Overlaps(from, to time.Time) bool {
+	return e.StartTime.Before(to) && (e.EndTime.After(from) || !e.StartTime.Before(from))
+}
+
+// ExpandOccurrences replaces every recurring event in events with its
+// occurrences and keeps only the events overlapping [from, to).
+func ExpandOccurrences(events []Event, from, to time.Time) ([]Event, error) {
+	result := make([]Event, 0, len(events))
+	for _, event := range events {
+		if !event.IsRecurring() {
+			if event.Overlaps(from, to) {
+				result = append(result, event)
+			}
+			continue
+		}
+		// Occurrences starting up to one duration before from still overlap.
+		occurrences, err := event.Occurrences(from.Add(-event.EndTime.Sub(event.StartTime)), to)
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			if occurrence.Overlaps(from, to) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ValidateEvent checks that the event has a title, does not end before it
+// starts, that its recurrence rule, if any, parses and that its reminders are
+// valid.
+func ValidateEvent(event Event) error {
+	if event.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidEvent)
+	}
+	if event.EndTime.Before(event.StartTime) {
+		return fmt.Errorf("%w: end time is before start time", ErrInvalidEvent)
+	}
+	if event.IsRecurring() {
+		if _, err := ParseRecurrence(event.RRule); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+		}
+	}
+	if err := ValidateReminders(event.Reminders); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	if event.TimeZone != "" {
+		if _, err := cachedLocation(event.TimeZone); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+		}
+	}
+	return nil
 }
@@ -8,47 +8,7 @@ import (
 	"github.com/stretchr/testify/require" //nolint
 )
 
-type MockStorage struct{}
-
-func (m *MockStorage) CreateEvent(_ Event) error {
-	return nil
-}
-
-func (m *MockStorage) UpdateEvent(_ uuid.UUID, _ Event) error {
-	return nil
-}
-
-func (m *MockStorage) DeleteEvent(_ uuid.UUID) error {
-	return nil
-}
-
-func (m *MockStorage) GetEvent(_ uuid.UUID) (Event, error) {
-	return Event{}, nil
-}
-
-func (m *MockStorage) ListEvents() ([]Event, error) {
-	return []Event{}, nil
-}
-
-func (m *MockStorage) ListEventsByDay(_ time.Time) ([]Event, error) {
-	return []Event{}, nil
-}
-
-func (m *MockStorage) ListEventsByWeek(_ time.Time) ([]Event, error) {
-	return []Event{}, nil
-}
-
-func (m *MockStorage) ListEventsByMonth(_ time.Time) ([]Event, error) {
-	return []Event{}, nil
-}
-
-func (m *MockStorage) DeleteOldEvents(_ time.Time) error {
-	return nil
-}
-
-func TestCreateEvent(t *testing.T) {
-	mock := &MockStorage{}
-
+func TestValidateEvent(t *testing.T) {
 	event := Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -57,7 +17,16 @@ func TestCreateEvent(t *testing.T) {
 		EndTime:     time.Now().Add(2 * time.Hour),
 		UserID:      uuid.New(),
 	}
-
-	err := mock.CreateEvent(event)
-	require.NoError(t, err, "Event created")
+	require.NoError(t, ValidateEvent(event))
+
+	for _, invalid := range []func(e *Event){
+		func(e *Event) { e.Title = "" },
+		func(e *Event) { e.EndTime = e.StartTime.Add(-time.Minute) },
+		func(e *Event) { e.RRule = "FREQ=HOURLY" },
+		func(e *Event) { e.TimeZone = "Mars/Olympus" },
+	} {
+		broken := event
+		invalid(&broken)
+		require.ErrorIs(t, ValidateEvent(broken), ErrInvalidEvent)
+	}
 }
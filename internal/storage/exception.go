@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// Scope selects which occurrences of a recurring series an update or a
+// delete applies to. It is ignored for one-off events.
+type Scope int
+
+const (
+	ScopeAll Scope = iota
+	ScopeThis
+	ScopeThisAndFollowing
+)
+
+var errNotAnOccurrence = fmt.Errorf("%w: recurrence id is not an occurrence of the series", ErrInvalidEvent)
+
+func ParseScope(value string) (Scope, error) {
+	switch strings.ToLower(value) {
+	case "", "all":
+		return ScopeAll, nil
+	case "this":
+		return ScopeThis, nil
+	case "following", "this_and_following":
+		return ScopeThisAndFollowing, nil
+	default:
+		return ScopeAll, fmt.Errorf("%w: unknown scope %q", ErrInvalidEvent, value)
+	}
+}
+
+// Times is a list of instants stored as a Postgres timestamptz array.
+type Times []time.Time
+
+func (t Times) Contains(instant time.Time) bool {
+	for _, v := range t {
+		if v.Equal(instant) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Times) Value() (driver.Value, error) {
+	values := make([]string, len(t))
+	for i, v := range t {
+		values[i] = `"` + v.UTC().Format("2006-01-02 15:04:05.999999") + `+00"`
+	}
+	return "{" + strings.Join(values, ",") + "}", nil
+}
+
+func (t *Times) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into Times", src)
+	}
+
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}")
+	if raw == "" {
+		*t = Times{}
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	times := make(Times, 0, len(parts))
+	for _, part := range parts {
+		parsed, err := parseTimestamp(strings.Trim(part, `"`))
+		if err != nil {
+			return err
+		}
+		times = append(times, parsed)
+	}
+	*t = times
+	return nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	for _, layout := range []string{
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999-07",
+		"2006-01-02 15:04:05.999999999-07:00",
+		time.RFC3339Nano,
+	} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// SeriesChange is the set of writes that applies an update or delete to an
+// event and its series. Save holds events to insert or overwrite by ID.
+type SeriesChange struct {
+	Save   []Event
+	Delete []uuid.UUID
+}
+
+// HasOccurrence reports whether the event has a (not cancelled) occurrence
+// starting exactly at start.
+func (e Event) HasOccurrence(start time.Time) bool {
+	occurrences, err := e.Occurrences(start, start.Add(time.Nanosecond))
+	return err == nil && len(occurrences) == 1
+}
+
+// IsOverride reports whether the event replaces a single occurrence of a
+// recurring series.
+func (e Event) IsOverride() bool {
+	return e.RecurringEventID != uuid.Nil
+}
+
+// PlanUpdate works out how to apply newEvent to current (a stored event) for
+// the given scope. overrides are the stored overrides of current's series.
+//
+// ScopeThis stores newEvent as an override of the occurrence starting at
+// recurrenceID and cancels that occurrence in the series. ScopeThisAndFollowing
+// ends the series before recurrenceID and starts a new series from newEvent.
+func PlanUpdate(current Event, overrides []Event, newEvent Event, scope Scope,
+	recurrenceID time.Time,
+) (SeriesChange, error) {
+	if !current.IsRecurring() || current.IsOverride() || scope == ScopeAll ||
+		(scope == ScopeThisAndFollowing && !recurrenceID.After(current.StartTime)) {
+		newEvent.ID = current.ID
+		newEvent.RecurringEventID = current.RecurringEventID
+		newEvent.RecurrenceID = current.RecurrenceID
+		if newEvent.ExDates == nil {
+			newEvent.ExDates = current.ExDates
+		}
+		if newEvent.UID == "" {
+			newEvent.UID = current.UID
+		}
+		if newEvent.TimeZone == "" {
+			newEvent.TimeZone = current.TimeZone
+		}
+		return SeriesChange{Save: []Event{newEvent}}, nil
+	}
+	if !current.HasOccurrence(recurrenceID) && !isOverridden(overrides, recurrenceID) {
+		return SeriesChange{}, errNotAnOccurrence
+	}
+
+	if scope == ScopeThis {
+		override := newEvent
+		override.ID = newID(newEvent.ID, current.ID)
+		for _, existing := range overrides {
+			if existing.RecurrenceID.Equal(recurrenceID) {
+				override.ID = existing.ID
+			}
+		}
+		override.RRule = ""
+		override.ExDates = nil
+		override.RecurringEventID = current.ID
+		override.RecurrenceID = recurrenceID
+		override.UID = ""
+
+		series := current
+		if !series.ExDates.Contains(recurrenceID) {
+			series.ExDates = append(append(Times{}, series.ExDates...), recurrenceID)
+		}
+		return SeriesChange{Save: []Event{series, override}}, nil
+	}
+
+	truncated, err := truncateSeries(current, recurrenceID)
+	if err != nil {
+		return SeriesChange{}, err
+	}
+	following := newEvent
+	following.ID = newID(newEvent.ID, current.ID)
+	following.RecurringEventID = uuid.Nil
+	following.RecurrenceID = time.Time{}
+	following.ExDates = nil
+	following.UID = ""
+	if following.TimeZone == "" {
+		following.TimeZone = current.TimeZone
+	}
+	return SeriesChange{
+		Save:   []Event{truncated, following},
+		Delete: overridesFrom(overrides, recurrenceID),
+	}, nil
+}
+
+// PlanDelete works out how to delete current (a stored event) for the given
+// scope. Deleting an override cancels its occurrence, since the occurrence is
+// already excluded from the series.
+func PlanDelete(current Event, overrides []Event, scope Scope, recurrenceID time.Time) (SeriesChange, error) {
+	if !current.IsRecurring() || current.IsOverride() || scope == ScopeAll ||
+		(scope == ScopeThisAndFollowing && !recurrenceID.After(current.StartTime)) {
+		return SeriesChange{Delete: append([]uuid.UUID{current.ID}, overridesFrom(overrides, time.Time{})...)}, nil
+	}
+	if !current.HasOccurrence(recurrenceID) && !isOverridden(overrides, recurrenceID) {
+		return SeriesChange{}, errNotAnOccurrence
+	}
+
+	if scope == ScopeThis {
+		series := current
+		if !series.ExDates.Contains(recurrenceID) {
+			series.ExDates = append(append(Times{}, series.ExDates...), recurrenceID)
+		}
+		change := SeriesChange{Save: []Event{series}}
+		for _, existing := range overrides {
+			if existing.RecurrenceID.Equal(recurrenceID) {
+				change.Delete = append(change.Delete, existing.ID)
+			}
+		}
+		return change, nil
+	}
+
+	truncated, err := truncateSeries(current, recurrenceID)
+	if err != nil {
+		return SeriesChange{}, err
+	}
+	return SeriesChange{Save: []Event{truncated}, Delete: overridesFrom(overrides, recurrenceID)}, nil
+}
+
+// truncateSeries ends the series right before the occurrence at "at",
+// keeping COUNT-based rules count-based.
+func truncateSeries(series Event, at time.Time) (Event, error) {
+	rule, dtstart, err := series.recurrence()
+	if err != nil {
+		return series, fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	if rule.Count > 0 {
+		rule.Count = len(rule.Between(dtstart, dtstart, at))
+	} else {
+		rule.Until = at.Add(-time.Second).UTC()
+	}
+	series.RRule = rule.String()
+
+	var exDates Times
+	for _, exDate := range series.ExDates {
+		if exDate.Before(at) {
+			exDates = append(exDates, exDate)
+		}
+	}
+	series.ExDates = exDates
+	return series, nil
+}
+
+func overridesFrom(overrides []Event, from time.Time) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, override := range overrides {
+		if !override.RecurrenceID.Before(from) {
+			ids = append(ids, override.ID)
+		}
+	}
+	return ids
+}
+
+func isOverridden(overrides []Event, recurrenceID time.Time) bool {
+	for _, override := range overrides {
+		if override.RecurrenceID.Equal(recurrenceID) {
+			return true
+		}
+	}
+	return false
+}
+
+func newID(requested, current uuid.UUID) uuid.UUID {
+	if requested == uuid.Nil || requested == current {
+		return uuid.New()
+	}
+	return requested
+}
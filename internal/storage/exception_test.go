@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"              //nolint
+	"github.com/stretchr/testify/require" //nolint
+)
+
+func newSeries() Event {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	return Event{
+		ID:        uuid.New(),
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		UserID:    uuid.New(),
+		RRule:     "FREQ=DAILY",
+	}
+}
+
+func TestPlanUpdate_This(t *testing.T) {
+	series := newSeries()
+	occurrence := series.StartTime.AddDate(0, 0, 3)
+
+	moved := series
+	moved.StartTime = occurrence.Add(time.Hour)
+	moved.EndTime = moved.StartTime.Add(15 * time.Minute)
+
+	change, err := PlanUpdate(series, nil, moved, ScopeThis, occurrence)
+	require.NoError(t, err)
+	require.Len(t, change.Save, 2)
+	require.Empty(t, change.Delete)
+
+	updatedSeries, override := change.Save[0], change.Save[1]
+	require.Equal(t, series.ID, updatedSeries.ID)
+	require.True(t, updatedSeries.ExDates.Contains(occurrence))
+	require.NotEqual(t, series.ID, override.ID)
+	require.Equal(t, series.ID, override.RecurringEventID)
+	require.Equal(t, occurrence, override.RecurrenceID)
+	require.False(t, override.IsRecurring())
+	require.False(t, updatedSeries.HasOccurrence(occurrence))
+}
+
+func TestPlanUpdate_ThisAndFollowing(t *testing.T) {
+	series := newSeries()
+	occurrence := series.StartTime.AddDate(0, 0, 5)
+	override := Event{ID: uuid.New(), RecurringEventID: series.ID, RecurrenceID: series.StartTime.AddDate(0, 0, 7)}
+
+	renamed := series
+	renamed.Title = "Sync"
+	renamed.StartTime = occurrence
+
+	change, err := PlanUpdate(series, []Event{override}, renamed, ScopeThisAndFollowing, occurrence)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{override.ID}, change.Delete)
+	require.Len(t, change.Save, 2)
+
+	truncated, following := change.Save[0], change.Save[1]
+	require.Equal(t, "FREQ=DAILY;UNTIL=20240106T095959Z", truncated.RRule)
+	require.NotEqual(t, series.ID, following.ID)
+	require.Equal(t, "Sync", following.Title)
+
+	occurrences, err := truncated.Occurrences(series.StartTime, series.StartTime.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 5)
+}
+
+func TestPlanUpdate_NotAnOccurrence(t *testing.T) {
+	series := newSeries()
+	_, err := PlanUpdate(series, nil, series, ScopeThis, series.StartTime.Add(time.Minute))
+	require.Error(t, err)
+}
+
+func TestPlanDelete(t *testing.T) {
+	series := newSeries()
+	series.RRule = "FREQ=DAILY;COUNT=10"
+	occurrence := series.StartTime.AddDate(0, 0, 4)
+	override := Event{ID: uuid.New(), RecurringEventID: series.ID, RecurrenceID: occurrence}
+
+	change, err := PlanDelete(series, []Event{override}, ScopeThis, occurrence)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{override.ID}, change.Delete)
+	require.True(t, change.Save[0].ExDates.Contains(occurrence))
+
+	change, err = PlanDelete(series, nil, ScopeThisAndFollowing, occurrence)
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=DAILY;COUNT=4", change.Save[0].RRule)
+
+	change, err = PlanDelete(series, []Event{override}, ScopeAll, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{series.ID, override.ID}, change.Delete)
+
+	change, err = PlanDelete(override, nil, ScopeThis, occurrence)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{override.ID}, change.Delete)
+}
+
+func TestTimes_ScanValue(t *testing.T) {
+	times := Times{
+		time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC),
+	}
+
+	value, err := times.Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"2024-01-01 10:00:00+00","2024-01-02 10:30:00+00"}`, value)
+
+	var scanned Times
+	require.NoError(t, scanned.Scan([]byte(`{"2024-01-01 10:00:00+00","2024-01-02 10:30:00+00"}`)))
+	require.Equal(t, times, scanned)
+
+	require.NoError(t, scanned.Scan("{}"))
+	require.Empty(t, scanned)
+}
+
+func TestSeriesChange_Changes(t *testing.T) {
+	series := newSeries()
+	occurrence := series.StartTime.AddDate(0, 0, 5)
+	override := Event{ID: uuid.New(), RecurringEventID: series.ID, RecurrenceID: series.StartTime.AddDate(0, 0, 7)}
+
+	renamed := series
+	renamed.Title = "Sync"
+	renamed.StartTime = occurrence
+
+	change, err := PlanUpdate(series, []Event{override}, renamed, ScopeThisAndFollowing, occurrence)
+	require.NoError(t, err)
+	changes := change.Changes([]Event{series, override})
+	require.Len(t, changes, 3)
+
+	require.Equal(t, EventChange{Type: ChangeDeleted, EventID: override.ID}, changes[0])
+	require.Equal(t, ChangeUpdated, changes[1].Type)
+	require.Equal(t, series.ID, changes[1].EventID)
+	require.Equal(t, ChangeCreated, changes[2].Type)
+	require.Equal(t, "Sync", changes[2].Event.Title)
+	require.Equal(t, changes[2].Event.ID, changes[2].EventID)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// Feed is a secret URL serving a read-only iCalendar feed of a calendar, or
+// of every calendar its owner can read. Only a hash of the secret is stored.
+type Feed struct {
+	ID     uuid.UUID `db:"id" json:"id"`
+	UserID uuid.UUID `db:"user_id" json:"userId"`
+	// CalendarID is uuid.Nil for a feed of all the owner's calendars.
+	CalendarID uuid.UUID `db:"calendar_id" json:"calendarId"`
+	Name       string    `db:"name" json:"name"`
+	SecretHash string    `db:"secret_hash" json:"-"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+type FeedInterface interface {
+	CreateFeed(ctx context.Context, feed Feed) error
+	GetFeed(ctx context.Context, id uuid.UUID) (Feed, error)
+	// ListFeeds returns the feeds owned by the user.
+	ListFeeds(ctx context.Context, userID uuid.UUID) ([]Feed, error)
+	// UpdateFeedSecret replaces the secret of the feed, revoking its old URL.
+	UpdateFeedSecret(ctx context.Context, id uuid.UUID, secretHash string) error
+	DeleteFeed(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Lease is a named lock held by one holder until it expires unless renewed.
+type Lease struct {
+	Name      string    `db:"name"`
+	Holder    string    `db:"holder"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type LeaseInterface interface {
+	// AcquireLease takes the lease for holder for ttl, or renews it if
+	// holder already has it, unless another holder's lease has not expired.
+	// It returns the lease as it stands afterwards.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (Lease, error)
+	// ReleaseLease gives up the lease if holder has it, so that another
+	// holder can take it at once.
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
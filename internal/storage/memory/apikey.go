@@ -0,0 +1,69 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) CreateAPIKey(_ context.Context, key storage.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.apiKeys[key.ID]; exists {
+		return storage.ErrAlreadyExists
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	s.apiKeys[key.ID] = key
+	return nil
+}
+
+func (s *Storage) GetAPIKey(_ context.Context, id uuid.UUID) (storage.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, exists := s.apiKeys[id]
+	if !exists {
+		return storage.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *Storage) ListAPIKeys(_ context.Context) ([]storage.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]storage.APIKey, 0, len(s.apiKeys))
+	for _, key := range s.apiKeys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *Storage) DeleteAPIKey(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.apiKeys[id]; !exists {
+		return storage.ErrAPIKeyNotFound
+	}
+	delete(s.apiKeys, id)
+	return nil
+}
+
+func (s *Storage) TouchAPIKey(_ context.Context, id uuid.UUID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.apiKeys[id]
+	if !exists {
+		return storage.ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = at
+	s.apiKeys[id] = key
+	return nil
+}
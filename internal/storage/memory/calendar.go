@@ -0,0 +1,159 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) CreateCalendar(_ context.Context, calendar storage.Calendar) error {
+	if err := storage.ValidateCalendar(calendar); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.calendars[calendar.ID]; exists {
+		return storage.ErrAlreadyExists
+	}
+	if calendar.CreatedAt.IsZero() {
+		calendar.CreatedAt = time.Now()
+	}
+	s.calendars[calendar.ID] = calendar
+	return nil
+}
+
+func (s *Storage) GetCalendar(_ context.Context, id uuid.UUID) (storage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendar, exists := s.calendars[id]
+	if !exists {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return calendar, nil
+}
+
+func (s *Storage) UpdateCalendar(_ context.Context, calendar storage.Calendar) error {
+	if err := storage.ValidateCalendar(calendar); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.calendars[calendar.ID]
+	if !exists {
+		return storage.ErrCalendarNotFound
+	}
+	current.Name, current.Color, current.TimeZone = calendar.Name, calendar.Color, calendar.TimeZone
+	s.calendars[calendar.ID] = current
+	return nil
+}
+
+func (s *Storage) DeleteCalendar(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.calendars[id]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+	delete(s.calendars, id)
+	delete(s.acl, id)
+	var deleted []uuid.UUID
+	for eventID, event := range s.events {
+		if event.CalendarID == id {
+			s.remove(eventID)
+			s.dropNotifications(eventID)
+			deleted = append(deleted, eventID)
+		}
+	}
+	s.writeEventChanges(storage.SeriesChange{Delete: deleted}, nil)
+	for feedID, feed := range s.feeds {
+		if feed.CalendarID == id {
+			delete(s.feeds, feedID)
+		}
+	}
+	for subscriptionID, subscription := range s.subscriptions {
+		if subscription.CalendarID == id {
+			delete(s.subscriptions, subscriptionID)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) ListCalendars(_ context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendars := make([]storage.Calendar, 0)
+	for id, calendar := range s.calendars {
+		if calendar.OwnerID == userID || s.acl[id][userID] != storage.RoleNone {
+			calendars = append(calendars, calendar)
+		}
+	}
+	sort.Slice(calendars, func(i, j int) bool {
+		return calendars[i].CreatedAt.Before(calendars[j].CreatedAt)
+	})
+	return calendars, nil
+}
+
+func (s *Storage) GetRole(_ context.Context, calendarID, userID uuid.UUID) (storage.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendar, exists := s.calendars[calendarID]
+	if !exists {
+		return storage.RoleNone, storage.ErrCalendarNotFound
+	}
+	if calendar.OwnerID == userID {
+		return storage.RoleOwner, nil
+	}
+	return s.acl[calendarID][userID], nil
+}
+
+func (s *Storage) SetACL(_ context.Context, entry storage.ACLEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.calendars[entry.CalendarID]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+	if s.acl[entry.CalendarID] == nil {
+		s.acl[entry.CalendarID] = make(map[uuid.UUID]storage.Role)
+	}
+	s.acl[entry.CalendarID][entry.UserID] = entry.Role
+	return nil
+}
+
+func (s *Storage) DeleteACL(_ context.Context, calendarID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.acl[calendarID][userID]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+	delete(s.acl[calendarID], userID)
+	return nil
+}
+
+func (s *Storage) ListACL(_ context.Context, calendarID uuid.UUID) ([]storage.ACLEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.calendars[calendarID]; !exists {
+		return nil, storage.ErrCalendarNotFound
+	}
+	entries := make([]storage.ACLEntry, 0, len(s.acl[calendarID]))
+	for userID, role := range s.acl[calendarID] {
+		entries = append(entries, storage.ACLEntry{CalendarID: calendarID, UserID: userID, Role: role})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UserID.String() < entries[j].UserID.String()
+	})
+	return entries, nil
+}
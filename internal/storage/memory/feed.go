@@ -0,0 +1,78 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) CreateFeed(_ context.Context, feed storage.Feed) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.feeds[feed.ID]; exists {
+		return storage.ErrAlreadyExists
+	}
+	if feed.CalendarID != uuid.Nil {
+		if _, exists := s.calendars[feed.CalendarID]; !exists {
+			return storage.ErrCalendarNotFound
+		}
+	}
+	if feed.CreatedAt.IsZero() {
+		feed.CreatedAt = time.Now()
+	}
+	s.feeds[feed.ID] = feed
+	return nil
+}
+
+func (s *Storage) GetFeed(_ context.Context, id uuid.UUID) (storage.Feed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	feed, exists := s.feeds[id]
+	if !exists {
+		return storage.Feed{}, storage.ErrFeedNotFound
+	}
+	return feed, nil
+}
+
+func (s *Storage) ListFeeds(_ context.Context, userID uuid.UUID) ([]storage.Feed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	feeds := make([]storage.Feed, 0)
+	for _, feed := range s.feeds {
+		if feed.UserID == userID {
+			feeds = append(feeds, feed)
+		}
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].CreatedAt.Before(feeds[j].CreatedAt) })
+	return feeds, nil
+}
+
+func (s *Storage) UpdateFeedSecret(_ context.Context, id uuid.UUID, secretHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feed, exists := s.feeds[id]
+	if !exists {
+		return storage.ErrFeedNotFound
+	}
+	feed.SecretHash = secretHash
+	s.feeds[id] = feed
+	return nil
+}
+
+func (s *Storage) DeleteFeed(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.feeds[id]; !exists {
+		return storage.ErrFeedNotFound
+	}
+	delete(s.feeds, id)
+	return nil
+}
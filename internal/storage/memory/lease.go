@@ -0,0 +1,31 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+)
+
+func (s *Storage) AcquireLease(_ context.Context, name, holder string, ttl time.Duration) (storage.Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	lease, exists := s.leases[name]
+	if !exists || lease.Holder == holder || lease.ExpiresAt.Before(now) {
+		lease = storage.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+		s.leases[name] = lease
+	}
+	return lease, nil
+}
+
+func (s *Storage) ReleaseLease(_ context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lease, exists := s.leases[name]; exists && lease.Holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
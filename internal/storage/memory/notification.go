@@ -0,0 +1,161 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) PlanNotifications(_ context.Context, from, to time.Time, eventIDs []uuid.UUID,
+	planned []storage.Notification,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := idSet(eventIDs)
+	for key, n := range s.notifications {
+		if n.State == storage.NotificationPending && !n.FireAt.Before(from) && n.FireAt.Before(to) &&
+			(eventIDs == nil || events[n.EventID]) {
+			delete(s.notifications, key)
+		}
+	}
+	for _, n := range planned {
+		if _, exists := s.notifications[n.NotificationKey]; exists {
+			continue
+		}
+		if _, exists := s.events[n.EventID]; !exists {
+			return storage.ErrInvalidEvent
+		}
+		n.State, n.UpdatedAt = storage.NotificationPending, time.Now()
+		s.notifications[n.NotificationKey] = n
+	}
+	return nil
+}
+
+func (s *Storage) QueueNotifications(_ context.Context, now time.Time, limit int,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []storage.Notification
+	for _, n := range s.notifications {
+		if n.State == storage.NotificationPending && !n.FireAt.After(now) {
+			due = append(due, n)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].FireAt.Before(due[j].FireAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return s.publishNotifications(due, message)
+}
+
+func (s *Storage) RequeueNotifications(_ context.Context, stale time.Time, maxAttempts, limit int,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []storage.Notification
+	for _, n := range s.notifications {
+		if n.UpdatedAt.Before(stale) && (n.State == storage.NotificationSending ||
+			(n.State == storage.NotificationFailed && n.Attempts < maxAttempts)) {
+			due = append(due, n)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].UpdatedAt.Before(due[j].UpdatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return s.publishNotifications(due, message)
+}
+
+// publishNotifications writes the message of each notification to the
+// outbox and marks it published, or changes nothing if a message cannot be
+// built. The caller must hold the mutex.
+func (s *Storage) publishNotifications(due []storage.Notification,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) (int, error) {
+	messages := make([]storage.OutboxMessage, len(due))
+	for i, n := range due {
+		m, err := message(n)
+		if err != nil {
+			return 0, err
+		}
+		messages[i] = m
+	}
+	for _, n := range due {
+		n.State, n.UpdatedAt = storage.NotificationPublished, time.Now()
+		s.notifications[n.NotificationKey] = n
+	}
+	s.writeOutbox(messages...)
+	return len(due), nil
+}
+
+func (s *Storage) NextNotification(_ context.Context, after time.Time) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	for _, n := range s.notifications {
+		if n.State == storage.NotificationPending && n.FireAt.After(after) && (next.IsZero() || n.FireAt.Before(next)) {
+			next = n.FireAt
+		}
+	}
+	return next, nil
+}
+
+// dropNotifications deletes the notifications of a deleted event, like the
+// foreign key of the SQL storage does. The caller must hold the mutex.
+func (s *Storage) dropNotifications(eventID uuid.UUID) {
+	for key := range s.notifications {
+		if key.EventID == eventID {
+			delete(s.notifications, key)
+		}
+	}
+}
+
+func (s *Storage) GetNotification(_ context.Context, key storage.NotificationKey) (storage.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, exists := s.notifications[key]
+	if !exists {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	return n, nil
+}
+
+func (s *Storage) ClaimNotification(_ context.Context, key storage.NotificationKey,
+) (storage.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, exists := s.notifications[key]
+	if !exists || n.State != storage.NotificationPublished {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	n.State, n.UpdatedAt = storage.NotificationSending, time.Now()
+	n.Attempts++
+	s.notifications[key] = n
+	return n, nil
+}
+
+func (s *Storage) UpdateNotificationState(_ context.Context, key storage.NotificationKey,
+	state storage.NotificationState, details string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, exists := s.notifications[key]
+	if !exists || n.State != storage.NotificationSending {
+		return storage.ErrNotificationNotFound
+	}
+	n.State, n.Details, n.UpdatedAt = state, details, time.Now()
+	s.notifications[key] = n
+	return nil
+}
@@ -0,0 +1,58 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+)
+
+// RelayOutbox holds the lock while sending, which serializes it like the
+// row locks of the SQL storage do.
+func (s *Storage) RelayOutbox(_ context.Context, limit int, send func(storage.OutboxMessage) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sent := 0
+	for sent < limit && sent < len(s.outbox) {
+		if err := send(s.outbox[sent]); err != nil {
+			s.outbox = s.outbox[sent:]
+			return sent, err
+		}
+		sent++
+	}
+	s.outbox = s.outbox[sent:]
+	return sent, nil
+}
+
+// PurgeOutbox deletes nothing: sent messages leave the outbox at once.
+func (s *Storage) PurgeOutbox(_ context.Context, _ time.Time) (int, error) {
+	return 0, nil
+}
+
+// maxOutbox caps the unsent messages kept: nothing drains the outbox of a
+// process that runs no relay, such as the API server.
+const maxOutbox = 10000
+
+// writeOutbox numbers the messages and adds them to the outbox, dropping the
+// oldest beyond maxOutbox. The caller must hold the mutex.
+func (s *Storage) writeOutbox(messages ...storage.OutboxMessage) {
+	for _, message := range messages {
+		s.outboxSeq++
+		message.ID, message.CreatedAt = s.outboxSeq, time.Now()
+		s.outbox = append(s.outbox, message)
+	}
+	if excess := len(s.outbox) - maxOutbox; excess > 0 {
+		s.outbox = append([]storage.OutboxMessage(nil), s.outbox[excess:]...)
+	}
+}
+
+// writeEventChanges reports the changes of applying change over the stored
+// events of the series, existing. The caller must hold the mutex.
+func (s *Storage) writeEventChanges(change storage.SeriesChange, existing []storage.Event) {
+	for _, eventChange := range change.Changes(existing) {
+		// An EventChange always encodes.
+		message, _ := storage.NewOutboxMessage(storage.EventChangesTopic, eventChange)
+		s.writeOutbox(message)
+	}
+}
@@ -0,0 +1,81 @@
+package memorystorage
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) GetDefaultReminders(_ context.Context, userID uuid.UUID) (storage.Durations, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.reminders[userID], nil
+}
+
+func (s *Storage) SetDefaultReminders(_ context.Context, userID uuid.UUID, reminders storage.Durations) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reminders == nil {
+		delete(s.reminders, userID)
+	} else {
+		s.reminders[userID] = append(storage.Durations{}, reminders...)
+	}
+	// The events using the default are planned again.
+	for id, event := range s.events {
+		if event.UserID == userID && event.Reminders == nil {
+			s.updated[id] = time.Now()
+			delete(s.nextReminder, id)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) ListDefaultReminders(_ context.Context) (map[uuid.UUID]storage.Durations, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	defaults := make(map[uuid.UUID]storage.Durations, len(s.reminders))
+	for userID, reminders := range s.reminders {
+		defaults[userID] = reminders
+	}
+	return defaults, nil
+}
+
+func (s *Storage) ListReminderEvents(_ context.Context, scan storage.ReminderScan,
+) ([]storage.ReminderEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []storage.ReminderEvent
+	for id, event := range s.events {
+		if bytes.Compare(id[:], scan.After[:]) <= 0 {
+			continue
+		}
+		if at, planned := s.nextReminder[id]; !planned || (!at.IsZero() && at.Before(scan.Until)) {
+			events = append(events, storage.ReminderEvent{Event: event, UpdatedAt: s.updated[id]})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return bytes.Compare(events[i].ID[:], events[j].ID[:]) < 0 })
+	if len(events) > scan.Limit {
+		events = events[:scan.Limit]
+	}
+	return events, nil
+}
+
+func (s *Storage) SetNextReminders(_ context.Context, next []storage.NextReminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, reminder := range next {
+		if updated, exists := s.updated[reminder.EventID]; exists && updated.Equal(reminder.UpdatedAt) {
+			s.nextReminder[reminder.EventID] = reminder.At
+		}
+	}
+	return nil
+}
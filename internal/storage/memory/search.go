@@ -0,0 +1,81 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+// put stores event, records when it was written, leaves it to be planned
+// again and indexes the words of
+// its title and description. The caller must hold the mutex.
+func (s *Storage) put(event storage.Event) {
+	s.remove(event.ID)
+	s.events[event.ID] = event
+	s.updated[event.ID] = time.Now()
+	for _, term := range storage.Tokenize(event.Title + " " + event.Description) {
+		if s.terms[term] == nil {
+			s.terms[term] = make(map[uuid.UUID]struct{})
+		}
+		s.terms[term][event.ID] = struct{}{}
+	}
+}
+
+// remove deletes the event with the given ID, if any, and its index entries.
+// The caller must hold the mutex.
+func (s *Storage) remove(id uuid.UUID) {
+	event, exists := s.events[id]
+	if !exists {
+		return
+	}
+	delete(s.events, id)
+	delete(s.updated, id)
+	delete(s.nextReminder, id)
+	for _, term := range storage.Tokenize(event.Title + " " + event.Description) {
+		delete(s.terms[term], id)
+		if len(s.terms[term]) == 0 {
+			delete(s.terms, term)
+		}
+	}
+}
+
+// SearchEvents looks the query terms up in the inverted index and ranks the
+// events containing all of them with storage.Rank.
+func (s *Storage) SearchEvents(_ context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	terms := storage.Tokenize(query.Text)
+	results := make([]storage.SearchResult, 0)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendars := idSet(query.CalendarIDs)
+	for id := range s.terms[terms[0]] {
+		event := s.events[id]
+		if !calendars[event.CalendarID] {
+			continue
+		}
+		if rank := storage.Rank(event, terms); rank > 0 {
+			results = append(results, storage.SearchResult{
+				Event:   event,
+				Rank:    rank,
+				Snippet: storage.Snippet(event, terms),
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return storage.Less(results[i].Event, results[j].Event)
+	})
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
@@ -1,7 +1,8 @@
 package memorystorage
 
 import (
-	"errors"
+	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,64 +11,243 @@ import (
 )
 
 type Storage struct {
-	mu     sync.RWMutex
-	events map[uuid.UUID]storage.Event
+	mu            sync.RWMutex
+	events        map[uuid.UUID]storage.Event
+	apiKeys       map[uuid.UUID]storage.APIKey
+	calendars     map[uuid.UUID]storage.Calendar
+	acl           map[uuid.UUID]map[uuid.UUID]storage.Role
+	terms         map[string]map[uuid.UUID]struct{}
+	feeds         map[uuid.UUID]storage.Feed
+	subscriptions map[uuid.UUID]storage.Subscription
+	reminders     map[uuid.UUID]storage.Durations
+	notifications map[storage.NotificationKey]storage.Notification
+	leases        map[string]storage.Lease
+	// updated holds when each event was last written.
+	updated map[uuid.UUID]time.Time
+	// nextReminder holds the next reminder recorded for each event by the
+	// scheduler; an event missing from it is to be planned.
+	nextReminder map[uuid.UUID]time.Time
+	// outbox holds the unsent messages, oldest first.
+	outbox     []storage.OutboxMessage
+	outboxSeq  int64
+	policy     storage.ConflictPolicy
+	onConflict storage.ConflictHandler
 }
 
 func New() *Storage {
 	return &Storage{
-		events: make(map[uuid.UUID]storage.Event),
+		events:        make(map[uuid.UUID]storage.Event),
+		apiKeys:       make(map[uuid.UUID]storage.APIKey),
+		calendars:     make(map[uuid.UUID]storage.Calendar),
+		acl:           make(map[uuid.UUID]map[uuid.UUID]storage.Role),
+		terms:         make(map[string]map[uuid.UUID]struct{}),
+		feeds:         make(map[uuid.UUID]storage.Feed),
+		subscriptions: make(map[uuid.UUID]storage.Subscription),
+		reminders:     make(map[uuid.UUID]storage.Durations),
+		notifications: make(map[storage.NotificationKey]storage.Notification),
+		leases:        make(map[string]storage.Lease),
+		updated:       make(map[uuid.UUID]time.Time),
+		nextReminder:  make(map[uuid.UUID]time.Time),
+		policy:        storage.ConflictReject,
 	}
 }
 
-func (s *Storage) CreateEvent(event storage.Event) error {
+// SetConflictPolicy configures how overlapping events of the same user are
+// handled. onConflict is called for overlaps accepted under ConflictWarn.
+func (s *Storage) SetConflictPolicy(policy storage.ConflictPolicy, onConflict storage.ConflictHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policy = policy
+	s.onConflict = onConflict
+}
+
+func (s *Storage) CreateEvent(_ context.Context, event storage.Event) error {
+	if err := storage.ValidateEvent(event); err != nil {
+		return err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, exists := s.events[event.ID]; exists {
-		return errors.New("event already exists")
+		return storage.ErrAlreadyExists
+	}
+	change := storage.SeriesChange{Save: []storage.Event{event}}
+	if err := storage.CheckConflicts(s.policy, s.onConflict, change, s.all()); err != nil {
+		return err
+	}
+	s.put(event)
+	s.writeEventChanges(change, nil)
+
+	return nil
+}
+
+func (s *Storage) UpdateEvent(_ context.Context, id uuid.UUID, newEvent storage.Event, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	if err := storage.ValidateEvent(newEvent); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.events[id]
+	if !exists {
+		return storage.ErrNotFound
+	}
+	change, err := storage.PlanUpdate(current, s.overrides(id), newEvent, scope, recurrenceID)
+	if err != nil {
+		return err
+	}
+	if err := storage.CheckConflicts(s.policy, s.onConflict, change, s.all()); err != nil {
+		return err
 	}
-	s.events[event.ID] = event
+	s.apply(change, append(s.overrides(id), current))
 
 	return nil
 }
 
-func (s *Storage) UpdateEvent(id uuid.UUID, newEvent storage.Event) error {
+func (s *Storage) DeleteEvent(_ context.Context, id uuid.UUID, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.events[id]; !exists {
-		return errors.New("event not found")
+	current, exists := s.events[id]
+	if !exists {
+		return storage.ErrNotFound
+	}
+	change, err := storage.PlanDelete(current, s.overrides(id), scope, recurrenceID)
+	if err != nil {
+		return err
 	}
-	s.events[id] = newEvent
+	s.apply(change, append(s.overrides(id), current))
 
 	return nil
 }
 
-func (s *Storage) DeleteEvent(id uuid.UUID) error {
+func (s *Storage) ReplaceResource(_ context.Context, calendarID uuid.UUID, name string,
+	plan func(current []storage.Event) (storage.SeriesChange, error),
+) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.events[id]; !exists {
-		return errors.New("event not found")
+	// A name that is no UUID matches no ID.
+	id, _ := uuid.Parse(name)
+	var current []storage.Event
+	for _, event := range s.events {
+		if event.CalendarID == calendarID && !event.IsOverride() &&
+			(event.UID == name || (event.UID == "" && event.ID == id)) {
+			current = append([]storage.Event{event}, s.overrides(event.ID)...)
+			break
+		}
+	}
+	change, err := plan(current)
+	if err != nil {
+		return err
 	}
-	delete(s.events, id)
+	for _, event := range change.Save {
+		if err := storage.ValidateEvent(event); err != nil {
+			return err
+		}
+	}
+	if err := storage.CheckConflicts(s.policy, s.onConflict, change, s.all()); err != nil {
+		return err
+	}
+	s.apply(change, current)
 
 	return nil
 }
 
-func (s *Storage) GetEvent(id uuid.UUID) (storage.Event, error) {
+// overrides returns the stored overrides of the series with the given ID.
+// The caller must hold the mutex.
+func (s *Storage) overrides(seriesID uuid.UUID) []storage.Event {
+	var overrides []storage.Event
+	for _, event := range s.events {
+		if event.RecurringEventID == seriesID {
+			overrides = append(overrides, event)
+		}
+	}
+	return overrides
+}
+
+// all returns every stored event. The caller must hold the mutex.
+func (s *Storage) all() []storage.Event {
+	events := make([]storage.Event, 0, len(s.events))
+	for _, event := range s.events {
+		events = append(events, event)
+	}
+	return events
+}
+
+// apply writes the change to the stored events of the series, existing, and
+// reports it in the outbox. The caller must hold the mutex.
+func (s *Storage) apply(change storage.SeriesChange, existing []storage.Event) {
+	for _, id := range change.Delete {
+		s.remove(id)
+		s.dropNotifications(id)
+	}
+	for _, event := range change.Save {
+		s.put(event)
+	}
+	s.writeEventChanges(change, existing)
+}
+
+func (s *Storage) GetEventByUID(_ context.Context, calendarID uuid.UUID, uid string) (storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if uid == "" {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	for _, event := range s.events {
+		if event.CalendarID == calendarID && event.UID == uid {
+			return event, nil
+		}
+	}
+	return storage.Event{}, storage.ErrNotFound
+}
+
+func (s *Storage) GetEvent(_ context.Context, id uuid.UUID) (storage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	event, exists := s.events[id]
 
 	if !exists {
-		return event, errors.New("event not found")
+		return event, storage.ErrNotFound
 	}
 	return event, nil
 }
 
-func (s *Storage) ListEvents() ([]storage.Event, error) {
+func (s *Storage) ListEvents(_ context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]storage.Event, 0)
+
+	calendars := idSet(filter.CalendarIDs)
+	for _, event := range s.events {
+		if calendars[event.CalendarID] && filter.Matches(event) && filter.IsAfter(event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if filter.Descending {
+			return storage.Less(events[j], events[i])
+		}
+		return storage.Less(events[i], events[j])
+	})
+
+	page := storage.EventPage{Events: events}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.Next = storage.CursorOf(page.Events[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (s *Storage) ListAllEvents(_ context.Context) ([]storage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	events := make([]storage.Event, 0, len(s.events))
@@ -78,49 +258,63 @@ func (s *Storage) ListEvents() ([]storage.Event, error) {
 	return events, nil
 }
 
-func (s *Storage) DeleteOldEvents(before time.Time) error {
+func (s *Storage) DeleteOldEvents(_ context.Context, before time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	var deleted []uuid.UUID
 	for id, event := range s.events {
-		if event.EndTime.Before(before) {
-			delete(s.events, id)
+		if !event.IsRecurring() && event.EndTime.Before(before) {
+			s.remove(id)
+			s.dropNotifications(id)
+			deleted = append(deleted, id)
 		}
 	}
+	s.writeEventChanges(storage.SeriesChange{Delete: deleted}, nil)
 
 	return nil
 }
 
-func (s *Storage) ListEventsByDay(date time.Time) ([]storage.Event, error) {
-	start := date.Truncate(24 * time.Hour)
-	end := start.Add(24 * time.Hour)
-	var events []storage.Event
-	for _, event := range s.events {
-		if event.StartTime.After(start) && event.StartTime.Before(end) {
-			events = append(events, event)
-		}
-	}
-	return events, nil
+func (s *Storage) ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.DayBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
 }
 
-func (s *Storage) ListEventsByWeek(start time.Time) ([]storage.Event, error) {
-	end := start.AddDate(0, 0, 7)
+func (s *Storage) ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.WeekBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
+}
+
+func (s *Storage) ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.MonthBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
+}
+
+func (s *Storage) ListEventsInRange(_ context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendars := idSet(calendarIDs)
 	var events []storage.Event
 	for _, event := range s.events {
-		if event.StartTime.After(start) && event.StartTime.Before(end) {
+		if calendars[event.CalendarID] {
 			events = append(events, event)
 		}
 	}
-	return events, nil
+	return storage.ExpandOccurrences(events, from, to)
 }
 
-func (s *Storage) ListEventsByMonth(start time.Time) ([]storage.Event, error) {
-	end := start.AddDate(0, 1, 0)
-	var events []storage.Event
-	for _, event := range s.events {
-		if event.StartTime.After(start) && event.StartTime.Before(end) {
-			events = append(events, event)
-		}
+func idSet(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
 	}
-	return events, nil
+	return set
 }
@@ -1,16 +1,20 @@
 package memorystorage
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
 	"github.com/google/uuid"                         //nolint
 	"github.com/stretchr/testify/assert"             //nolint
+	"github.com/stretchr/testify/require"            //nolint
 )
 
 func TestStorage_CreateEvent(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -18,18 +22,20 @@ func TestStorage_CreateEvent(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event)
+	err := s.CreateEvent(ctx, event)
 	assert.NoError(t, err)
 
-	storedEvent, err := s.GetEvent(event.ID)
+	storedEvent, err := s.GetEvent(ctx, event.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, event, storedEvent)
 }
 
 func TestStorage_CreateEvent_AlreadyExists(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -37,18 +43,20 @@ func TestStorage_CreateEvent_AlreadyExists(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event)
+	err := s.CreateEvent(ctx, event)
 	assert.NoError(t, err)
 
-	err = s.CreateEvent(event)
+	err = s.CreateEvent(ctx, event)
 	assert.Error(t, err)
 	assert.Equal(t, "event already exists", err.Error())
 }
 
 func TestStorage_UpdateEvent(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -56,23 +64,25 @@ func TestStorage_UpdateEvent(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event)
+	err := s.CreateEvent(ctx, event)
 	assert.NoError(t, err)
 
 	updatedEvent := event
 	updatedEvent.Title = "Updated Event"
-	err = s.UpdateEvent(event.ID, updatedEvent)
+	err = s.UpdateEvent(ctx, event.ID, updatedEvent, storage.ScopeAll, time.Time{})
 	assert.NoError(t, err)
 
-	storedEvent, err := s.GetEvent(event.ID)
+	storedEvent, err := s.GetEvent(ctx, event.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, updatedEvent.Title, storedEvent.Title)
 }
 
 func TestStorage_UpdateEvent_NotFound(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -80,15 +90,17 @@ func TestStorage_UpdateEvent_NotFound(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.UpdateEvent(event.ID, event)
+	err := s.UpdateEvent(ctx, event.ID, event, storage.ScopeAll, time.Time{})
 	assert.Error(t, err)
 	assert.Equal(t, "event not found", err.Error())
 }
 
 func TestStorage_DeleteEvent(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event",
@@ -96,30 +108,33 @@ func TestStorage_DeleteEvent(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event)
+	err := s.CreateEvent(ctx, event)
 	assert.NoError(t, err)
 
-	err = s.DeleteEvent(event.ID)
+	err = s.DeleteEvent(ctx, event.ID, storage.ScopeAll, time.Time{})
 	assert.NoError(t, err)
 
-	_, err = s.GetEvent(event.ID)
+	_, err = s.GetEvent(ctx, event.ID)
 	assert.Error(t, err)
 	assert.Equal(t, "event not found", err.Error())
 }
 
 func TestStorage_DeleteEvent_NotFound(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	eventID := uuid.New()
 
-	err := s.DeleteEvent(eventID)
+	err := s.DeleteEvent(ctx, eventID, storage.ScopeAll, time.Time{})
 	assert.Error(t, err)
 	assert.Equal(t, "event not found", err.Error())
 }
 
 func TestStorage_ListEvents(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event1 := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Test Event 1",
@@ -127,6 +142,7 @@ func TestStorage_ListEvents(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
 	event2 := storage.Event{
@@ -136,20 +152,32 @@ func TestStorage_ListEvents(t *testing.T) {
 		StartTime:   time.Now().Add(2 * time.Hour),
 		EndTime:     time.Now().Add(3 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event1)
+	err := s.CreateEvent(ctx, event1)
 	assert.NoError(t, err)
-	err = s.CreateEvent(event2)
+	err = s.CreateEvent(ctx, event2)
 	assert.NoError(t, err)
 
-	events, err := s.ListEvents()
+	page, err := s.ListEvents(ctx, storage.EventFilter{CalendarIDs: []uuid.UUID{event1.CalendarID}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.Event{event1}, page.Events)
+	assert.Nil(t, page.Next)
+
+	page, err = s.ListEvents(ctx, storage.EventFilter{CalendarIDs: []uuid.UUID{event1.CalendarID}})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.Event{event1}, page.Events)
+	assert.Nil(t, page.Next)
+
+	events, err := s.ListAllEvents(ctx)
 	assert.NoError(t, err)
 	assert.Len(t, events, 2)
 }
 
 func TestStorage_ListEventsByDay(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	event := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Daily Event",
@@ -157,18 +185,24 @@ func TestStorage_ListEventsByDay(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(event)
+	err := s.CreateEvent(ctx, event)
 	assert.NoError(t, err)
 
-	events, err := s.ListEventsByDay(time.Now())
+	events, err := s.ListEventsByDay(ctx, []uuid.UUID{event.CalendarID}, time.Now(), nil)
 	assert.NoError(t, err)
 	assert.Len(t, events, 1)
+
+	events, err = s.ListEventsByDay(ctx, []uuid.UUID{uuid.New()}, time.Now(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
 }
 
 func TestStorage_DeleteOldEvents(t *testing.T) {
 	s := New()
+	ctx := context.Background()
 	oldEvent := storage.Event{
 		ID:          uuid.New(),
 		Title:       "Old Event",
@@ -176,6 +210,7 @@ func TestStorage_DeleteOldEvents(t *testing.T) {
 		StartTime:   time.Now().Add(-2 * time.Hour),
 		EndTime:     time.Now().Add(-1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
 	newEvent := storage.Event{
@@ -185,21 +220,408 @@ func TestStorage_DeleteOldEvents(t *testing.T) {
 		StartTime:   time.Now(),
 		EndTime:     time.Now().Add(1 * time.Hour),
 		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
 	}
 
-	err := s.CreateEvent(oldEvent)
+	err := s.CreateEvent(ctx, oldEvent)
 	assert.NoError(t, err)
-	err = s.CreateEvent(newEvent)
+	err = s.CreateEvent(ctx, newEvent)
 	assert.NoError(t, err)
 
-	err = s.DeleteOldEvents(time.Now())
+	err = s.DeleteOldEvents(ctx, time.Now())
 	assert.NoError(t, err)
 
-	_, err = s.GetEvent(oldEvent.ID)
+	_, err = s.GetEvent(ctx, oldEvent.ID)
 	assert.Error(t, err)
 	assert.Equal(t, "event not found", err.Error())
 
-	storedNewEvent, err := s.GetEvent(newEvent.ID)
+	storedNewEvent, err := s.GetEvent(ctx, newEvent.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, newEvent, storedNewEvent)
 }
+
+func TestStorage_CreateEvent_Invalid(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	event := storage.Event{
+		ID:        uuid.New(),
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(-1 * time.Hour),
+		UserID:    uuid.New(),
+	}
+
+	err := s.CreateEvent(ctx, event)
+	assert.ErrorIs(t, err, storage.ErrInvalidEvent)
+}
+
+func TestStorage_ListEventsByWeek_Recurring(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{
+		ID:          uuid.New(),
+		Title:       "Standup",
+		Description: "Team standup",
+		StartTime:   start,
+		EndTime:     start.Add(15 * time.Minute),
+		UserID:      uuid.New(),
+		CalendarID:  uuid.New(),
+		RRule:       "FREQ=WEEKLY;BYDAY=MO,TH",
+	}
+
+	err := s.CreateEvent(ctx, event)
+	assert.NoError(t, err)
+
+	events, err := s.ListEventsByWeek(ctx, []uuid.UUID{event.CalendarID}, start.AddDate(0, 0, 14), nil)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	for _, occurrence := range events {
+		assert.Equal(t, event.ID, occurrence.ID)
+		assert.Equal(t, 15*time.Minute, occurrence.EndTime.Sub(occurrence.StartTime))
+	}
+}
+
+func TestStorage_CreateEvent_InvalidRRule(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	event := storage.Event{
+		ID:        uuid.New(),
+		Title:     "Broken",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(1 * time.Hour),
+		UserID:    uuid.New(),
+		RRule:     "FREQ=SOMETIMES",
+	}
+
+	err := s.CreateEvent(ctx, event)
+	assert.ErrorIs(t, err, storage.ErrInvalidEvent)
+}
+
+func TestStorage_UpdateEvent_ThisOccurrence(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	series := storage.Event{
+		ID:         uuid.New(),
+		Title:      "Standup",
+		StartTime:  start,
+		EndTime:    start.Add(15 * time.Minute),
+		UserID:     uuid.New(),
+		CalendarID: uuid.New(),
+		RRule:      "FREQ=DAILY",
+	}
+	assert.NoError(t, s.CreateEvent(ctx, series))
+
+	thursday := start.AddDate(0, 0, 3)
+	moved := series
+	moved.StartTime = thursday.Add(time.Hour)
+	moved.EndTime = moved.StartTime.Add(15 * time.Minute)
+	assert.NoError(t, s.UpdateEvent(ctx, series.ID, moved, storage.ScopeThis, thursday))
+
+	events, err := s.ListEventsByDay(ctx, []uuid.UUID{series.CalendarID}, thursday, nil)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, moved.StartTime, events[0].StartTime)
+	assert.Equal(t, series.ID, events[0].RecurringEventID)
+
+	assert.NoError(t, s.DeleteEvent(ctx, events[0].ID, storage.ScopeThis, thursday))
+	events, err = s.ListEventsByDay(ctx, []uuid.UUID{series.CalendarID}, thursday, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestStorage_DeleteEvent_ThisAndFollowing(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	series := storage.Event{
+		ID:         uuid.New(),
+		Title:      "Standup",
+		StartTime:  start,
+		EndTime:    start.Add(15 * time.Minute),
+		UserID:     uuid.New(),
+		CalendarID: uuid.New(),
+		RRule:      "FREQ=DAILY",
+	}
+	assert.NoError(t, s.CreateEvent(ctx, series))
+	assert.NoError(t, s.DeleteEvent(ctx, series.ID, storage.ScopeThisAndFollowing, start.AddDate(0, 0, 3)))
+
+	events, err := s.ListEventsByWeek(ctx, []uuid.UUID{series.CalendarID}, start.Add(-time.Minute), nil)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+func TestStorage_CreateEvent_DateBusy(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user := uuid.New()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{
+		ID:        uuid.New(),
+		Title:     "Meeting",
+		StartTime: start,
+		EndTime:   start.Add(1 * time.Hour),
+		UserID:    user,
+	}
+	assert.NoError(t, s.CreateEvent(ctx, event))
+
+	overlapping := event
+	overlapping.ID = uuid.New()
+	overlapping.StartTime = start.Add(30 * time.Minute)
+	overlapping.EndTime = start.Add(90 * time.Minute)
+
+	err := s.CreateEvent(ctx, overlapping)
+	assert.ErrorIs(t, err, storage.ErrDateBusy)
+
+	var warned []uuid.UUID
+	s.SetConflictPolicy(storage.ConflictWarn, func(_, conflict storage.Event) {
+		warned = append(warned, conflict.ID)
+	})
+	assert.NoError(t, s.CreateEvent(ctx, overlapping))
+	assert.Equal(t, []uuid.UUID{event.ID}, warned)
+}
+
+func TestStorage_ListEventsByDay_TimeZone(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	calendarID := uuid.New()
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// Sunday 20:00, 21:00 and 22:00 UTC; midnight in Moscow (UTC+3) is 21:00 UTC.
+	sunday := time.Date(2024, 1, 7, 20, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		start := sunday.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, s.CreateEvent(ctx, storage.Event{
+			ID: uuid.New(), Title: "Call", StartTime: start, EndTime: start.Add(30 * time.Minute),
+			UserID: uuid.New(), CalendarID: calendarID,
+		}))
+	}
+	late := sunday.Add(2 * time.Hour)
+
+	tests := []struct {
+		name string
+		list func(context.Context, []uuid.UUID, time.Time, *time.Location) ([]storage.Event, error)
+		date time.Time
+		loc  *time.Location
+		want int
+	}{
+		{"day in UTC", s.ListEventsByDay, late, nil, 3},
+		{"day in Moscow", s.ListEventsByDay, late, moscow, 2},
+		{"previous day in Moscow", s.ListEventsByDay, sunday, moscow, 1},
+		{"week in UTC", s.ListEventsByWeek, late, nil, 3},
+		{"week in Moscow", s.ListEventsByWeek, late, moscow, 2},
+		{"month in Moscow", s.ListEventsByMonth, late, moscow, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := tt.list(ctx, []uuid.UUID{calendarID}, tt.date, tt.loc)
+			assert.NoError(t, err)
+			assert.Len(t, events, tt.want)
+		})
+	}
+}
+
+func TestStorage_ListEventsInRange_Overlap(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	calendarID := uuid.New()
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := func(title string, start, end time.Time, rrule string) {
+		require.NoError(t, s.CreateEvent(ctx, storage.Event{
+			ID: uuid.New(), Title: title, StartTime: start, EndTime: end, RRule: rrule,
+			UserID: uuid.New(), CalendarID: calendarID,
+		}))
+	}
+	create("Conference", monday.Add(9*time.Hour), monday.AddDate(0, 0, 3).Add(18*time.Hour), "")
+	create("At midnight", monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 1).Add(time.Hour), "")
+	create("Ended at midnight", monday.Add(23*time.Hour), monday.AddDate(0, 0, 1), "")
+	create("Night shift", monday.Add(22*time.Hour), monday.Add(30*time.Hour), "FREQ=WEEKLY")
+
+	events, err := s.ListEventsInRange(ctx, []uuid.UUID{calendarID}, monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2))
+	assert.NoError(t, err)
+	titles := make([]string, len(events))
+	for i, event := range events {
+		titles[i] = event.Title
+	}
+	assert.ElementsMatch(t, []string{"Conference", "At midnight", "Night shift"}, titles)
+
+	events, err = s.ListEventsByDay(ctx, []uuid.UUID{calendarID}, monday.AddDate(0, 0, 2), nil)
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Conference", events[0].Title)
+}
+
+func TestStorage_ListEvents_Pagination(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	calendarID, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Budget review", "Standup", "Q3 budget", "Standup", "Retro"} {
+		owner := alice
+		if i%2 == 1 {
+			owner = bob
+		}
+		at := start.AddDate(0, 0, i/2) // pairs of events share a start time
+		require.NoError(t, s.CreateEvent(ctx, storage.Event{ID: uuid.New(), Title: title, StartTime: at,
+			EndTime: at.Add(time.Hour), UserID: owner, CalendarID: calendarID}))
+	}
+
+	collect := func(filter storage.EventFilter) []storage.Event {
+		filter.CalendarIDs, filter.Limit = []uuid.UUID{calendarID}, 2
+		var events []storage.Event
+		for {
+			page, err := s.ListEvents(ctx, filter)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(page.Events), 2)
+			events = append(events, page.Events...)
+			if page.Next == nil {
+				return events
+			}
+			filter.After, err = storage.ParseCursor(page.Next.Token())
+			require.NoError(t, err)
+		}
+	}
+
+	ascending := collect(storage.EventFilter{})
+	require.Len(t, ascending, 5)
+	for i := 1; i < len(ascending); i++ {
+		assert.True(t, storage.Less(ascending[i-1], ascending[i]))
+	}
+	descending := collect(storage.EventFilter{Descending: true})
+	require.Len(t, descending, 5)
+	for i := range descending {
+		assert.Equal(t, ascending[len(ascending)-1-i].ID, descending[i].ID)
+	}
+
+	assert.Len(t, collect(storage.EventFilter{Title: "BUDGET"}), 2)
+	assert.Len(t, collect(storage.EventFilter{UserID: bob}), 2)
+	assert.Len(t, collect(storage.EventFilter{From: start.AddDate(0, 0, 1), To: start.AddDate(0, 0, 2)}), 2)
+
+	_, err := storage.ParseCursor("not a token")
+	assert.ErrorIs(t, err, storage.ErrInvalidPageToken)
+}
+
+func TestStorage_SearchEvents(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	calendarID := uuid.New()
+	start := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	newEvent := func(title, description string, day int) storage.Event {
+		at := start.AddDate(0, 0, day)
+		return storage.Event{ID: uuid.New(), Title: title, Description: description, StartTime: at,
+			EndTime: at.Add(time.Hour), UserID: uuid.New(), CalendarID: calendarID}
+	}
+	review := newEvent("Q3 budget review", "Numbers for the quarter", 0)
+	financeSync := newEvent("Finance financeSync", "Touch on the Q3 budget", 1)
+	other := newEvent("Q3 budget", "", 2)
+	other.CalendarID = uuid.New()
+	for _, event := range []storage.Event{review, financeSync, other} {
+		require.NoError(t, s.CreateEvent(ctx, event))
+	}
+	search := func(text string) []storage.SearchResult {
+		results, err := s.SearchEvents(ctx,
+			storage.SearchQuery{CalendarIDs: []uuid.UUID{calendarID}, Text: text, Limit: 10})
+		require.NoError(t, err)
+		return results
+	}
+
+	results := search("q3 Budget")
+	require.Len(t, results, 2)
+	assert.Equal(t, review.ID, results[0].Event.ID)
+	assert.Equal(t, financeSync.ID, results[1].Event.ID)
+	assert.Equal(t, "Finance financeSync Touch on the <b>Q3</b> <b>budget</b>", results[1].Snippet)
+	assert.Empty(t, search("budget retro"))
+
+	renamed := review
+	renamed.Title = "Quarterly planning"
+	require.NoError(t, s.UpdateEvent(ctx, review.ID, renamed, storage.ScopeAll, time.Time{}))
+	assert.Len(t, search("budget"), 1)
+	assert.Len(t, search("quarterly"), 1)
+
+	require.NoError(t, s.DeleteEvent(ctx, financeSync.ID, storage.ScopeAll, time.Time{}))
+	assert.Empty(t, search("budget"))
+	assert.Empty(t, s.terms["finance"])
+}
+
+func TestStorage_ListReminderEvents(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	until := time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)
+	var events []storage.Event
+	for _, title := range []string{"Due", "Later", "Done", "Defaulted"} {
+		e := storage.Event{ID: uuid.New(), Title: title, StartTime: until, EndTime: until.Add(time.Hour),
+			UserID: uuid.New()}
+		require.NoError(t, s.CreateEvent(ctx, e))
+		events = append(events, e)
+	}
+	due, later, done, defaulted := events[0], events[1], events[2], events[3]
+	list := func() []uuid.UUID {
+		scan := storage.ReminderScan{Until: until, Limit: 2}
+		var ids []uuid.UUID
+		for {
+			listed, err := s.ListReminderEvents(ctx, scan)
+			require.NoError(t, err)
+			for _, e := range listed {
+				ids = append(ids, e.ID)
+			}
+			if len(listed) < scan.Limit {
+				return ids
+			}
+			scan.After = listed[len(listed)-1].ID
+		}
+	}
+	// Every new event is to be planned.
+	assert.ElementsMatch(t, []uuid.UUID{due.ID, later.ID, done.ID, defaulted.ID}, list())
+
+	// A write after listing keeps the event selected.
+	listed, err := s.ListReminderEvents(ctx, storage.ReminderScan{Until: until, Limit: 10})
+	require.NoError(t, err)
+	updatedAt := make(map[uuid.UUID]time.Time)
+	for _, e := range listed {
+		updatedAt[e.ID] = e.UpdatedAt
+	}
+	renamed := later
+	renamed.Title = "Renamed"
+	require.NoError(t, s.UpdateEvent(ctx, later.ID, renamed, storage.ScopeAll, time.Time{}))
+	require.NoError(t, s.SetNextReminders(ctx, []storage.NextReminder{
+		{EventID: due.ID, UpdatedAt: updatedAt[due.ID], At: until.Add(-time.Minute)},
+		{EventID: later.ID, UpdatedAt: updatedAt[later.ID], At: until.Add(time.Hour)},
+		{EventID: done.ID, UpdatedAt: updatedAt[done.ID]},
+		{EventID: defaulted.ID, UpdatedAt: updatedAt[defaulted.ID], At: until.Add(time.Hour)},
+	}))
+	assert.ElementsMatch(t, []uuid.UUID{due.ID, later.ID}, list())
+
+	// So does a change of the owner's default reminders.
+	require.NoError(t, s.SetDefaultReminders(ctx, defaulted.UserID, storage.Durations{time.Hour}))
+	assert.ElementsMatch(t, []uuid.UUID{due.ID, later.ID, defaulted.ID}, list())
+}
+
+func TestStorage_DeletionsReachOutbox(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	calendar := storage.Calendar{ID: uuid.New(), OwnerID: uuid.New(), Name: "Work", TimeZone: "UTC"}
+	require.NoError(t, s.CreateCalendar(ctx, calendar))
+	start := time.Now().Add(-2 * time.Hour)
+	inCalendar := storage.Event{ID: uuid.New(), UserID: calendar.OwnerID, CalendarID: calendar.ID, Title: "Planning",
+		StartTime: start.Add(4 * time.Hour), EndTime: start.Add(5 * time.Hour)}
+	old := storage.Event{ID: uuid.New(), UserID: uuid.New(), Title: "Retro", StartTime: start,
+		EndTime: start.Add(time.Hour)}
+	require.NoError(t, s.CreateEvent(ctx, inCalendar))
+	require.NoError(t, s.CreateEvent(ctx, old))
+
+	require.NoError(t, s.DeleteCalendar(ctx, calendar.ID))
+	require.NoError(t, s.DeleteOldEvents(ctx, time.Now()))
+
+	var changes []storage.EventChange
+	_, err := s.RelayOutbox(ctx, 10, func(m storage.OutboxMessage) error {
+		var change storage.EventChange
+		require.NoError(t, json.Unmarshal(m.Payload, &change))
+		changes = append(changes, change)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+	assert.Equal(t, storage.EventChange{Type: storage.ChangeDeleted, EventID: inCalendar.ID}, changes[2])
+	assert.Equal(t, storage.EventChange{Type: storage.ChangeDeleted, EventID: old.ID}, changes[3])
+}
@@ -0,0 +1,94 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint:depguard
+	"github.com/google/uuid"                         //nolint
+)
+
+func (s *Storage) CreateSubscription(_ context.Context, subscription storage.Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subscriptions[subscription.ID]; exists {
+		return storage.ErrAlreadyExists
+	}
+	if _, exists := s.calendars[subscription.CalendarID]; !exists {
+		return storage.ErrCalendarNotFound
+	}
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+	s.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (s *Storage) GetSubscription(_ context.Context, id uuid.UUID) (storage.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscription, exists := s.subscriptions[id]
+	if !exists {
+		return storage.Subscription{}, storage.ErrSubscriptionNotFound
+	}
+	return subscription, nil
+}
+
+func (s *Storage) ListSubscriptions(_ context.Context, userID uuid.UUID) ([]storage.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriptions := make([]storage.Subscription, 0)
+	for _, subscription := range s.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (s *Storage) ListDueSubscriptions(_ context.Context, now time.Time) ([]storage.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriptions := make([]storage.Subscription, 0)
+	for _, subscription := range s.subscriptions {
+		if !subscription.NextFetchAt.After(now) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].NextFetchAt.Before(subscriptions[j].NextFetchAt)
+	})
+	return subscriptions, nil
+}
+
+func (s *Storage) UpdateSubscriptionState(_ context.Context, id uuid.UUID, state storage.SubscriptionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, exists := s.subscriptions[id]
+	if !exists {
+		return storage.ErrSubscriptionNotFound
+	}
+	subscription.SubscriptionState = state
+	s.subscriptions[id] = subscription
+	return nil
+}
+
+func (s *Storage) DeleteSubscription(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subscriptions[id]; !exists {
+		return storage.ErrSubscriptionNotFound
+	}
+	delete(s.subscriptions, id)
+	return nil
+}
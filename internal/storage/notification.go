@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// NotificationState is where a reminder is in its delivery. Delivered is
+// final; failed is final once the attempts run out.
+type NotificationState string
+
+const (
+	// NotificationPending is planned and not yet published.
+	NotificationPending NotificationState = "pending"
+	// NotificationPublished is in the outbox or on the queue, waiting for
+	// the sender.
+	NotificationPublished NotificationState = "published"
+	// NotificationSending is claimed by a sender, which is sending it. If the
+	// sender crashes or cannot record the outcome, the scheduler publishes
+	// it again once the claim is stale.
+	NotificationSending   NotificationState = "sending"
+	NotificationDelivered NotificationState = "delivered"
+	// NotificationFailed could not be sent; it is published again until it
+	// has been attempted the allowed number of times.
+	NotificationFailed NotificationState = "failed"
+)
+
+// NotificationKey identifies the reminder at one offset of one occurrence
+// of an event. Occurrence is the start of the occurrence in whole seconds.
+type NotificationKey struct {
+	// EventID is the ID of the event, or of the series for an occurrence.
+	EventID       uuid.UUID `db:"event_id"`
+	Occurrence    time.Time `db:"occurrence"`
+	OffsetSeconds int64     `db:"offset_seconds"`
+}
+
+// NewNotificationKey normalizes the occurrence start to whole seconds in UTC.
+func NewNotificationKey(eventID uuid.UUID, occurrence time.Time, offset time.Duration) NotificationKey {
+	return NotificationKey{
+		EventID:       eventID,
+		Occurrence:    occurrence.Truncate(time.Second).UTC(),
+		OffsetSeconds: int64(offset / time.Second),
+	}
+}
+
+// Notification is a reminder in the ledger that makes each reminder fire
+// exactly once.
+type Notification struct {
+	NotificationKey
+	UserID uuid.UUID         `db:"user_id"`
+	Title  string            `db:"title"`
+	FireAt time.Time         `db:"fire_at"`
+	State  NotificationState `db:"state"`
+	// Details explains a failure.
+	Details string `db:"details"`
+	// Attempts counts the claims of the notification by senders.
+	Attempts  int       `db:"attempts"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type NotificationInterface interface {
+	// PlanNotifications replaces the pending notifications of the given
+	// events, or of all events if eventIDs is nil, firing in [from, to) with
+	// planned, which are stored as pending. Notifications that already left
+	// the pending state are kept as they are, so a reminder is never
+	// planned twice.
+	PlanNotifications(ctx context.Context, from, to time.Time, eventIDs []uuid.UUID, planned []Notification) error
+	// QueueNotifications marks up to limit pending notifications due at now,
+	// earliest first, as published and writes the message built for each to
+	// the outbox in the same transaction. It returns the number queued.
+	QueueNotifications(ctx context.Context, now time.Time, limit int,
+		message func(Notification) (OutboxMessage, error)) (int, error)
+	// NextNotification returns when the first pending notification after
+	// the given time fires, or the zero time if none does.
+	NextNotification(ctx context.Context, after time.Time) (time.Time, error)
+	GetNotification(ctx context.Context, key NotificationKey) (Notification, error)
+	// RequeueNotifications publishes again, in the outbox, up to limit
+	// notifications last updated before stale that are sending, or that
+	// failed after fewer than maxAttempts attempts. It returns the number
+	// requeued.
+	RequeueNotifications(ctx context.Context, stale time.Time, maxAttempts, limit int,
+		message func(Notification) (OutboxMessage, error)) (int, error)
+	// ClaimNotification moves a published notification to sending, counts
+	// the attempt and returns it, or returns ErrNotificationNotFound if it is
+	// not published, so that of several senders only one claims it.
+	ClaimNotification(ctx context.Context, key NotificationKey) (Notification, error)
+	// UpdateNotificationState moves a sending notification to a final state,
+	// or returns ErrNotificationNotFound.
+	UpdateNotificationState(ctx context.Context, key NotificationKey, state NotificationState, details string) error
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// EventChangesTopic is the outbox topic of the changes to events.
+const EventChangesTopic = "event_changes"
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// EventChange is the payload of a change to one event on
+// EventChangesTopic.
+type EventChange struct {
+	Type    ChangeType `json:"type"`
+	EventID uuid.UUID  `json:"eventId"`
+	// Event is the event after the change, nil for a deletion.
+	Event *Event `json:"event,omitempty"`
+}
+
+// Changes lists the changes to single events that applying c to the stored
+// events of the series, existing, makes.
+func (c SeriesChange) Changes(existing []Event) []EventChange {
+	stored := make(map[uuid.UUID]bool, len(existing))
+	for _, event := range existing {
+		stored[event.ID] = true
+	}
+	changes := make([]EventChange, 0, len(c.Delete)+len(c.Save))
+	for _, id := range c.Delete {
+		changes = append(changes, EventChange{Type: ChangeDeleted, EventID: id})
+	}
+	for i := range c.Save {
+		event := c.Save[i]
+		change := EventChange{Type: ChangeCreated, EventID: event.ID, Event: &event}
+		if stored[event.ID] {
+			change.Type = ChangeUpdated
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// OutboxMessage is a message written in the same transaction as the change
+// it reports and sent to the queue named by Topic afterwards. Messages are
+// sent at least once; consumers deduplicate by ID.
+type OutboxMessage struct {
+	ID        int64     `db:"id"`
+	Topic     string    `db:"topic"`
+	Payload   []byte    `db:"payload"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// NewOutboxMessage encodes payload as JSON.
+func NewOutboxMessage(topic string, payload interface{}) (OutboxMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+	return OutboxMessage{Topic: topic, Payload: body}, nil
+}
+
+type OutboxInterface interface {
+	// RelayOutbox calls send with up to limit unsent messages, oldest first,
+	// and marks those it succeeds for as sent. It stops at the first failure
+	// and returns it with the number sent.
+	RelayOutbox(ctx context.Context, limit int, send func(OutboxMessage) error) (int, error)
+	// PurgeOutbox deletes the messages sent before the given time and
+	// returns how many it deleted.
+	PurgeOutbox(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// EventFilter selects one page of events for ListEvents. Events are ordered
+// by (StartTime, ID); recurring series are returned as stored, not expanded.
+type EventFilter struct {
+	// CalendarIDs is required: only events of these calendars are listed.
+	CalendarIDs []uuid.UUID
+	// UserID, if set, keeps only the events owned by this user.
+	UserID uuid.UUID
+	// Title, if set, keeps events whose title contains it, ignoring case.
+	Title string
+	// From and To, if set, bound StartTime to [From, To).
+	From, To time.Time
+	// Descending lists the latest events first.
+	Descending bool
+	// Limit is the page size; zero or less lists every matching event.
+	Limit int
+	// After continues the listing after this cursor.
+	After *Cursor
+}
+
+// EventPage is a page of events and the cursor of the next page, nil on the
+// last page.
+type EventPage struct {
+	Events []Event
+	Next   *Cursor
+}
+
+// Cursor is the position of the last event of a page.
+type Cursor struct {
+	StartTime time.Time
+	ID        uuid.UUID
+}
+
+// CursorOf returns the cursor positioned at event.
+func CursorOf(event Event) *Cursor {
+	return &Cursor{StartTime: event.StartTime, ID: event.ID}
+}
+
+// Token encodes the cursor as an opaque page token.
+func (c Cursor) Token() string {
+	raw := strconv.FormatInt(c.StartTime.UnixNano(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a page token produced by Cursor.Token.
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidPageToken
+	}
+	cursor := &Cursor{}
+	value, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	cursor.StartTime = time.Unix(0, value).UTC()
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	return cursor, nil
+}
+
+// Less orders events by (StartTime, ID), the order of ListEvents. IDs compare
+// bytewise like Postgres uuids.
+func Less(a, b Event) bool {
+	if !a.StartTime.Equal(b.StartTime) {
+		return a.StartTime.Before(b.StartTime)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
+
+// Matches reports whether event passes every condition of the filter except
+// the cursor and the calendars.
+func (f EventFilter) Matches(event Event) bool {
+	if f.UserID != uuid.Nil && event.UserID != f.UserID {
+		return false
+	}
+	if f.Title != "" && !strings.Contains(strings.ToLower(event.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	if !f.From.IsZero() && event.StartTime.Before(f.From) {
+		return false
+	}
+	return f.To.IsZero() || event.StartTime.Before(f.To)
+}
+
+// IsAfter reports whether event comes after the filter's cursor in the
+// listing order.
+func (f EventFilter) IsAfter(event Event) bool {
+	if f.After == nil {
+		return true
+	}
+	position := Event{StartTime: f.After.StartTime, ID: f.After.ID}
+	if f.Descending {
+		return Less(event, position)
+	}
+	return Less(position, event)
+}
@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds rule expansion so a malformed or very sparse rule
+// cannot loop forever. Rules without COUNT are expanded from the period of
+// the window, not from DTSTART, so the bound only limits the window.
+const maxPeriods = 100000
+
+// maxCount bounds COUNT: a COUNT rule is expanded from DTSTART, and even
+// the sparsest rule yields maxCount occurrences within maxPeriods.
+const maxCount = maxPeriods / 10
+
+const untilLayout = "20060102T150405Z"
+
+// WeekdayNum is a BYDAY entry: a weekday with an optional ordinal
+// (e.g. 1MO for the first Monday, -1FR for the last Friday).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Recurrence is the supported subset of an RFC 5545 RRULE:
+// FREQ, INTERVAL, BYDAY, COUNT and UNTIL.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return r, fmt.Errorf("invalid rrule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch f := Frequency(strings.ToUpper(value)); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				return r, fmt.Errorf("unsupported rrule frequency %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return r, fmt.Errorf("invalid rrule interval %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxCount {
+				return r, fmt.Errorf("invalid rrule count %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return r, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(day)
+				if err != nil {
+					return r, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		default:
+			return r, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return r, fmt.Errorf("rrule %q has no FREQ", rule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return r, fmt.Errorf("rrule %q sets both COUNT and UNTIL", rule)
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly {
+			return r, fmt.Errorf("rrule %q: ordinal BYDAY is only supported with FREQ=MONTHLY", rule)
+		}
+	}
+	if len(r.ByDay) > 0 && r.Freq == Yearly {
+		return r, fmt.Errorf("rrule %q: BYDAY is not supported with FREQ=YEARLY", rule)
+	}
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{untilLayout, "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid rrule until %q", value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid rrule byday %q", value)
+	}
+	wd, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid rrule byday %q", value)
+	}
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("invalid rrule byday %q", value)
+		}
+	}
+	return WeekdayNum{Weekday: wd, N: n}, nil
+}
+
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = weekdayNames[wd.Weekday]
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns the start times of the occurrences of a series beginning
+// at dtstart whose start lies in [from, to). DTSTART always counts as the
+// first occurrence, as in RFC 5545.
+func (r Recurrence) Between(dtstart, from, to time.Time) []time.Time {
+	var starts []time.Time
+	if !dtstart.Before(from) && dtstart.Before(to) {
+		starts = append(starts, dtstart)
+	}
+	count := 1
+
+	first := r.firstPeriod(dtstart, from)
+	for period := first; period < first+maxPeriods; period++ {
+		for _, t := range r.candidates(dtstart, period) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if (r.Count > 0 && count >= r.Count) || (!r.Until.IsZero() && t.After(r.Until)) || !t.Before(to) {
+				return starts
+			}
+			count++
+			if !t.Before(from) {
+				starts = append(starts, t)
+			}
+		}
+	}
+	return starts
+}
+
+// Next returns the start of the first occurrence of a series beginning at
+// dtstart that starts at or after from, and false if there is none.
+func (r Recurrence) Next(dtstart, from time.Time) (time.Time, bool) {
+	if !dtstart.Before(from) {
+		return dtstart, true
+	}
+	count := 1
+
+	first := r.firstPeriod(dtstart, from)
+	for period := first; period < first+maxPeriods; period++ {
+		for _, t := range r.candidates(dtstart, period) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if (r.Count > 0 && count >= r.Count) || (!r.Until.IsZero() && t.After(r.Until)) {
+				return time.Time{}, false
+			}
+			count++
+			if !t.Before(from) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// firstPeriod returns the period (counted from dtstart) to expand a rule
+// from so that no occurrence at or after from is missed. A COUNT rule has
+// to count every occurrence and starts at DTSTART; other rules skip the
+// periods that end before from. One period is kept as a margin for DST
+// changes and months of different lengths.
+func (r Recurrence) firstPeriod(dtstart, from time.Time) int {
+	if r.Count > 0 || !from.After(dtstart) {
+		return 0
+	}
+	from = from.In(dtstart.Location())
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = int(from.Sub(dtstart) / (24 * time.Hour))
+	case Weekly:
+		periods = int(from.Sub(dtstart) / (7 * 24 * time.Hour))
+	case Monthly:
+		periods = (from.Year()-dtstart.Year())*12 + int(from.Month()-dtstart.Month())
+	case Yearly:
+		periods = from.Year() - dtstart.Year()
+	}
+	if periods = periods/r.Interval - 1; periods < 0 {
+		return 0
+	}
+	return periods
+}
+
+// candidates returns the sorted occurrence starts generated by the rule in
+// the given period (day, week, month or year counted from dtstart).
+func (r Recurrence) candidates(dtstart time.Time, period int) []time.Time {
+	y, m, d := dtstart.Date()
+	h, mi, s := dtstart.Clock()
+	ns, loc := dtstart.Nanosecond(), dtstart.Location()
+	step := period * r.Interval
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, h, mi, s, ns, loc)
+	}
+
+	var out []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+step)
+		if len(r.ByDay) == 0 || r.hasWeekday(t.Weekday()) {
+			out = append(out, t)
+		}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(y, m, d+7*step)}
+		}
+		// Weeks start on Monday (WKST=MO).
+		monday := d - (int(dtstart.Weekday())+6)%7 + 7*step
+		for i := 0; i < 7; i++ {
+			t := at(y, m, monday+i)
+			if r.hasWeekday(t.Weekday()) {
+				out = append(out, t)
+			}
+		}
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		if len(r.ByDay) == 0 {
+			if t := at(first.Year(), first.Month(), d); t.Month() == first.Month() {
+				out = append(out, t)
+			}
+			return out
+		}
+		out = r.monthlyByDay(first)
+	case Yearly:
+		if t := at(y+step, m, d); t.Month() == m {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (r Recurrence) monthlyByDay(first time.Time) []time.Time {
+	daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	seen := make(map[int]bool)
+	var out []time.Time
+
+	for _, wd := range r.ByDay {
+		var days []int
+		for day := 1; day <= daysInMonth; day++ {
+			if first.AddDate(0, 0, day-1).Weekday() == wd.Weekday {
+				days = append(days, day)
+			}
+		}
+		switch {
+		case wd.N > 0 && wd.N <= len(days):
+			days = days[wd.N-1 : wd.N]
+		case wd.N < 0 && -wd.N <= len(days):
+			days = days[len(days)+wd.N : len(days)+wd.N+1]
+		case wd.N != 0:
+			days = nil
+		}
+		for _, day := range days {
+			if !seen[day] {
+				seen[day] = true
+				out = append(out, first.AddDate(0, 0, day-1))
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+func (r Recurrence) hasWeekday(day time.Weekday) bool {
+	for _, wd := range r.ByDay {
+		if wd.Weekday == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require" //nolint
+)
+
+func TestParseRecurrence(t *testing.T) {
+	rule, err := ParseRecurrence("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10")
+	require.NoError(t, err)
+	require.Equal(t, Weekly, rule.Freq)
+	require.Equal(t, 2, rule.Interval)
+	require.Equal(t, []WeekdayNum{{Weekday: time.Monday}, {Weekday: time.Thursday}}, rule.ByDay)
+	require.Equal(t, 10, rule.Count)
+	require.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10", rule.String())
+
+	for _, invalid := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=10001",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101T000000Z",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=DAILY;BYMONTH=1",
+	} {
+		_, err := ParseRecurrence(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	dtstart := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC) // Monday
+
+	testCases := []struct {
+		rule     string
+		dtstart  time.Time
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			rule: "FREQ=DAILY;COUNT=3",
+			from: dtstart, to: dtstart.AddDate(0, 1, 0),
+			expected: []time.Time{dtstart, dtstart.AddDate(0, 0, 1), dtstart.AddDate(0, 0, 2)},
+		},
+		{
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE",
+			from: dtstart.AddDate(0, 0, 7), to: dtstart.AddDate(0, 0, 14),
+			expected: []time.Time{dtstart.AddDate(0, 0, 7), dtstart.AddDate(0, 0, 9)},
+		},
+		{
+			rule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20240120T000000Z",
+			from: dtstart, to: dtstart.AddDate(0, 2, 0),
+			expected: []time.Time{dtstart, dtstart.AddDate(0, 0, 14)},
+		},
+		{
+			rule: "FREQ=MONTHLY;BYDAY=-1FR",
+			from: dtstart, to: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				dtstart,
+				time.Date(2024, 1, 26, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 23, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 29, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			rule:    "FREQ=MONTHLY",
+			dtstart: time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+			from:    time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), to: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			rule: "FREQ=YEARLY",
+			from: dtstart.AddDate(1, 0, 0), to: dtstart.AddDate(3, 0, 0),
+			expected: []time.Time{dtstart.AddDate(1, 0, 0), dtstart.AddDate(2, 0, 0)},
+		},
+		{
+			// More than maxPeriods days after DTSTART.
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			dtstart:  time.Date(1700, 1, 1, 9, 0, 0, 0, time.UTC),
+			from:     dtstart,
+			to:       dtstart.AddDate(0, 0, 4),
+			expected: []time.Time{dtstart, dtstart.AddDate(0, 0, 2)},
+		},
+		{
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE",
+			dtstart:  time.Date(1024, 1, 1, 9, 0, 0, 0, time.UTC),
+			from:     dtstart,
+			to:       dtstart.AddDate(0, 0, 7),
+			expected: []time.Time{dtstart, dtstart.AddDate(0, 0, 2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			rule, err := ParseRecurrence(tc.rule)
+			require.NoError(t, err)
+			start := tc.dtstart
+			if start.IsZero() {
+				start = dtstart
+			}
+			require.Equal(t, tc.expected, rule.Between(start, tc.from, tc.to))
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	dtstart := time.Date(1700, 1, 1, 9, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	rule, err := ParseRecurrence("FREQ=DAILY")
+	require.NoError(t, err)
+	next, ok := rule.Next(dtstart, from)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), next)
+
+	rule, err = ParseRecurrence("FREQ=DAILY;COUNT=3")
+	require.NoError(t, err)
+	_, ok = rule.Next(dtstart, from)
+	require.False(t, ok)
+}
+
+func TestEventOccurrences(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	event := Event{
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		RRule:     "FREQ=DAILY",
+	}
+
+	occurrences, err := event.Occurrences(start.AddDate(0, 0, 3), start.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 2)
+	require.Equal(t, event.ID, occurrences[0].ID)
+	require.Equal(t, start.AddDate(0, 0, 3), occurrences[0].StartTime)
+	require.Equal(t, start.AddDate(0, 0, 3).Add(15*time.Minute), occurrences[0].EndTime)
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// Reminder offsets are whole minutes, at most maxReminders per event and
+// at most maxReminderOffset before the start.
+const (
+	maxReminders      = 5
+	maxReminderOffset = 4 * 7 * 24 * time.Hour
+)
+
+// FallbackReminders remind of events a day ahead when neither the event
+// nor its owner chose reminders.
+var FallbackReminders = Durations{24 * time.Hour}
+
+// Durations are reminder offsets: how long before the start of an event its
+// reminders fire. They are stored as an array of seconds and written in
+// JSON as Go duration strings such as "15m" or "1h30m".
+type Durations []time.Duration
+
+// Value writes nil Durations as NULL, unlike an empty array.
+func (d Durations) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	values := make([]string, len(d))
+	for i, v := range d {
+		values[i] = strconv.FormatInt(int64(v/time.Second), 10)
+	}
+	return "{" + strings.Join(values, ",") + "}", nil
+}
+
+func (d *Durations) Scan(src interface{}) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into Durations", src)
+	}
+
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "{"), "}")
+	if raw == "" {
+		*d = Durations{}
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	durations := make(Durations, 0, len(parts))
+	for _, part := range parts {
+		seconds, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan %q into Durations: %w", part, err)
+		}
+		durations = append(durations, time.Duration(seconds)*time.Second)
+	}
+	*d = durations
+	return nil
+}
+
+func (d Durations) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("null"), nil
+	}
+	values := make([]string, len(d))
+	for i, v := range d {
+		values[i] = v.String()
+	}
+	return json.Marshal(values)
+}
+
+func (d *Durations) UnmarshalJSON(data []byte) error {
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		*d = nil
+		return nil
+	}
+	durations := make(Durations, len(values))
+	for i, value := range values {
+		var err error
+		if durations[i], err = time.ParseDuration(value); err != nil {
+			return err
+		}
+	}
+	*d = durations
+	return nil
+}
+
+func (d Durations) contains(offset time.Duration) bool {
+	for _, v := range d {
+		if v == offset {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateReminders checks the count, the range and the uniqueness of
+// reminder offsets.
+func ValidateReminders(reminders Durations) error {
+	if len(reminders) > maxReminders {
+		return fmt.Errorf("at most %d reminders are allowed", maxReminders)
+	}
+	for i, offset := range reminders {
+		if reminders[:i].contains(offset) {
+			return fmt.Errorf("reminder %s is repeated", offset)
+		}
+		if offset < 0 || offset > maxReminderOffset || offset%time.Minute != 0 {
+			return fmt.Errorf("reminder %s is not a whole number of minutes between 0 and %s",
+				offset, maxReminderOffset)
+		}
+	}
+	return nil
+}
+
+// ReminderInterface stores the default reminders of users, used for their
+// events that have no reminders of their own.
+type ReminderInterface interface {
+	// GetDefaultReminders returns nil if the user has no default.
+	GetDefaultReminders(ctx context.Context, userID uuid.UUID) (Durations, error)
+	SetDefaultReminders(ctx context.Context, userID uuid.UUID, reminders Durations) error
+	// ListDefaultReminders returns the defaults of every user that has one.
+	ListDefaultReminders(ctx context.Context) (map[uuid.UUID]Durations, error)
+}
+
+// ReminderScan selects the events that may have a reminder firing before
+// Until that is not in the notification ledger: those written, or whose
+// owner's default reminders changed, since they were last planned, and
+// those whose next reminder fires before Until.
+type ReminderScan struct {
+	Until time.Time
+	// After is the cursor: the scan goes on with the IDs greater than it.
+	After uuid.UUID
+	Limit int
+}
+
+// ReminderEvent is an event selected by a ReminderScan.
+type ReminderEvent struct {
+	Event
+	// UpdatedAt is when the event was last written.
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// NextReminder is when the first reminder of an event that is not in the
+// ledger yet fires, or the zero time if none will.
+type NextReminder struct {
+	EventID uuid.UUID
+	// UpdatedAt is the ReminderEvent.UpdatedAt the reminder was worked out
+	// from.
+	UpdatedAt time.Time
+	At        time.Time
+}
+
+// ReminderScanInterface lists the events the scheduler plans reminders of.
+type ReminderScanInterface interface {
+	// ListReminderEvents returns up to Limit events selected by the scan,
+	// ordered by ID.
+	ListReminderEvents(ctx context.Context, scan ReminderScan) ([]ReminderEvent, error)
+	// SetNextReminders records the next reminders of the events, skipping
+	// those written since UpdatedAt: they stay selected.
+	SetNextReminders(ctx context.Context, next []NextReminder) error
+}
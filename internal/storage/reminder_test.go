@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"  //nolint
+	"github.com/stretchr/testify/require" //nolint
+)
+
+func TestDurations(t *testing.T) {
+	reminders := Durations{15 * time.Minute, 90 * time.Minute}
+	value, err := reminders.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "{900,5400}", value)
+	var scanned Durations
+	require.NoError(t, scanned.Scan([]byte("{900,5400}")))
+	assert.Equal(t, reminders, scanned)
+	require.NoError(t, scanned.Scan("{}"))
+	assert.Equal(t, Durations{}, scanned)
+	require.NoError(t, scanned.Scan(nil))
+	assert.Nil(t, scanned)
+	value, err = Durations(nil).Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	data, err := json.Marshal(reminders)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["15m0s","1h30m0s"]`, string(data))
+	var decoded Durations
+	require.NoError(t, json.Unmarshal([]byte(`["15m","1h30m"]`), &decoded))
+	assert.Equal(t, reminders, decoded)
+	require.NoError(t, json.Unmarshal([]byte(`null`), &decoded))
+	assert.Nil(t, decoded)
+	assert.Error(t, json.Unmarshal([]byte(`[900]`), &decoded))
+}
+
+func TestValidateReminders(t *testing.T) {
+	assert.NoError(t, ValidateReminders(nil))
+	assert.NoError(t, ValidateReminders(Durations{0, 10 * time.Minute, 7 * 24 * time.Hour}))
+	for _, reminders := range []Durations{
+		{-time.Minute},
+		{90 * time.Second},
+		{5 * 7 * 24 * time.Hour},
+		{time.Hour, time.Hour},
+		{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute, 6 * time.Minute},
+	} {
+		assert.Error(t, ValidateReminders(reminders), reminders)
+	}
+}
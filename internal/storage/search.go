@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid" //nolint
+)
+
+// SearchInterface finds events by the words of their title and description.
+type SearchInterface interface {
+	// SearchEvents returns the events of query.CalendarIDs containing every
+	// word of query.Text, best match first.
+	SearchEvents(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+}
+
+// SearchQuery is a full-text search over the events of some calendars.
+type SearchQuery struct {
+	CalendarIDs []uuid.UUID
+	Text        string
+	// Limit is the maximum number of results; it must be positive.
+	Limit int
+}
+
+// SearchResult is a matching event with its relevance and a fragment of its
+// text as HTML: the text is escaped and the matched words are wrapped in
+// <b></b>.
+type SearchResult struct {
+	Event   Event   `json:"event"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Title words weigh more than description words, as with the A and B weights
+// of the Postgres search vector.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+)
+
+// snippetWords is the length of a snippet, in words.
+const snippetWords = 20
+
+// htmlEscaper escapes text for HTML element content, like the escaping of
+// the SQL storage's snippets.
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Tokenize splits text into lowercase words made of letters and digits, the
+// terms of the search index.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Rank scores event for the query terms: the weighted frequency of the terms
+// in the title and description. It is zero unless every term occurs.
+func Rank(event Event, terms []string) float64 {
+	counts := make(map[string]float64)
+	for _, word := range Tokenize(event.Title) {
+		counts[word] += titleWeight
+	}
+	for _, word := range Tokenize(event.Description) {
+		counts[word] += descriptionWeight
+	}
+	var rank float64
+	for _, term := range terms {
+		if counts[term] == 0 {
+			return 0
+		}
+		rank += counts[term]
+	}
+	return rank
+}
+
+// Snippet returns up to snippetWords words of the title and description around
+// the first matched term as HTML, with every matched word wrapped in <b></b>.
+func Snippet(event Event, terms []string) string {
+	words := strings.Fields(strings.TrimSpace(event.Title + " " + event.Description))
+	matched := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		matched[term] = true
+	}
+	isMatch := func(word string) bool {
+		for _, token := range Tokenize(word) {
+			if matched[token] {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := 0
+	for i, word := range words {
+		if isMatch(word) {
+			first = i
+			break
+		}
+	}
+	start := max(0, min(first-snippetWords/4, len(words)-snippetWords))
+	end := min(len(words), start+snippetWords)
+	fragment := make([]string, 0, end-start)
+	for _, word := range words[start:end] {
+		escaped := htmlEscaper.Replace(word)
+		if isMatch(word) {
+			escaped = "<b>" + escaped + "</b>"
+		}
+		fragment = append(fragment, escaped)
+	}
+	return strings.Join(fragment, " ")
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert" //nolint
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"q3", "budget", "планёрка", "2024"}, Tokenize("Q3-budget: Планёрка (2024)!"))
+	assert.Empty(t, Tokenize(" ,.- "))
+}
+
+func TestRankAndSnippet(t *testing.T) {
+	budget := Event{Title: "Q3 budget review", Description: "Go through the budget with finance"}
+	notes := Event{Title: "Finance sync", Description: "Mention the Q3 budget"}
+	terms := Tokenize("q3 BUDGET")
+
+	assert.Greater(t, Rank(budget, terms), Rank(notes, terms))
+	assert.Greater(t, Rank(notes, terms), 0.0)
+	assert.Zero(t, Rank(Event{Title: "Budget"}, terms))
+
+	assert.Equal(t, "<b>Q3</b> <b>budget</b> review Go through the <b>budget</b> with finance", Snippet(budget, terms))
+
+	long := Event{Title: "Weekly", Description: "one two three four five six seven eight nine ten eleven twelve" +
+		" thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty budget q3 end"}
+	snippet := Snippet(long, terms)
+	assert.Contains(t, snippet, "<b>budget</b> <b>q3</b> end")
+	assert.Len(t, strings.Fields(snippet), snippetWords)
+}
+
+func TestSnippet_EscapesHTML(t *testing.T) {
+	event := Event{Title: `<img src=x onerror=alert(1)> budget`, Description: "R&D <script>budget</script>"}
+	snippet := Snippet(event, Tokenize("budget"))
+	assert.Equal(t, "&lt;img src=x onerror=alert(1)&gt; <b>budget</b> R&amp;D <b>&lt;script&gt;budget&lt;/script&gt;</b>",
+		snippet)
+	assert.NotContains(t, strings.ReplaceAll(strings.ReplaceAll(snippet, "<b>", ""), "</b>", ""), "<")
+}
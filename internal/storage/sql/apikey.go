@@ -0,0 +1,89 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// selectAPIKeyColumns maps NULL timestamps to the zero time.Time, spelled
+// in UTC so that it does not depend on the session time zone.
+const selectAPIKeyColumns = "id, user_id, name, secret_hash, scopes," +
+	" COALESCE(expires_at, '0001-01-01 00:00:00+00') AS expires_at," +
+	" COALESCE(last_used_at, '0001-01-01 00:00:00+00') AS last_used_at," +
+	" created_at"
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key storage.APIKey) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var expiresAt interface{}
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = key.ExpiresAt
+	}
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = storage.Strings{}
+	}
+	query := `INSERT INTO api_keys (id, user_id, name, secret_hash, scopes, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := s.DB.ExecContext(ctx, query, key.ID, key.UserID, key.Name, key.SecretHash, scopes, expiresAt)
+	return wrapError(err)
+}
+
+func (s *Storage) GetAPIKey(ctx context.Context, id uuid.UUID) (storage.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var key storage.APIKey
+	err := s.DB.GetContext(ctx, &key, "SELECT "+selectAPIKeyColumns+" FROM api_keys WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, storage.ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var keys []storage.APIKey
+	err := s.DB.SelectContext(ctx, &keys, "SELECT "+selectAPIKeyColumns+" FROM api_keys ORDER BY created_at")
+	return keys, err
+}
+
+func (s *Storage) DeleteAPIKey(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return expectRow(storage.ErrAPIKeyNotFound)(s.DB.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1", id))
+}
+
+func (s *Storage) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE api_keys SET last_used_at = $2 WHERE id = $1"
+	return expectRow(storage.ErrAPIKeyNotFound)(s.DB.ExecContext(ctx, query, id, at))
+}
+
+// expectRow turns a statement that matched no row into notFound.
+func expectRow(notFound error) func(result sql.Result, err error) error {
+	return func(result sql.Result, err error) error {
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return notFound
+		}
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/jmoiron/sqlx"                        //nolint
+)
+
+const calendarColumns = "id, owner_id, name, color, time_zone, created_at"
+
+func (s *Storage) CreateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	if err := storage.ValidateCalendar(calendar); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "INSERT INTO calendars (id, owner_id, name, color, time_zone) VALUES ($1, $2, $3, $4, $5)"
+	_, err := s.DB.ExecContext(ctx, query, calendar.ID, calendar.OwnerID, calendar.Name, calendar.Color,
+		calendar.TimeZone)
+	return wrapError(err)
+}
+
+func (s *Storage) UpdateCalendar(ctx context.Context, calendar storage.Calendar) error {
+	if err := storage.ValidateCalendar(calendar); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE calendars SET name = $2, color = $3, time_zone = $4 WHERE id = $1"
+	return expectRow(storage.ErrCalendarNotFound)(s.DB.ExecContext(ctx, query, calendar.ID, calendar.Name,
+		calendar.Color, calendar.TimeZone))
+}
+
+func (s *Storage) GetCalendar(ctx context.Context, id uuid.UUID) (storage.Calendar, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var calendar storage.Calendar
+	err := s.DB.GetContext(ctx, &calendar, "SELECT "+calendarColumns+" FROM calendars WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return calendar, storage.ErrCalendarNotFound
+	}
+	return calendar, err
+}
+
+// DeleteCalendar relies on ON DELETE CASCADE to remove the calendar's events
+// and ACL entries.
+// DeleteCalendar deletes the events of the calendar itself rather than
+// leaving them to the foreign key, so that their deletion is reported in the
+// outbox.
+func (s *Storage) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		var deleted []uuid.UUID
+		err := tx.SelectContext(ctx, &deleted, "DELETE FROM events WHERE calendar_id = $1 RETURNING id", id)
+		if err != nil {
+			return err
+		}
+		err = expectRow(storage.ErrCalendarNotFound)(tx.ExecContext(ctx, "DELETE FROM calendars WHERE id = $1", id))
+		if err != nil {
+			return err
+		}
+		return writeEventChanges(ctx, tx, storage.SeriesChange{Delete: deleted}, nil)
+	})
+}
+
+func (s *Storage) ListCalendars(ctx context.Context, userID uuid.UUID) ([]storage.Calendar, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + calendarColumns + ` FROM calendars
+              WHERE owner_id = $1
+                 OR id IN (SELECT calendar_id FROM calendar_acl WHERE user_id = $1)
+              ORDER BY created_at, id`
+	calendars := make([]storage.Calendar, 0)
+	err := s.DB.SelectContext(ctx, &calendars, query, userID)
+	return calendars, err
+}
+
+func (s *Storage) GetRole(ctx context.Context, calendarID, userID uuid.UUID) (storage.Role, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT CASE WHEN c.owner_id = $2 THEN 'owner' ELSE COALESCE(a.role, '') END
+              FROM calendars c
+              LEFT JOIN calendar_acl a ON a.calendar_id = c.id AND a.user_id = $2
+              WHERE c.id = $1`
+	var role storage.Role
+	err := s.DB.GetContext(ctx, &role, query, calendarID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.RoleNone, storage.ErrCalendarNotFound
+	}
+	return role, err
+}
+
+func (s *Storage) SetACL(ctx context.Context, entry storage.ACLEntry) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO calendar_acl (calendar_id, user_id, role) VALUES ($1, $2, $3)
+              ON CONFLICT (calendar_id, user_id) DO UPDATE SET role = EXCLUDED.role`
+	_, err := s.DB.ExecContext(ctx, query, entry.CalendarID, entry.UserID, entry.Role)
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == "23503" { // foreign_key_violation
+		return storage.ErrCalendarNotFound
+	}
+	return wrapError(err)
+}
+
+func (s *Storage) DeleteACL(ctx context.Context, calendarID, userID uuid.UUID) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "DELETE FROM calendar_acl WHERE calendar_id = $1 AND user_id = $2"
+	return expectRow(storage.ErrCalendarNotFound)(s.DB.ExecContext(ctx, query, calendarID, userID))
+}
+
+func (s *Storage) ListACL(ctx context.Context, calendarID uuid.UUID) ([]storage.ACLEntry, error) {
+	if _, err := s.GetCalendar(ctx, calendarID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT calendar_id, user_id, role FROM calendar_acl WHERE calendar_id = $1 ORDER BY user_id"
+	entries := make([]storage.ACLEntry, 0)
+	err := s.DB.SelectContext(ctx, &entries, query, calendarID)
+	return entries, err
+}
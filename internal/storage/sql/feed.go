@@ -0,0 +1,65 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+// selectFeedColumns maps a NULL calendar_id to uuid.Nil.
+const selectFeedColumns = "id, user_id, COALESCE(calendar_id, '00000000-0000-0000-0000-000000000000') AS calendar_id," +
+	" name, secret_hash, created_at"
+
+func (s *Storage) CreateFeed(ctx context.Context, feed storage.Feed) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var calendarID interface{}
+	if feed.CalendarID != uuid.Nil {
+		calendarID = feed.CalendarID
+	}
+	query := `INSERT INTO feeds (id, user_id, calendar_id, name, secret_hash)
+              VALUES ($1, $2, $3, $4, $5)`
+	_, err := s.DB.ExecContext(ctx, query, feed.ID, feed.UserID, calendarID, feed.Name, feed.SecretHash)
+	return wrapError(err)
+}
+
+func (s *Storage) GetFeed(ctx context.Context, id uuid.UUID) (storage.Feed, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var feed storage.Feed
+	err := s.DB.GetContext(ctx, &feed, "SELECT "+selectFeedColumns+" FROM feeds WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return feed, storage.ErrFeedNotFound
+	}
+	return feed, err
+}
+
+func (s *Storage) ListFeeds(ctx context.Context, userID uuid.UUID) ([]storage.Feed, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	feeds := []storage.Feed{}
+	query := "SELECT " + selectFeedColumns + " FROM feeds WHERE user_id = $1 ORDER BY created_at"
+	err := s.DB.SelectContext(ctx, &feeds, query, userID)
+	return feeds, err
+}
+
+func (s *Storage) UpdateFeedSecret(ctx context.Context, id uuid.UUID, secretHash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE feeds SET secret_hash = $2 WHERE id = $1"
+	return expectRow(storage.ErrFeedNotFound)(s.DB.ExecContext(ctx, query, id, secretHash))
+}
+
+func (s *Storage) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return expectRow(storage.ErrFeedNotFound)(s.DB.ExecContext(ctx, "DELETE FROM feeds WHERE id = $1", id))
+}
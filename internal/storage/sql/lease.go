@@ -0,0 +1,38 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// AcquireLease measures expiry on the database clock, so that the replicas'
+// clocks need not agree.
+func (s *Storage) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (storage.Lease, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var lease storage.Lease
+	query := `INSERT INTO leases (name, holder, expires_at)
+              VALUES ($1, $2, now() + make_interval(secs => $3))
+              ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+              WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < now()
+              RETURNING name, holder, expires_at`
+	err := s.DB.GetContext(ctx, &lease, query, name, holder, ttl.Seconds())
+	if !errors.Is(err, sql.ErrNoRows) {
+		return lease, err
+	}
+	err = s.DB.GetContext(ctx, &lease, "SELECT name, holder, expires_at FROM leases WHERE name = $1", name)
+	return lease, err
+}
+
+func (s *Storage) ReleaseLease(ctx context.Context, name, holder string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM leases WHERE name = $1 AND holder = $2", name, holder)
+	return err
+}
@@ -0,0 +1,163 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/jmoiron/sqlx"                        //nolint
+)
+
+const selectNotificationColumns = "event_id, occurrence, offset_seconds, user_id, title, fire_at, state, details," +
+	" attempts, updated_at"
+
+func (s *Storage) PlanNotifications(ctx context.Context, from, to time.Time, eventIDs []uuid.UUID,
+	planned []storage.Notification,
+) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := "DELETE FROM notifications WHERE state = 'pending' AND fire_at >= $1 AND fire_at < $2"
+		args := []interface{}{from, to}
+		if eventIDs != nil {
+			query += " AND event_id = ANY($3::uuid[])"
+			args = append(args, idArray(eventIDs))
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		query = `INSERT INTO notifications (event_id, occurrence, offset_seconds, user_id, title, fire_at)
+                  VALUES ($1, $2, $3, $4, $5, $6)
+                  ON CONFLICT (event_id, occurrence, offset_seconds) DO NOTHING`
+		for _, n := range planned {
+			_, err := tx.ExecContext(ctx, query, n.EventID, n.Occurrence, n.OffsetSeconds, n.UserID, n.Title, n.FireAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// QueueNotifications locks the due rows with SKIP LOCKED, so that
+// concurrent schedulers queue disjoint batches.
+func (s *Storage) QueueNotifications(ctx context.Context, now time.Time, limit int,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) (int, error) {
+	queued := 0
+	err := s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		var due []storage.Notification
+		query := "SELECT " + selectNotificationColumns + ` FROM notifications
+                  WHERE state = 'pending' AND fire_at <= $1
+                  ORDER BY fire_at LIMIT $2 FOR UPDATE SKIP LOCKED`
+		if err := tx.SelectContext(ctx, &due, query, now, limit); err != nil {
+			return err
+		}
+		queued = len(due)
+		return publishNotifications(ctx, tx, due, message)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return queued, nil
+}
+
+// RequeueNotifications locks the stale rows with SKIP LOCKED, like
+// QueueNotifications.
+func (s *Storage) RequeueNotifications(ctx context.Context, stale time.Time, maxAttempts, limit int,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) (int, error) {
+	requeued := 0
+	err := s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		var due []storage.Notification
+		query := "SELECT " + selectNotificationColumns + ` FROM notifications
+                  WHERE updated_at < $1 AND (state = 'sending' OR (state = 'failed' AND attempts < $2))
+                  ORDER BY updated_at LIMIT $3 FOR UPDATE SKIP LOCKED`
+		if err := tx.SelectContext(ctx, &due, query, stale, maxAttempts, limit); err != nil {
+			return err
+		}
+		requeued = len(due)
+		return publishNotifications(ctx, tx, due, message)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return requeued, nil
+}
+
+// publishNotifications writes the message of each notification to the
+// outbox and marks it published.
+func publishNotifications(ctx context.Context, tx *sqlx.Tx, due []storage.Notification,
+	message func(storage.Notification) (storage.OutboxMessage, error),
+) error {
+	for _, n := range due {
+		m, err := message(n)
+		if err != nil {
+			return err
+		}
+		if err := writeOutbox(ctx, tx, m); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE notifications SET state = 'published', updated_at = now()
+                                      WHERE event_id = $1 AND occurrence = $2 AND offset_seconds = $3`,
+			n.EventID, n.Occurrence, n.OffsetSeconds)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) NextNotification(ctx context.Context, after time.Time) (time.Time, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var next sql.NullTime
+	err := s.DB.GetContext(ctx, &next,
+		"SELECT min(fire_at) FROM notifications WHERE state = 'pending' AND fire_at > $1", after)
+	return next.Time, err
+}
+
+func (s *Storage) GetNotification(ctx context.Context, key storage.NotificationKey) (storage.Notification, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var n storage.Notification
+	query := "SELECT " + selectNotificationColumns +
+		" FROM notifications WHERE event_id = $1 AND occurrence = $2 AND offset_seconds = $3"
+	err := s.DB.GetContext(ctx, &n, query, key.EventID, key.Occurrence, key.OffsetSeconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return n, storage.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (s *Storage) ClaimNotification(ctx context.Context, key storage.NotificationKey,
+) (storage.Notification, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var n storage.Notification
+	query := `UPDATE notifications SET state = 'sending', attempts = attempts + 1, updated_at = now()
+              WHERE event_id = $1 AND occurrence = $2 AND offset_seconds = $3 AND state = 'published'
+              RETURNING ` + selectNotificationColumns
+	err := s.DB.GetContext(ctx, &n, query, key.EventID, key.Occurrence, key.OffsetSeconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return n, storage.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (s *Storage) UpdateNotificationState(ctx context.Context, key storage.NotificationKey,
+	state storage.NotificationState, details string,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE notifications SET state = $4, details = $5, updated_at = now()
+              WHERE event_id = $1 AND occurrence = $2 AND offset_seconds = $3
+                AND state = 'sending'`
+	return expectRow(storage.ErrNotificationNotFound)(s.DB.ExecContext(ctx, query, key.EventID, key.Occurrence,
+		key.OffsetSeconds, state, details))
+}
@@ -0,0 +1,83 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/jmoiron/sqlx"                        //nolint
+)
+
+// RelayOutbox sends each message in a transaction of its own that locks its
+// row with SKIP LOCKED, so that concurrent relays send disjoint messages and
+// a slow broker confirm cannot roll back the marks of the messages already
+// confirmed.
+func (s *Storage) RelayOutbox(ctx context.Context, limit int, send func(storage.OutboxMessage) error) (int, error) {
+	for sent := 0; sent < limit; sent++ {
+		found := false
+		var sendErr error
+		err := s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+			var unsent []storage.OutboxMessage
+			query := `SELECT id, topic, payload, created_at FROM outbox WHERE sent_at IS NULL
+                      ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED`
+			if err := tx.SelectContext(ctx, &unsent, query); err != nil || len(unsent) == 0 {
+				return err
+			}
+			found = true
+			if sendErr = send(unsent[0]); sendErr != nil {
+				return nil
+			}
+			_, err := tx.ExecContext(ctx, "UPDATE outbox SET sent_at = now() WHERE id = $1", unsent[0].ID)
+			return err
+		})
+		switch {
+		case err != nil:
+			return sent, err
+		case sendErr != nil:
+			return sent, sendErr
+		case !found:
+			return sent, nil
+		}
+	}
+	return limit, nil
+}
+
+func (s *Storage) PurgeOutbox(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM outbox WHERE sent_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// writeOutbox adds the messages to the outbox in the transaction of the
+// change they report.
+func writeOutbox(ctx context.Context, tx *sqlx.Tx, messages ...storage.OutboxMessage) error {
+	for _, message := range messages {
+		_, err := tx.ExecContext(ctx, "INSERT INTO outbox (topic, payload) VALUES ($1, $2)",
+			message.Topic, string(message.Payload))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEventChanges reports the changes of applying change over the stored
+// events of the series, existing, on storage.EventChangesTopic.
+func writeEventChanges(ctx context.Context, tx *sqlx.Tx, change storage.SeriesChange, existing []storage.Event) error {
+	for _, eventChange := range change.Changes(existing) {
+		message, err := storage.NewOutboxMessage(storage.EventChangesTopic, eventChange)
+		if err != nil {
+			return err
+		}
+		if err := writeOutbox(ctx, tx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
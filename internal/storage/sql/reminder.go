@@ -0,0 +1,92 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+	"github.com/jmoiron/sqlx"                        //nolint
+)
+
+func (s *Storage) GetDefaultReminders(ctx context.Context, userID uuid.UUID) (storage.Durations, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var reminders storage.Durations
+	err := s.DB.GetContext(ctx, &reminders, "SELECT reminders FROM reminder_defaults WHERE user_id = $1", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return reminders, err
+}
+
+// SetDefaultReminders also marks the user's events that use the default as
+// written, so that the scheduler plans them again.
+func (s *Storage) SetDefaultReminders(ctx context.Context, userID uuid.UUID, reminders storage.Durations) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `INSERT INTO reminder_defaults (user_id, reminders) VALUES ($1, $2)
+                  ON CONFLICT (user_id) DO UPDATE SET reminders = EXCLUDED.reminders`
+		args := []interface{}{userID, reminders}
+		if reminders == nil {
+			query, args = "DELETE FROM reminder_defaults WHERE user_id = $1", args[:1]
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE events SET updated_at = now(), next_reminder_at = '-infinity'
+                                       WHERE user_id = $1 AND reminders IS NULL`, userID)
+		return err
+	})
+}
+
+func (s *Storage) ListDefaultReminders(ctx context.Context) (map[uuid.UUID]storage.Durations, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var rows []struct {
+		UserID    uuid.UUID         `db:"user_id"`
+		Reminders storage.Durations `db:"reminders"`
+	}
+	if err := s.DB.SelectContext(ctx, &rows, "SELECT user_id, reminders FROM reminder_defaults"); err != nil {
+		return nil, err
+	}
+	defaults := make(map[uuid.UUID]storage.Durations, len(rows))
+	for _, row := range rows {
+		defaults[row.UserID] = row.Reminders
+	}
+	return defaults, nil
+}
+
+// ListReminderEvents uses the index on next_reminder_at, which writes reset
+// to -infinity.
+func (s *Storage) ListReminderEvents(ctx context.Context, scan storage.ReminderScan,
+) ([]storage.ReminderEvent, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + selectEventColumns + ", updated_at FROM events" +
+		" WHERE next_reminder_at < $1 AND id > $2 ORDER BY id LIMIT $3"
+	var events []storage.ReminderEvent
+	if err := s.DB.SelectContext(ctx, &events, query, scan.Until, scan.After, scan.Limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *Storage) SetNextReminders(ctx context.Context, next []storage.NextReminder) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := "UPDATE events SET next_reminder_at = $3 WHERE id = $1 AND updated_at = $2"
+		for _, reminder := range next {
+			var at interface{}
+			if !reminder.At.IsZero() {
+				at = reminder.At
+			}
+			if _, err := tx.ExecContext(ctx, query, reminder.EventID, reminder.UpdatedAt, at); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
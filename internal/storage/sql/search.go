@@ -0,0 +1,51 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// escapedText is the title and description escaped for HTML element content,
+// like the snippets of the memory storage, so that a snippet holds no markup
+// but the <b></b> around matches.
+const escapedText = `replace(replace(replace(title || ' ' || coalesce(description, ''),
+                   '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+
+// searchQuery matches the generated search_vector column against the words of
+// $2, all of which must occur, and ranks with ts_rank over the A (title) and B
+// (description) weights.
+const searchQuery = "SELECT " + selectEventColumns + `,
+       ts_rank(search_vector, query) AS rank,
+       ts_headline('simple', ` + escapedText + `, query,
+                   'StartSel=<b>, StopSel=</b>, MinWords=5, MaxWords=20') AS snippet
+  FROM events, plainto_tsquery('simple', $2) AS query
+ WHERE calendar_id = ANY($1::uuid[]) AND search_vector @@ query
+ ORDER BY rank DESC, start_time, id
+ LIMIT $3`
+
+type searchRow struct {
+	storage.Event
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+func (s *Storage) SearchEvents(ctx context.Context, query storage.SearchQuery) ([]storage.SearchResult, error) {
+	results := make([]storage.SearchResult, 0)
+	if len(storage.Tokenize(query.Text)) == 0 {
+		return results, nil
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var rows []searchRow
+	err := s.DB.SelectContext(ctx, &rows, searchQuery, idArray(query.CalendarIDs), query.Text, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		results = append(results, storage.SearchResult{Event: row.Event, Rank: row.Rank, Snippet: row.Snippet})
+	}
+	return results, nil
+}
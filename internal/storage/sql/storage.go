@@ -1,9 +1,14 @@
 package sqlstorage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Dendyator/calendar/internal/storage" //nolint
@@ -12,8 +17,33 @@ import (
 	"github.com/jmoiron/sqlx"                        //nolint
 )
 
+const eventColumns = "id, title, description, start_time, end_time, user_id, calendar_id, rrule, exdates," +
+	" recurring_event_id, recurrence_id, uid, reminders, time_zone"
+
+// selectEventColumns maps a NULL recurrence_id to the zero time.Time, spelled
+// in UTC so that it does not depend on the session time zone.
+const selectEventColumns = "id, title, description, start_time, end_time, user_id, calendar_id, rrule, exdates," +
+	" recurring_event_id, COALESCE(recurrence_id, '0001-01-01 00:00:00+00') AS recurrence_id, uid, reminders, time_zone"
+
+const upsertEventQuery = "INSERT INTO events (" + eventColumns + ")" +
+	" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)" +
+	" ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description," +
+	" start_time = EXCLUDED.start_time, end_time = EXCLUDED.end_time, user_id = EXCLUDED.user_id," +
+	" calendar_id = EXCLUDED.calendar_id," +
+	" rrule = EXCLUDED.rrule, exdates = EXCLUDED.exdates, recurring_event_id = EXCLUDED.recurring_event_id," +
+	" recurrence_id = EXCLUDED.recurrence_id, uid = EXCLUDED.uid, reminders = EXCLUDED.reminders," +
+	" time_zone = EXCLUDED.time_zone," +
+	" updated_at = now(), next_reminder_at = '-infinity'"
+
+// defaultQueryTimeout bounds a single query or transaction unless
+// SetQueryTimeout overrides it.
+const defaultQueryTimeout = 5 * time.Second
+
 type Storage struct {
-	DB *sqlx.DB
+	DB           *sqlx.DB
+	policy       storage.ConflictPolicy
+	onConflict   storage.ConflictHandler
+	queryTimeout time.Duration
 }
 
 func New(dsn string) (*Storage, error) {
@@ -28,7 +58,7 @@ func New(dsn string) (*Storage, error) {
 		if err == nil {
 			if err = db.Ping(); err == nil {
 				log.Println("Successfully connected to the database!")
-				return &Storage{DB: db}, nil
+				return &Storage{DB: db, policy: storage.ConflictReject, queryTimeout: defaultQueryTimeout}, nil
 			}
 		}
 		log.Printf("Failed to connect to database: %v. Retrying...\n", err)
@@ -37,95 +67,407 @@ func New(dsn string) (*Storage, error) {
 	return nil, err
 }
 
-func (s *Storage) CreateEvent(event storage.Event) error {
-	query := "INSERT INTO events (id, title, description, start_time," +
-		" end_time, user_id) VALUES ($1, $2, $3, $4, $5, $6)"
-	_, err := s.DB.Exec(query, event.ID, event.Title, event.Description, event.StartTime, event.EndTime, event.UserID)
-	return err
+// SetConflictPolicy configures how overlapping events of the same user are
+// handled. onConflict is called for overlaps accepted under ConflictWarn.
+func (s *Storage) SetConflictPolicy(policy storage.ConflictPolicy, onConflict storage.ConflictHandler) {
+	s.policy = policy
+	s.onConflict = onConflict
 }
 
-func (s *Storage) UpdateEvent(id uuid.UUID, newEvent storage.Event) error {
-	query := "UPDATE events SET title = $1, description = $2, start_time = $3, end_time = $4," +
-		" user_id = $5 WHERE id = $6"
-	res, err := s.DB.Exec(query, newEvent.Title, newEvent.Description, newEvent.StartTime,
-		newEvent.EndTime, newEvent.UserID, id)
-	if err != nil {
+// SetQueryTimeout bounds every query or transaction; zero disables the limit
+// and leaves only the caller's deadline.
+func (s *Storage) SetQueryTimeout(timeout time.Duration) {
+	s.queryTimeout = timeout
+}
+
+// withTimeout derives the per-query context from the caller's context.
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
+	if err := storage.ValidateEvent(event); err != nil {
 		return err
 	}
-	affectedRows, err := res.RowsAffected()
-	if err != nil {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		change := storage.SeriesChange{Save: []storage.Event{event}}
+		if err := s.checkConflicts(ctx, tx, change); err != nil {
+			return err
+		}
+		query := "INSERT INTO events (" + eventColumns + ")" +
+			" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)"
+		if _, err := tx.ExecContext(ctx, query, eventArgs(event)...); err != nil {
+			return err
+		}
+		return writeEventChanges(ctx, tx, change, nil)
+	})
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id uuid.UUID, newEvent storage.Event, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	if err := storage.ValidateEvent(newEvent); err != nil {
 		return err
 	}
-	if affectedRows == 0 {
-		return errors.New("event not found")
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		current, overrides, err := loadSeries(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		change, err := storage.PlanUpdate(current, overrides, newEvent, scope, recurrenceID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkConflicts(ctx, tx, change); err != nil {
+			return err
+		}
+		return applyChange(ctx, tx, change, append(overrides, current))
+	})
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id uuid.UUID, scope storage.Scope,
+	recurrenceID time.Time,
+) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		current, overrides, err := loadSeries(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		change, err := storage.PlanDelete(current, overrides, scope, recurrenceID)
+		if err != nil {
+			return err
+		}
+		return applyChange(ctx, tx, change, append(overrides, current))
+	})
+}
+
+// ReplaceResource serialises writers of the name with a transaction-scoped
+// advisory lock, which also covers a resource that does not exist yet, and
+// locks the stored rows against other writes of the series.
+func (s *Storage) ReplaceResource(ctx context.Context, calendarID uuid.UUID, name string,
+	plan func(current []storage.Event) (storage.SeriesChange, error),
+) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		lock := "SELECT pg_advisory_xact_lock(hashtext($1))"
+		if _, err := tx.ExecContext(ctx, lock, calendarID.String()+"/"+name); err != nil {
+			return err
+		}
+		current, err := loadResource(ctx, tx, calendarID, name)
+		if err != nil {
+			return err
+		}
+		change, err := plan(current)
+		if err != nil {
+			return err
+		}
+		for _, event := range change.Save {
+			if err := storage.ValidateEvent(event); err != nil {
+				return err
+			}
+		}
+		if err := s.checkConflicts(ctx, tx, change); err != nil {
+			return err
+		}
+		return applyChange(ctx, tx, change, current)
+	})
+}
+
+// loadResource locks and loads the named series of the calendar followed by
+// its overrides, or nothing if there is no such series.
+func loadResource(ctx context.Context, tx *sqlx.Tx, calendarID uuid.UUID, name string) ([]storage.Event, error) {
+	// A name that is no UUID matches no ID.
+	id, _ := uuid.Parse(name)
+	var ids []uuid.UUID
+	query := `SELECT id FROM events
+              WHERE calendar_id = $1 AND recurring_event_id IS NULL AND (uid = $2 OR (uid = '' AND id = $3))`
+	if err := tx.SelectContext(ctx, &ids, query, calendarID, name, id); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	series, overrides, err := loadSeries(ctx, tx, ids[0])
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return append([]storage.Event{series}, overrides...), nil
 }
 
-func (s *Storage) DeleteEvent(id uuid.UUID) error {
-	query := "DELETE FROM events WHERE id = $1"
-	res, err := s.DB.Exec(query, id)
+// inTx runs fn in a transaction bounded by the query timeout. fn must use the
+// context it is given.
+func (s *Storage) inTx(ctx context.Context, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	affectedRows, err := res.RowsAffected()
-	if err != nil {
+	if err := fn(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return wrapError(err)
+	}
+	return wrapError(tx.Commit())
+}
+
+// wrapError maps Postgres constraint violations onto the storage sentinel
+// errors, keeping the driver message.
+func wrapError(err error) error {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
 		return err
 	}
-	if affectedRows == 0 {
-		return errors.New("event not found")
+	switch pgErr.SQLState() {
+	case "23505": // unique_violation
+		return fmt.Errorf("%w: %w", storage.ErrAlreadyExists, err)
+	case "23503", "23514", "22007", "22008": // foreign_key, check, datetime format and overflow
+		return fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+	default:
+		return err
+	}
+}
+
+// checkConflicts serialises writers of the affected users with
+// transaction-scoped advisory locks and checks the change against their
+// stored events. An exclusion constraint cannot express recurring series, so
+// the overlap test itself is storage.CheckConflicts.
+func (s *Storage) checkConflicts(ctx context.Context, tx *sqlx.Tx, change storage.SeriesChange) error {
+	if s.policy == storage.ConflictAllow {
+		return nil
+	}
+
+	users := make([]string, 0, len(change.Save))
+	for _, event := range change.Save {
+		users = append(users, event.UserID.String())
+	}
+	sort.Strings(users)
+	for i, user := range users {
+		if i > 0 && users[i-1] == user {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", user); err != nil {
+			return err
+		}
+	}
+
+	query := "SELECT " + selectEventColumns + ` FROM events
+              WHERE user_id = $1 AND start_time < $2 AND (end_time > $3 OR rrule <> '')`
+	seen := make(map[uuid.UUID]bool)
+	var existing []storage.Event
+	for _, event := range change.Save {
+		start, end := event.Span()
+		var candidates []storage.Event
+		if err := tx.SelectContext(ctx, &candidates, query, event.UserID, end, start); err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			if !seen[candidate.ID] {
+				seen[candidate.ID] = true
+				existing = append(existing, candidate)
+			}
+		}
 	}
-	return nil
+	return storage.CheckConflicts(s.policy, s.onConflict, change, existing)
 }
 
-func (s *Storage) GetEvent(id uuid.UUID) (storage.Event, error) {
+// loadSeries locks the event with the given ID and loads the overrides of
+// its series.
+func loadSeries(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (storage.Event, []storage.Event, error) {
+	var current storage.Event
+	err := tx.GetContext(ctx, &current, "SELECT "+selectEventColumns+" FROM events WHERE id = $1 FOR UPDATE", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return current, nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return current, nil, err
+	}
+
+	var overrides []storage.Event
+	err = tx.SelectContext(ctx, &overrides,
+		"SELECT "+selectEventColumns+" FROM events WHERE recurring_event_id = $1", id)
+	return current, overrides, err
+}
+
+// applyChange writes the change to the stored events of the series,
+// existing, and reports it in the outbox.
+func applyChange(ctx context.Context, tx *sqlx.Tx, change storage.SeriesChange, existing []storage.Event) error {
+	for _, id := range change.Delete {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM events WHERE id = $1", id); err != nil {
+			return err
+		}
+	}
+	for _, event := range change.Save {
+		if _, err := tx.ExecContext(ctx, upsertEventQuery, eventArgs(event)...); err != nil {
+			return err
+		}
+	}
+	return writeEventChanges(ctx, tx, change, existing)
+}
+
+func eventArgs(event storage.Event) []interface{} {
+	var recurringEventID, recurrenceID interface{}
+	if event.IsOverride() {
+		recurringEventID, recurrenceID = event.RecurringEventID, event.RecurrenceID
+	}
+	exDates := event.ExDates
+	if exDates == nil {
+		exDates = storage.Times{}
+	}
+	return []interface{}{
+		event.ID, event.Title, event.Description, event.StartTime, event.EndTime, event.UserID,
+		event.CalendarID, event.RRule, exDates, recurringEventID, recurrenceID, event.UID, event.Reminders,
+		event.TimeZone,
+	}
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id uuid.UUID) (storage.Event, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var event storage.Event
-	query := "SELECT id, title, description, start_time, end_time, user_id FROM events WHERE id = $1"
-	err := s.DB.Get(&event, query, id)
+	query := "SELECT " + selectEventColumns + " FROM events WHERE id = $1"
+	err := s.DB.GetContext(ctx, &event, query, id)
 
 	if errors.Is(err, sql.ErrNoRows) {
-		return event, errors.New("event not found")
+		return event, storage.ErrNotFound
 	}
 	return event, err
 }
 
-func (s *Storage) ListEvents() ([]storage.Event, error) {
-	var events []storage.Event
-	query := "SELECT id, title, description, start_time, end_time, user_id FROM events"
-	err := s.DB.Select(&events, query)
-	return events, err
-}
+func (s *Storage) GetEventByUID(ctx context.Context, calendarID uuid.UUID, uid string) (storage.Event, error) {
+	if uid == "" {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var event storage.Event
+	query := "SELECT " + selectEventColumns + " FROM events WHERE calendar_id = $1 AND uid = $2"
+	err := s.DB.GetContext(ctx, &event, query, calendarID, uid)
 
-func (s *Storage) DeleteOldEvents(before time.Time) error {
-	query := "DELETE FROM events WHERE end_time < $1"
-	_, err := s.DB.Exec(query, before)
-	return err
+	if errors.Is(err, sql.ErrNoRows) {
+		return event, storage.ErrNotFound
+	}
+	return event, err
 }
 
-func (s *Storage) ListEventsByDay(date time.Time) ([]storage.Event, error) {
-	start := date.Truncate(24 * time.Hour)
-	end := start.Add(24 * time.Hour)
-	query := `SELECT id, title, description, start_time, end_time, user_id FROM events 
-              WHERE start_time >= $1 AND start_time < $2`
+// ListEvents builds the filter into a keyset query and reads one row past
+// the page to find out whether another page follows.
+func (s *Storage) ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+	conditions := []string{"calendar_id = ANY(" + arg(idArray(filter.CalendarIDs)) + "::uuid[])"}
+	if filter.UserID != uuid.Nil {
+		conditions = append(conditions, "user_id = "+arg(filter.UserID))
+	}
+	if filter.Title != "" {
+		conditions = append(conditions, "title ILIKE '%' || "+arg(likeEscaper.Replace(filter.Title))+" || '%'")
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "start_time >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "start_time < "+arg(filter.To))
+	}
+	order, compare := "ASC", ">"
+	if filter.Descending {
+		order, compare = "DESC", "<"
+	}
+	if filter.After != nil {
+		conditions = append(conditions,
+			"(start_time, id) "+compare+" ("+arg(filter.After.StartTime)+", "+arg(filter.After.ID)+")")
+	}
+
+	query := "SELECT " + selectEventColumns + " FROM events WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY start_time " + order + ", id " + order
+	if filter.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(filter.Limit+1)
+	}
 	var events []storage.Event
-	err := s.DB.Select(&events, query, start, end)
-	return events, err
+	if err := s.DB.SelectContext(ctx, &events, query, args...); err != nil {
+		return storage.EventPage{}, err
+	}
+	page := storage.EventPage{Events: events}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.Next = storage.CursorOf(page.Events[filter.Limit-1])
+	}
+	return page, nil
 }
 
-func (s *Storage) ListEventsByWeek(start time.Time) ([]storage.Event, error) {
-	end := start.AddDate(0, 0, 7)
-	query := `SELECT id, title, description, start_time, end_time, user_id FROM events
-              WHERE start_time >= $1 AND start_time < $2`
+func (s *Storage) ListAllEvents(ctx context.Context) ([]storage.Event, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	var events []storage.Event
-	err := s.DB.Select(&events, query, start, end)
+	query := "SELECT " + selectEventColumns + " FROM events"
+	err := s.DB.SelectContext(ctx, &events, query)
 	return events, err
 }
 
-func (s *Storage) ListEventsByMonth(start time.Time) ([]storage.Event, error) {
-	end := start.AddDate(0, 1, 0)
-	query := `SELECT id, title, description, start_time, end_time, user_id FROM events
-              WHERE start_time >= $1 AND start_time < $2`
+func (s *Storage) DeleteOldEvents(ctx context.Context, before time.Time) error {
+	return s.inTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		var deleted []uuid.UUID
+		query := "DELETE FROM events WHERE end_time < $1 AND rrule = '' RETURNING id"
+		if err := tx.SelectContext(ctx, &deleted, query, before); err != nil {
+			return err
+		}
+		return writeEventChanges(ctx, tx, storage.SeriesChange{Delete: deleted}, nil)
+	})
+}
+
+func (s *Storage) ListEventsByDay(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.DayBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
+}
+
+func (s *Storage) ListEventsByWeek(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.WeekBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
+}
+
+func (s *Storage) ListEventsByMonth(ctx context.Context, calendarIDs []uuid.UUID, date time.Time,
+	loc *time.Location,
+) ([]storage.Event, error) {
+	start, end := storage.MonthBounds(date, loc)
+	return s.ListEventsInRange(ctx, calendarIDs, start, end)
+}
+
+// ListEventsInRange selects the calendars' one-off events overlapping
+// [from, to) and every series starting before to, then expands the series.
+func (s *Storage) ListEventsInRange(ctx context.Context, calendarIDs []uuid.UUID, from, to time.Time,
+) ([]storage.Event, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT " + selectEventColumns + ` FROM events
+              WHERE calendar_id = ANY($1::uuid[]) AND start_time < $3
+                AND (rrule <> '' OR end_time > $2 OR start_time >= $2)`
 	var events []storage.Event
-	err := s.DB.Select(&events, query, start, end)
-	return events, err
+	if err := s.DB.SelectContext(ctx, &events, query, idArray(calendarIDs), from, to); err != nil {
+		return nil, err
+	}
+	return storage.ExpandOccurrences(events, from, to)
+}
+
+// likeEscaper escapes the LIKE wildcards of a literal substring.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// idArray passes ids as a Postgres array literal, to be cast with ::uuid[].
+func idArray(ids []uuid.UUID) storage.Strings {
+	values := make(storage.Strings, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
 }
@@ -5,8 +5,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/Dendyator/1/hw12_13_14_15_calendar/internal/storage" //nolint
-	"github.com/google/uuid"                                         //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
 )
 
 type MockStorage struct {
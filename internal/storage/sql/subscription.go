@@ -0,0 +1,78 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+	"github.com/google/uuid"                         //nolint
+)
+
+const selectSubscriptionColumns = "id, user_id, calendar_id, url, refresh_seconds, etag, last_modified," +
+	" next_fetch_at, last_error, created_at"
+
+func (s *Storage) CreateSubscription(ctx context.Context, subscription storage.Subscription) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO subscriptions (id, user_id, calendar_id, url, refresh_seconds, next_fetch_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := s.DB.ExecContext(ctx, query, subscription.ID, subscription.UserID, subscription.CalendarID,
+		subscription.URL, subscription.RefreshSeconds, subscription.NextFetchAt)
+	return wrapError(err)
+}
+
+func (s *Storage) GetSubscription(ctx context.Context, id uuid.UUID) (storage.Subscription, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var subscription storage.Subscription
+	query := "SELECT " + selectSubscriptionColumns + " FROM subscriptions WHERE id = $1"
+	err := s.DB.GetContext(ctx, &subscription, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return subscription, storage.ErrSubscriptionNotFound
+	}
+	return subscription, err
+}
+
+func (s *Storage) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]storage.Subscription, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	subscriptions := []storage.Subscription{}
+	query := "SELECT " + selectSubscriptionColumns + " FROM subscriptions WHERE user_id = $1 ORDER BY created_at"
+	err := s.DB.SelectContext(ctx, &subscriptions, query, userID)
+	return subscriptions, err
+}
+
+func (s *Storage) ListDueSubscriptions(ctx context.Context, now time.Time) ([]storage.Subscription, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	subscriptions := []storage.Subscription{}
+	query := "SELECT " + selectSubscriptionColumns +
+		" FROM subscriptions WHERE next_fetch_at <= $1 ORDER BY next_fetch_at"
+	err := s.DB.SelectContext(ctx, &subscriptions, query, now)
+	return subscriptions, err
+}
+
+func (s *Storage) UpdateSubscriptionState(ctx context.Context, id uuid.UUID, state storage.SubscriptionState,
+) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE subscriptions SET etag = $2, last_modified = $3, next_fetch_at = $4, last_error = $5
+              WHERE id = $1`
+	return expectRow(storage.ErrSubscriptionNotFound)(s.DB.ExecContext(ctx, query, id, state.ETag,
+		state.LastModified, state.NextFetchAt, state.LastError))
+}
+
+func (s *Storage) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return expectRow(storage.ErrSubscriptionNotFound)(s.DB.ExecContext(ctx,
+		"DELETE FROM subscriptions WHERE id = $1", id))
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid" //nolint
+)
+
+// Subscription is an external iCalendar feed whose events are kept in a
+// calendar. The events are matched by UID, so the calendar should be
+// dedicated to the feed.
+type Subscription struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"userId"`
+	CalendarID uuid.UUID `db:"calendar_id" json:"calendarId"`
+	URL        string    `db:"url" json:"url"`
+	// RefreshSeconds is how often the feed is fetched.
+	RefreshSeconds int64 `db:"refresh_seconds" json:"refreshSeconds"`
+	SubscriptionState
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+}
+
+// SubscriptionState is what the fetcher remembers of a subscription between
+// fetches.
+type SubscriptionState struct {
+	// ETag and LastModified are the validators of the last feed synced, sent
+	// back to make the next fetch conditional.
+	ETag         string `db:"etag" json:"-"`
+	LastModified string `db:"last_modified" json:"-"`
+	// NextFetchAt is when the feed is due to be fetched again.
+	NextFetchAt time.Time `db:"next_fetch_at" json:"nextFetchAt"`
+	// LastError is the error of the last fetch, or empty if it succeeded.
+	LastError string `db:"last_error" json:"lastError,omitempty"`
+}
+
+// RefreshInterval returns RefreshSeconds as a duration.
+func (s Subscription) RefreshInterval() time.Duration {
+	return time.Duration(s.RefreshSeconds) * time.Second
+}
+
+type SubscriptionInterface interface {
+	CreateSubscription(ctx context.Context, subscription Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (Subscription, error)
+	// ListSubscriptions returns the subscriptions owned by the user.
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]Subscription, error)
+	// ListDueSubscriptions returns the subscriptions of every user that are
+	// due to be fetched at now, the most overdue first.
+	ListDueSubscriptions(ctx context.Context, now time.Time) ([]Subscription, error)
+	UpdateSubscriptionState(ctx context.Context, id uuid.UUID, state SubscriptionState) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoadLocation resolves an IANA time zone name. The empty name is UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, name)
+	}
+	return location, nil
+}
+
+// locations caches the zones series recur in, which time.LoadLocation
+// would read from disk on every expansion.
+var locations sync.Map
+
+func cachedLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// DayBounds returns [start, end) of the day containing t in loc. A day
+// spanning a DST transition is 23 or 25 hours long.
+func DayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(orUTC(loc))
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// WeekBounds returns [start, end) of the ISO week (Monday to Sunday)
+// containing t in loc.
+func WeekBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	start, _ := DayBounds(t, loc)
+	offset := (int(start.Weekday()) + 6) % 7 // days since Monday
+	start = start.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// MonthBounds returns [start, end) of the month containing t in loc.
+func MonthBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(orUTC(loc))
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+func orUTC(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"  //nolint
+	"github.com/stretchr/testify/require" //nolint
+)
+
+func TestBounds_DST(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Clocks jump from 02:00 to 03:00 on Sunday, 31 March 2024.
+	start, end := DayBounds(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), berlin)
+	assert.Equal(t, time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC), start.UTC())
+	assert.Equal(t, 23*time.Hour, end.Sub(start))
+
+	start, end = WeekBounds(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), berlin)
+	assert.Equal(t, time.Date(2024, 3, 25, 0, 0, 0, 0, berlin), start)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, berlin), end)
+	assert.Equal(t, 7*24*time.Hour-time.Hour, end.Sub(start))
+
+	start, end = MonthBounds(time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC), berlin)
+	assert.Equal(t, time.Date(2024, 9, 30, 22, 0, 0, 0, time.UTC), start.UTC())
+	assert.Equal(t, time.Date(2024, 10, 31, 23, 0, 0, 0, time.UTC), end.UTC())
+}
+
+func TestBounds_DayInZone(t *testing.T) {
+	moscow, err := LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// 22:30 UTC on Sunday is already Monday in Moscow.
+	instant := time.Date(2024, 1, 7, 22, 30, 0, 0, time.UTC)
+	start, _ := DayBounds(instant, moscow)
+	assert.Equal(t, time.Date(2024, 1, 7, 21, 0, 0, 0, time.UTC), start.UTC())
+	start, _ = WeekBounds(instant, moscow)
+	assert.Equal(t, time.Monday, start.Weekday())
+	assert.Equal(t, time.Date(2024, 1, 7, 21, 0, 0, 0, time.UTC), start.UTC())
+
+	start, _ = WeekBounds(instant, nil)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), start)
+
+	_, err = LoadLocation("Mars/Olympus")
+	assert.ErrorIs(t, err, ErrInvalidTimeZone)
+}
+
+func TestOccurrences_TimeZone(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Stored as UTC, as the SQL storage reads it back; clocks jump on 31 March.
+	start := time.Date(2024, 3, 25, 10, 0, 0, 0, berlin).UTC()
+	event := Event{
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		RRule:     "FREQ=WEEKLY;COUNT=2",
+		TimeZone:  "Europe/Berlin",
+	}
+	occurrences, err := event.Occurrences(start, start.AddDate(0, 0, 14))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 2)
+	for _, occurrence := range occurrences {
+		assert.Equal(t, 10, occurrence.StartTime.In(berlin).Hour())
+	}
+	assert.Equal(t, 7*24*time.Hour-time.Hour, occurrences[1].StartTime.Sub(occurrences[0].StartTime))
+
+	event.TimeZone = "Mars/Olympus"
+	_, err = event.Occurrences(start, start.AddDate(0, 0, 14))
+	assert.Error(t, err)
+}
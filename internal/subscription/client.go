@@ -0,0 +1,78 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// maxRedirects bounds the redirects followed when fetching a feed.
+const maxRedirects = 5
+
+// ErrForbiddenAddress is returned for a feed that resolves to an address
+// inside the network the scheduler runs in.
+var ErrForbiddenAddress = errors.New("feed address is not public")
+
+// reserved are the non-public ranges netip does not count as private:
+// "this network" and the carrier-grade NAT range (RFC 6598).
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// NewClient returns the HTTP client feeds are fetched with. Feed URLs come
+// from users, so the client refuses to connect to loopback, private,
+// link-local and unspecified addresses. The check runs on the address
+// actually dialled, after DNS resolution and on every redirect, so neither
+// a DNS name nor a redirect can point it inside the network.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkAddress}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would be dialled instead of the feed, bypassing the check.
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s URL", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// checkAddress is a net.Dialer Control function rejecting non-public
+// addresses.
+func checkAddress(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reserved {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
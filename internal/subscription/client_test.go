@@ -0,0 +1,78 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New()})
+
+	holidays := &feed{}
+	holidays.set(`"1"`, newYear)
+	server := httptest.NewServer(holidays)
+	defer server.Close()
+
+	calendar, err := application.CreateCalendar(ctx, storage.Calendar{Name: "Holidays"})
+	require.NoError(t, err)
+	subscription, err := application.CreateSubscription(ctx, storage.Subscription{CalendarID: calendar.ID,
+		URL: server.URL})
+	require.NoError(t, err)
+
+	fetcher := NewFetcher(application, store, NewClient(time.Second), logg)
+	require.NoError(t, fetcher.SyncDue(ctx, time.Now()))
+	stored, err := store.GetSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Contains(t, stored.LastError, ErrForbiddenAddress.Error())
+	page, err := application.ListEvents(ctx, storage.EventFilter{CalendarIDs: []uuid.UUID{calendar.ID}})
+	require.NoError(t, err)
+	assert.Empty(t, page.Events)
+}
+
+func TestClientRedirects(t *testing.T) {
+	client := NewClient(time.Second)
+	redirect := func(target string, hops int) error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+		require.NoError(t, err)
+		return client.CheckRedirect(req, make([]*http.Request, hops))
+	}
+	assert.NoError(t, redirect("https://example.com/holidays.ics", 1))
+	assert.Error(t, redirect("file:///etc/passwd", 1))
+	assert.Error(t, redirect("https://example.com/holidays.ics", maxRedirects))
+}
+
+func TestIsPublic(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		assert.Equal(t, public, isPublic(netip.MustParseAddr(address)), address)
+	}
+}
@@ -0,0 +1,118 @@
+// Package subscription keeps subscribed calendars in sync with their
+// external iCalendar feeds.
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"     //nolint
+	"github.com/Dendyator/calendar/internal/storage" //nolint
+)
+
+// maxFeedSize bounds the feeds read, like the size of an iCalendar import.
+const maxFeedSize = 10 << 20
+
+// Fetcher fetches the feeds of due subscriptions with conditional GETs and
+// syncs them into their calendars.
+type Fetcher struct {
+	app    *app.App
+	store  storage.SubscriptionInterface
+	client *http.Client
+	logger app.Logger
+}
+
+func NewFetcher(application *app.App, store storage.SubscriptionInterface, client *http.Client,
+	logger app.Logger,
+) *Fetcher {
+	return &Fetcher{app: application, store: store, client: client, logger: logger}
+}
+
+// SyncDue syncs every subscription due at now. A subscription that fails is
+// recorded with its error and retried after its refresh interval; only
+// storage errors stop the run.
+func (f *Fetcher) SyncDue(ctx context.Context, now time.Time) error {
+	subscriptions, err := f.store.ListDueSubscriptions(ctx, now)
+	if err != nil {
+		return fmt.Errorf("list due subscriptions: %w", err)
+	}
+	for _, subscription := range subscriptions {
+		if err := f.Sync(ctx, subscription, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync fetches the feed of the subscription and syncs it unless it is not
+// modified since the last sync.
+func (f *Fetcher) Sync(ctx context.Context, subscription storage.Subscription, now time.Time) error {
+	state := subscription.SubscriptionState
+	state.NextFetchAt = now.Add(subscription.RefreshInterval())
+	state.LastError = ""
+	body, validators, err := f.fetch(ctx, subscription)
+	switch {
+	case err != nil:
+		state.LastError = err.Error()
+	case body == nil:
+		f.logger.Info("Feed of subscription ", subscription.ID, " not modified")
+	default:
+		if _, err := f.app.SyncSubscription(ctx, subscription, bytes.NewReader(body)); err != nil {
+			state.LastError = err.Error()
+		} else {
+			state.ETag, state.LastModified = validators.ETag, validators.LastModified
+		}
+	}
+	if state.LastError != "" {
+		f.logger.Error("Failed to sync subscription ", subscription.ID, ": ", state.LastError)
+	}
+	if err := f.store.UpdateSubscriptionState(ctx, subscription.ID, state); err != nil {
+		return fmt.Errorf("update subscription %s: %w", subscription.ID, err)
+	}
+	return nil
+}
+
+// fetch GETs the feed, conditionally on the validators of the last sync. It
+// returns a nil body if the feed is not modified.
+func (f *Fetcher) fetch(ctx context.Context, subscription storage.Subscription,
+) ([]byte, storage.SubscriptionState, error) {
+	var validators storage.SubscriptionState
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscription.URL, nil)
+	if err != nil {
+		return nil, validators, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+	if subscription.ETag != "" {
+		req.Header.Set("If-None-Match", subscription.ETag)
+	}
+	if subscription.LastModified != "" {
+		req.Header.Set("If-Modified-Since", subscription.LastModified)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, validators, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, validators, nil
+	default:
+		return nil, validators, fmt.Errorf("fetch %s: unexpected status %s", subscription.URL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, validators, fmt.Errorf("fetch %s: %w", subscription.URL, err)
+	}
+	if len(body) > maxFeedSize {
+		return nil, validators, fmt.Errorf("fetch %s: feed exceeds %d bytes", subscription.URL, maxFeedSize)
+	}
+	validators.ETag = resp.Header.Get("ETag")
+	validators.LastModified = resp.Header.Get("Last-Modified")
+	return body, validators, nil
+}
@@ -0,0 +1,155 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dendyator/calendar/internal/app"                          //nolint
+	"github.com/Dendyator/calendar/internal/auth"                         //nolint
+	"github.com/Dendyator/calendar/internal/logger"                       //nolint
+	"github.com/Dendyator/calendar/internal/storage"                      //nolint
+	memorystorage "github.com/Dendyator/calendar/internal/storage/memory" //nolint
+	"github.com/google/uuid"                                              //nolint
+	"github.com/stretchr/testify/assert"                                  //nolint
+	"github.com/stretchr/testify/require"                                 //nolint
+)
+
+const (
+	newYear = "BEGIN:VEVENT\r\nUID:new-year@holidays\r\nDTSTART;VALUE=DATE:20250101\r\n" +
+		"SUMMARY:New Year\r\nEND:VEVENT\r\n"
+	christmas = "BEGIN:VEVENT\r\nUID:christmas@holidays\r\nDTSTART;VALUE=DATE:20250107\r\n" +
+		"SUMMARY:Christmas\r\nEND:VEVENT\r\n"
+)
+
+// feed serves a calendar of the given VEVENTs with an ETag, answering 304
+// to a matching If-None-Match.
+type feed struct {
+	mu          sync.Mutex
+	events      string
+	etag        string
+	status      int
+	conditional int
+}
+
+func (f *feed) set(etag string, events ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.etag, f.events = etag, strings.Join(events, "")
+}
+
+func (f *feed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	if r.Header.Get("If-None-Match") != "" {
+		f.conditional++
+		if r.Header.Get("If-None-Match") == f.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Write([]byte("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + f.events + "END:VCALENDAR\r\n"))
+}
+
+func TestFetcher(t *testing.T) {
+	logg := logger.New("info")
+	store := memorystorage.New()
+	application := app.New(logg, store)
+	owner := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: owner})
+
+	holidays := &feed{}
+	holidays.set(`"1"`, newYear, christmas)
+	server := httptest.NewServer(holidays)
+	defer server.Close()
+
+	calendar, err := application.CreateCalendar(ctx, storage.Calendar{Name: "Holidays", TimeZone: "Europe/Moscow"})
+	require.NoError(t, err)
+	subscription, err := application.CreateSubscription(ctx, storage.Subscription{CalendarID: calendar.ID,
+		URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6*60*60), subscription.RefreshSeconds)
+	// A hand-made event without a UID is not touched by syncs.
+	_, err = application.CreateEvent(ctx, storage.Event{CalendarID: calendar.ID, Title: "Day off",
+		StartTime: time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), EndTime: time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+
+	fetcher := NewFetcher(application, store, server.Client(), logg)
+	titles := func() []string {
+		t.Helper()
+		page, err := application.ListEvents(ctx, storage.EventFilter{CalendarIDs: []uuid.UUID{calendar.ID}})
+		require.NoError(t, err)
+		var titles []string
+		for _, event := range page.Events {
+			titles = append(titles, event.Title)
+		}
+		return titles
+	}
+	state := func() storage.SubscriptionState {
+		t.Helper()
+		subscription, err := store.GetSubscription(ctx, subscription.ID)
+		require.NoError(t, err)
+		return subscription.SubscriptionState
+	}
+
+	now := time.Now()
+	require.NoError(t, fetcher.SyncDue(ctx, now))
+	assert.Equal(t, []string{"New Year", "Day off", "Christmas"}, titles())
+	assert.Equal(t, `"1"`, state().ETag)
+	assert.Equal(t, now.Add(6*time.Hour), state().NextFetchAt)
+
+	// Nothing is due before the refresh interval.
+	holidays.set(`"2"`, newYear)
+	require.NoError(t, fetcher.SyncDue(ctx, now.Add(time.Hour)))
+	assert.Len(t, titles(), 3)
+
+	// Events that left the feed are deleted.
+	now = now.Add(6 * time.Hour)
+	require.NoError(t, fetcher.SyncDue(ctx, now))
+	assert.Equal(t, []string{"New Year", "Day off"}, titles())
+	assert.Equal(t, 1, holidays.conditional)
+
+	// An unchanged feed is not downloaded again.
+	now = now.Add(6 * time.Hour)
+	require.NoError(t, fetcher.SyncDue(ctx, now))
+	assert.Equal(t, 2, holidays.conditional)
+	assert.Equal(t, `"2"`, state().ETag)
+	assert.Empty(t, state().LastError)
+
+	// Failures are recorded and keep the events and validators.
+	holidays.status = http.StatusInternalServerError
+	now = now.Add(6 * time.Hour)
+	require.NoError(t, fetcher.SyncDue(ctx, now))
+	assert.Contains(t, state().LastError, "500")
+	assert.Equal(t, `"2"`, state().ETag)
+	assert.Len(t, titles(), 2)
+}
+
+func TestCreateSubscription_Invalid(t *testing.T) {
+	application := app.New(logger.New("info"), memorystorage.New())
+	ctx := auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New()})
+	calendar, err := application.CreateCalendar(ctx, storage.Calendar{Name: "Holidays"})
+	require.NoError(t, err)
+
+	for _, subscription := range []storage.Subscription{
+		{URL: "https://example.com/holidays.ics"},
+		{CalendarID: calendar.ID, URL: "file:///etc/passwd"},
+		{CalendarID: calendar.ID, URL: "/holidays.ics"},
+		{CalendarID: calendar.ID, URL: "https://example.com/holidays.ics", RefreshSeconds: 60},
+	} {
+		_, err := application.CreateSubscription(ctx, subscription)
+		assert.ErrorIs(t, err, storage.ErrInvalidSubscription, subscription.URL)
+	}
+	_, err = application.CreateSubscription(auth.WithIdentity(context.Background(), auth.Identity{UserID: uuid.New()}),
+		storage.Subscription{CalendarID: calendar.ID, URL: "https://example.com/holidays.ics"})
+	assert.ErrorIs(t, err, storage.ErrCalendarNotFound)
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN rrule TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS rrule;
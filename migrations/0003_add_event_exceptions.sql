@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN exdates TIMESTAMP[] NOT NULL DEFAULT '{}';
+ALTER TABLE events ADD COLUMN recurring_event_id UUID REFERENCES events (id) ON DELETE CASCADE;
+ALTER TABLE events ADD COLUMN recurrence_id TIMESTAMP;
+CREATE UNIQUE INDEX IF NOT EXISTS events_recurrence_idx ON events (recurring_event_id, recurrence_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_recurrence_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS recurrence_id;
+ALTER TABLE events DROP COLUMN IF EXISTS recurring_event_id;
+ALTER TABLE events DROP COLUMN IF EXISTS exdates;
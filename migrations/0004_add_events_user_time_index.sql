@@ -0,0 +1,6 @@
+-- +goose Up
+-- Supports the per-user overlap check run on every event write.
+CREATE INDEX IF NOT EXISTS events_user_start_idx ON events (user_id, start_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_user_start_idx;
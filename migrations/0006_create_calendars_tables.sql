@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS calendars (
+    id UUID PRIMARY KEY,
+    owner_id UUID NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS calendars_owner_idx ON calendars (owner_id);
+
+CREATE TABLE IF NOT EXISTS calendar_acl (
+    calendar_id UUID NOT NULL REFERENCES calendars (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+    PRIMARY KEY (calendar_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS calendar_acl_user_idx ON calendar_acl (user_id);
+
+-- Every user with events gets a default calendar holding them.
+ALTER TABLE events ADD COLUMN calendar_id UUID REFERENCES calendars (id) ON DELETE CASCADE;
+INSERT INTO calendars (id, owner_id, name)
+SELECT gen_random_uuid(), user_id, 'Default' FROM (SELECT DISTINCT user_id FROM events) AS users;
+UPDATE events SET calendar_id = calendars.id FROM calendars WHERE calendars.owner_id = events.user_id;
+ALTER TABLE events ALTER COLUMN calendar_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS events_calendar_start_idx ON events (calendar_id, start_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_calendar_start_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS calendar_id;
+DROP TABLE IF EXISTS calendar_acl;
+DROP TABLE IF EXISTS calendars;
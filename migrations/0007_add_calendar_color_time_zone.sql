@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS color TEXT NOT NULL DEFAULT '';
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+ALTER TABLE calendars DROP COLUMN IF EXISTS time_zone;
+ALTER TABLE calendars DROP COLUMN IF EXISTS color;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Times were written as UTC wall clock; reinterpret them as instants.
+SET LOCAL TIME ZONE 'UTC';
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN recurrence_id TYPE TIMESTAMPTZ USING recurrence_id AT TIME ZONE 'UTC',
+    ALTER COLUMN exdates DROP DEFAULT,
+    ALTER COLUMN exdates TYPE TIMESTAMPTZ[] USING exdates::TIMESTAMPTZ[],
+    ALTER COLUMN exdates SET DEFAULT '{}';
+ALTER TABLE api_keys
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN last_used_at TYPE TIMESTAMPTZ USING last_used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE calendars
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+SET LOCAL TIME ZONE 'UTC';
+ALTER TABLE calendars
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE api_keys
+    ALTER COLUMN expires_at TYPE TIMESTAMP USING expires_at AT TIME ZONE 'UTC',
+    ALTER COLUMN last_used_at TYPE TIMESTAMP USING last_used_at AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN recurrence_id TYPE TIMESTAMP USING recurrence_id AT TIME ZONE 'UTC',
+    ALTER COLUMN exdates DROP DEFAULT,
+    ALTER COLUMN exdates TYPE TIMESTAMP[] USING exdates::TIMESTAMP[],
+    ALTER COLUMN exdates SET DEFAULT '{}';
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Serves the (start_time, id) keyset pagination of ListEvents.
+CREATE INDEX IF NOT EXISTS events_start_id_idx ON events (start_time, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_start_id_idx;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- The 'simple' configuration lowercases words without language-specific
+-- stemming, since titles mix Russian and English.
+ALTER TABLE events ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS events_search_idx ON events USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS events_search_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS search_vector;
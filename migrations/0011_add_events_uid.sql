@@ -0,0 +1,8 @@
+-- +goose Up
+-- iCalendar UID of imported events; overrides and API-created events keep ''.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS uid TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS events_calendar_uid_idx ON events (calendar_id, uid) WHERE uid <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS events_calendar_uid_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS uid;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS feeds (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    -- NULL for a feed of every calendar the user can read.
+    calendar_id UUID REFERENCES calendars (id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    secret_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS feeds_user_idx ON feeds (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS feeds;
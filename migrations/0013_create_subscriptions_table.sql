@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    calendar_id UUID NOT NULL REFERENCES calendars (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    refresh_seconds BIGINT NOT NULL CHECK (refresh_seconds > 0),
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    next_fetch_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS subscriptions_user_idx ON subscriptions (user_id);
+CREATE INDEX IF NOT EXISTS subscriptions_next_fetch_idx ON subscriptions (next_fetch_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscriptions;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Reminder offsets in seconds before the start; NULL uses the owner's default.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS reminders BIGINT[];
+CREATE TABLE IF NOT EXISTS reminder_defaults (
+    user_id UUID PRIMARY KEY,
+    reminders BIGINT[] NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS reminder_defaults;
+ALTER TABLE events DROP COLUMN IF EXISTS reminders;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- The ledger of reminders: one row per (event, occurrence, offset), so that
+-- each reminder is published and delivered once.
+CREATE TABLE IF NOT EXISTS notifications (
+    event_id UUID NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    occurrence TIMESTAMPTZ NOT NULL,
+    offset_seconds BIGINT NOT NULL,
+    user_id UUID NOT NULL,
+    title TEXT NOT NULL,
+    fire_at TIMESTAMPTZ NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending'
+        CHECK (state IN ('pending', 'published', 'sending', 'delivered', 'failed')),
+    details TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, occurrence, offset_seconds)
+);
+CREATE INDEX IF NOT EXISTS notifications_pending_idx ON notifications (fire_at) WHERE state = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
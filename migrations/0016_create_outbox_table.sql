@@ -0,0 +1,14 @@
+-- +goose Up
+-- Messages written in the same transaction as the changes they report and
+-- relayed to RabbitMQ afterwards.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    topic TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_unsent_idx ON outbox (id) WHERE sent_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Leases elect one leader among replicas, such as the scheduler's.
+CREATE TABLE IF NOT EXISTS leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS leases;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- next_reminder_at is when the first reminder of an event that is not in the
+-- notification ledger yet fires, or NULL if none will. Every write of the
+-- event resets it to -infinity, so the scheduler plans the event again;
+-- updated_at tells the scheduler whether the event was written after it
+-- listed it.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE events ADD COLUMN IF NOT EXISTS next_reminder_at TIMESTAMPTZ DEFAULT '-infinity';
+CREATE INDEX IF NOT EXISTS events_next_reminder_idx ON events (next_reminder_at)
+    WHERE next_reminder_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS events_next_reminder_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS next_reminder_at;
+ALTER TABLE events DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- time_zone is the IANA zone a series recurs in. TIMESTAMPTZ keeps only the
+-- instant, so without it a series imported with a TZID would recur in UTC
+-- and shift by an hour across DST changes.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS time_zone;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- attempts counts the claims by senders, so that failed reminders are
+-- retried a bounded number of times; the index finds the sending and failed
+-- ones the scheduler publishes again.
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS notifications_retry_idx ON notifications (updated_at)
+    WHERE state IN ('sending', 'failed');
+
+-- +goose Down
+DROP INDEX IF EXISTS notifications_retry_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS attempts;
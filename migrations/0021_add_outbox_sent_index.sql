@@ -0,0 +1,6 @@
+-- +goose Up
+-- The scheduler's cleanup deletes the messages relayed long ago.
+CREATE INDEX IF NOT EXISTS outbox_sent_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS outbox_sent_idx;